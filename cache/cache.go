@@ -0,0 +1,136 @@
+// Package cache stores analysis results on disk so later runs can skip unchanged packages.
+// Entries are addressed by a hash of every input of the analysis, so an entry is never stale:
+// when an input changes, the run looks up a different key. Clean removes the entries.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// dirName is the directory of the linter cache within the user cache directory
+const dirName = "azurerm-linter"
+
+// Cache is a directory of JSON entries
+type Cache struct {
+	dir string
+}
+
+// DefaultDir returns the cache directory within the user cache directory
+func DefaultDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the user cache directory: %w", err)
+	}
+	return filepath.Join(dir, dirName), nil
+}
+
+// Open opens the cache in dir, creating the directory if needed
+func Open(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return &Cache{dir: dir}, nil
+}
+
+// Dir returns the directory of the cache
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Get decodes the entry of key into v. It reports false when there is no readable entry.
+func (c *Cache) Get(key string, v interface{}) bool {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, v) == nil
+}
+
+// Put stores v as the entry of key. The entry is written to a temporary file first,
+// so concurrent runs never read a partial entry.
+func (c *Cache) Put(key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+	if writeErr == nil {
+		writeErr = closeErr
+	}
+	if writeErr == nil {
+		writeErr = os.Rename(tmp.Name(), path)
+	}
+	if writeErr != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", writeErr)
+	}
+	return nil
+}
+
+// path spreads the entries over subdirectories named after the first byte of their key
+func (c *Cache) path(key string) string {
+	if len(key) < 2 {
+		return filepath.Join(c.dir, key+".json")
+	}
+	return filepath.Join(c.dir, key[:2], key+".json")
+}
+
+// Clean removes the cache in dir
+func Clean(dir string) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to remove cache directory: %w", err)
+	}
+	return nil
+}
+
+// Hash computes a key from the inputs added to it
+type Hash struct {
+	h hash.Hash
+}
+
+// NewHash creates an empty Hash
+func NewHash() *Hash {
+	return &Hash{h: sha256.New()}
+}
+
+// Add adds inputs to the hash. Each input is length-prefixed, so ("ab", "c") and ("a", "bc") differ.
+func (h *Hash) Add(inputs ...string) {
+	for _, input := range inputs {
+		h.h.Write([]byte(strconv.Itoa(len(input))))
+		h.h.Write([]byte{':'})
+		h.h.Write([]byte(input))
+	}
+}
+
+// AddFile adds the content of a file to the hash
+func (h *Hash) AddFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	h.Add(string(data))
+	return nil
+}
+
+// Sum returns the key of the inputs added so far
+func (h *Hash) Sum() string {
+	return hex.EncodeToString(h.h.Sum(nil))
+}
@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPutAndGet(t *testing.T) {
+	c, err := Open(filepath.Join(t.TempDir(), "cache"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	key := NewHash()
+	key.Add("package", "content")
+
+	var got []string
+	if c.Get(key.Sum(), &got) {
+		t.Fatalf("Get() found an entry in an empty cache")
+	}
+	if err := c.Put(key.Sum(), []string{"finding"}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if !c.Get(key.Sum(), &got) || len(got) != 1 || got[0] != "finding" {
+		t.Fatalf("Get() = %v, want the stored entry", got)
+	}
+
+	if err := Clean(c.Dir()); err != nil {
+		t.Fatalf("Clean() error = %v", err)
+	}
+	if _, err := os.Stat(c.Dir()); !os.IsNotExist(err) {
+		t.Fatalf("cache directory still exists after Clean(): %v", err)
+	}
+}
+
+func TestHashSeparatesInputs(t *testing.T) {
+	a, b := NewHash(), NewHash()
+	a.Add("ab", "c")
+	b.Add("a", "bc")
+	if a.Sum() == b.Sum() {
+		t.Fatalf("Sum() is the same for differently split inputs")
+	}
+}
@@ -0,0 +1,18 @@
+// Command azurerm-linter-vet runs the AzureRM provider checks as a go vet tool, reusing the
+// go build cache and analyzing one package at a time:
+//
+//	go vet -vettool=$(which azurerm-linter-vet) ./internal/services/...
+//
+// Every diagnostic is reported, as the change filtering of azurerm-linter is not available
+// to vet tools. Per-check settings are flags prefixed by the check ID, for example
+// -AZBP005.license-header.
+package main
+
+import (
+	"github.com/qixialu/azurerm-linter/passes"
+	"golang.org/x/tools/go/analysis/unitchecker"
+)
+
+func main() {
+	unitchecker.Main(passes.AllChecks...)
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// baselineFormatVersion is bumped when the fingerprint inputs change
+const baselineFormatVersion = 3
+
+// Baseline is the content of a --write-baseline file
+type Baseline struct {
+	Version       int             `json:"version"`
+	LinterVersion string          `json:"linter_version"`
+	Findings      []BaselineEntry `json:"findings"`
+}
+
+// BaselineEntry records one pre-existing finding. Only Fingerprint, see lint.AssignFingerprints,
+// is used for matching; the other fields make the file reviewable.
+type BaselineEntry struct {
+	Fingerprint string `json:"fingerprint"`
+	CheckID     string `json:"check_id"`
+	Package     string `json:"package"`
+	Scope       string `json:"scope,omitempty"`
+	Path        string `json:"path"`
+	Message     string `json:"message"`
+}
+
+// BaselineSummary describes how the baseline affected the reported findings
+type BaselineSummary struct {
+	File       string `json:"file"`
+	Suppressed int    `json:"suppressed"`
+	Fixed      int    `json:"fixed"`
+}
+
+// writeBaseline records findings in a baseline file
+func writeBaseline(path, root string, findings []Finding) error {
+	baseline := Baseline{
+		Version:       baselineFormatVersion,
+		LinterVersion: ShortVersion(),
+		Findings:      make([]BaselineEntry, 0, len(findings)),
+	}
+
+	for _, f := range findings {
+		baseline.Findings = append(baseline.Findings, BaselineEntry{
+			Fingerprint: f.Fingerprint,
+			CheckID:     f.CheckID,
+			Package:     f.PkgPath,
+			Scope:       f.Scope,
+			Path:        displayPath(root, f.Path),
+			Message:     stripANSI(f.Message),
+		})
+	}
+
+	sort.SliceStable(baseline.Findings, func(i, j int) bool {
+		a, b := baseline.Findings[i], baseline.Findings[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.CheckID != b.CheckID {
+			return a.CheckID < b.CheckID
+		}
+		return a.Fingerprint < b.Fingerprint
+	})
+
+	data, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal baseline: %w", err)
+	}
+
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	return nil
+}
+
+// readBaseline loads a baseline file written by writeBaseline
+func readBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+
+	var baseline Baseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline %s: %w", path, err)
+	}
+	if baseline.Version != baselineFormatVersion {
+		return nil, fmt.Errorf("baseline %s has format version %d, expected %d; regenerate it with --write-baseline",
+			path, baseline.Version, baselineFormatVersion)
+	}
+
+	return &baseline, nil
+}
+
+// applyBaseline drops findings recorded in the baseline. Identical findings in one scope have
+// fingerprints of their own, so a finding added next to a recorded one is still reported.
+// Entries of the analyzed packages that no longer match any finding are counted as fixed.
+// Filtered runs only report findings in the changes, so they count none as fixed.
+func applyBaseline(baseline *Baseline, findings []Finding, analyzed []string, filtered bool) ([]Finding, BaselineSummary) {
+	remaining := make(map[string]int, len(baseline.Findings))
+	for _, entry := range baseline.Findings {
+		remaining[entry.Fingerprint]++
+	}
+
+	var summary BaselineSummary
+	var kept []Finding
+	for _, f := range findings {
+		if remaining[f.Fingerprint] > 0 {
+			remaining[f.Fingerprint]--
+			summary.Suppressed++
+			continue
+		}
+		kept = append(kept, f)
+	}
+
+	if filtered {
+		return kept, summary
+	}
+	packages := make(map[string]bool, len(analyzed))
+	for _, path := range analyzed {
+		packages[path] = true
+	}
+	for _, entry := range baseline.Findings {
+		if packages[entry.Package] && remaining[entry.Fingerprint] > 0 {
+			remaining[entry.Fingerprint]--
+			summary.Fixed++
+		}
+	}
+
+	return kept, summary
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/qixialu/azurerm-linter/lint"
+)
+
+func TestBaselineRoundTripSuppressesExistingAndCountsFixed(t *testing.T) {
+	root := t.TempDir()
+	baselinePath := filepath.Join(t.TempDir(), "baseline.json")
+	file := filepath.Join(root, "internal", "services", "cdn", "cdn_profile_resource.go")
+
+	existing := []Finding{
+		{CheckID: "AZRE001", PkgPath: "example.com/cdn", Scope: "CdnProfileResource.Create", Path: file, Line: 10, Message: "AZRE001: fixed error strings should use errors.New()\n"},
+		{CheckID: "AZRE001", PkgPath: "example.com/cdn", Scope: "CdnProfileResource.Create", Path: file, Line: 20, Message: "AZRE001: fixed error strings should use errors.New()\n"},
+		{CheckID: "AZBP001", PkgPath: "example.com/cdn", Scope: "CdnProfileResource.Arguments#name", Path: file, Line: 30, Message: "AZBP001: string argument \"name\" must have ValidateFunc\n"},
+	}
+	lint.AssignFingerprints(root, existing)
+	if err := writeBaseline(baselinePath, root, existing); err != nil {
+		t.Fatalf("writeBaseline() error = %v", err)
+	}
+
+	baseline, err := readBaseline(baselinePath)
+	if err != nil {
+		t.Fatalf("readBaseline() error = %v", err)
+	}
+	if baseline.Findings[0].Path != "internal/services/cdn/cdn_profile_resource.go" {
+		t.Fatalf("Path = %q, want repository-relative path", baseline.Findings[0].Path)
+	}
+
+	// Lines shifted by an unrelated edit, one AZRE001 finding gained a sibling,
+	// and the AZBP001 finding was fixed.
+	current := []Finding{existing[0], existing[1], existing[1]}
+	for i := range current {
+		current[i].Line += 5 * (i + 1)
+	}
+	lint.AssignFingerprints(root, current)
+
+	kept, summary := applyBaseline(baseline, current, []string{"example.com/cdn"}, false)
+	if len(kept) != 1 {
+		t.Fatalf("len(kept) = %d, want 1 new finding", len(kept))
+	}
+	if summary.Suppressed != 2 || summary.Fixed != 1 {
+		t.Fatalf("summary = %+v, want 2 suppressed and 1 fixed", summary)
+	}
+
+	// Entries of packages that were not analyzed are not fixed
+	if _, summary := applyBaseline(baseline, nil, []string{"example.com/dns"}, false); summary.Fixed != 0 {
+		t.Fatalf("Fixed = %d for a run over another package, want 0", summary.Fixed)
+	}
+	// Filtered runs only report findings in the changes
+	if _, summary := applyBaseline(baseline, current, []string{"example.com/cdn"}, true); summary.Suppressed != 2 || summary.Fixed != 0 {
+		t.Fatalf("summary = %+v for a filtered run, want 2 suppressed and none fixed", summary)
+	}
+}
+
+func TestBaselineTellsApartFindingsOfFilesInOnePackage(t *testing.T) {
+	root := t.TempDir()
+	baselinePath := filepath.Join(t.TempDir(), "baseline.json")
+	finding := func(name string) Finding {
+		return Finding{CheckID: "AZBP005", PkgPath: "example.com/cdn", Path: filepath.Join(root, "internal", "services", "cdn", name), Line: 1, Message: "AZBP005: Go file missing copyright header"}
+	}
+
+	existing := []Finding{finding("client.go")}
+	lint.AssignFingerprints(root, existing)
+	if err := writeBaseline(baselinePath, root, existing); err != nil {
+		t.Fatalf("writeBaseline() error = %v", err)
+	}
+	baseline, err := readBaseline(baselinePath)
+	if err != nil {
+		t.Fatalf("readBaseline() error = %v", err)
+	}
+
+	current := []Finding{finding("client.go"), finding("resource.go")}
+	lint.AssignFingerprints(root, current)
+	kept, summary := applyBaseline(baseline, current, []string{"example.com/cdn"}, false)
+	if len(kept) != 1 || kept[0].Path != finding("resource.go").Path {
+		t.Fatalf("kept = %+v, want the finding of resource.go", kept)
+	}
+	if summary.Suppressed != 1 {
+		t.Fatalf("Suppressed = %d, want 1", summary.Suppressed)
+	}
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/qixialu/azurerm-linter/cache"
+)
+
+// openCache opens the result cache, or returns nil when it is disabled by --no-cache or unavailable
+func (r *Runner) openCache() *cache.Cache {
+	if r.Config.NoCache {
+		return nil
+	}
+
+	dir, err := cache.DefaultDir()
+	if err == nil {
+		var c *cache.Cache
+		if c, err = cache.Open(dir); err == nil {
+			return c
+		}
+	}
+	log.Printf("Warning: analyzing without the result cache: %v", err)
+	return nil
+}
+
+// CleanCache removes the result cache
+func CleanCache() ExitCode {
+	dir, err := cache.DefaultDir()
+	if err == nil {
+		err = cache.Clean(dir)
+	}
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return ExitError
+	}
+
+	log.Printf("✓ Removed cache %s", dir)
+	return ExitSuccess
+}
@@ -7,7 +7,11 @@ import (
 	"runtime/debug"
 	"strings"
 
+	"github.com/qixialu/azurerm-linter/loader"
 	"github.com/qixialu/azurerm-linter/passes"
+	"github.com/qixialu/azurerm-linter/rules"
+	"github.com/qixialu/azurerm-linter/session"
+	"golang.org/x/tools/go/analysis"
 )
 
 // Version is set at build time via -ldflags, or auto-detected from build info
@@ -54,6 +58,17 @@ func init() {
 	}
 }
 
+// FailOnNone is the --fail-on value with which findings never fail the run
+const FailOnNone = "none"
+
+// Environment variables providing defaults for the GitHub flags. They match the variables
+// GitHub Actions sets, so workflows on forks and GitHub Enterprise Server work without flags.
+const (
+	EnvRepo         = "GITHUB_REPOSITORY"
+	EnvGitHubAPIURL = "GITHUB_API_URL"
+	EnvGitHubHost   = "GH_HOST"
+)
+
 // Config holds all configuration options for the linter
 type Config struct {
 	// Command options
@@ -61,9 +76,34 @@ type Config struct {
 	ShowHelp    bool
 	ShowVersion bool
 	ListChecks  bool
+	LSP         bool // serve diagnostics to editors over the Language Server Protocol
+	CleanCache  bool // remove the result cache
+	InstallHook bool // install a git pre-commit hook running the linter on staged changes
+
+	// Explain options
+	Explain       bool
+	ExplainChecks []string // check IDs to document; all checks with MarkdownDir when empty
+	MarkdownDir   string   // write one Markdown page per check to this directory
 
 	// Output options
 	OutputFormat string
+	Format       string // layout of text output: plain or pretty
+	FailOn       string // lowest severity of the findings that fail the run: error, warning, info or none
+
+	// Project configuration options
+	ConfigFile   string
+	Checks       []*analysis.Analyzer      // checks to run, resolved from the config file; nil runs all checks
+	ExcludePaths []string                  // repository-relative path globs whose findings are dropped
+	Severities   map[string]rules.Severity // severities set by the config file, by check ID
+	CheckConfig  session.Config            // check settings and skip-packages from the config file
+
+	// Baseline options
+	BaselineFile      string
+	WriteBaselineFile string
+
+	// Fix options
+	Fix     bool
+	DiffFix bool
 
 	// Loader options
 	NoFilter   bool
@@ -71,6 +111,25 @@ type Config struct {
 	RemoteName string
 	BaseBranch string
 	DiffFile   string
+	Staged     bool
+	Range      string
+
+	// Cache options
+	NoCache bool
+
+	// KeepGoing analyzes the packages that load when others fail to load
+	KeepGoing bool
+
+	// Profiling options
+	Profile    bool
+	CPUProfile string
+	MemProfile string
+
+	// GitHub options
+	Repo         string
+	GitHubAPIURL string
+	GitHubHost   string
+	PostReview   bool
 
 	// Internal: flagSet for help printing
 	flagSet *flag.FlagSet
@@ -89,14 +148,45 @@ func ParseFlags() (*Config, error) {
 	fs.BoolVar(&cfg.ListChecks, "list", false, "list all available checks")
 
 	// Output flags
-	fs.StringVar(&cfg.OutputFormat, "output", "text", "output format: text or json")
+	fs.StringVar(&cfg.OutputFormat, "output", "text", "output format: text, json or sarif")
+	fs.StringVar(&cfg.Format, "format", FormatPlain, "layout of text output: plain, or pretty to group findings by file with source snippets")
+	fs.StringVar(&cfg.FailOn, "fail-on", string(rules.SeverityWarning), "exit with code 1 when findings of this severity or higher are reported: error, warning, info or none")
+
+	// Project configuration flags
+	fs.StringVar(&cfg.ConfigFile, "config", "", "path to config file (default: "+ProjectConfigFileName+" at the repository root)")
+
+	// Baseline flags
+	fs.StringVar(&cfg.BaselineFile, "baseline", "", "only report findings not recorded in this baseline file")
+	fs.StringVar(&cfg.WriteBaselineFile, "write-baseline", "", "record all current findings in this baseline file and exit")
+
+	// Fix flags
+	fs.BoolVar(&cfg.Fix, "fix", false, "apply suggested fixes to lines in the current diff")
+	fs.BoolVar(&cfg.DiffFix, "diff-fix", false, "print suggested fixes as a unified diff instead of applying them")
 
 	// Loader flags
 	fs.BoolVar(&cfg.NoFilter, "no-filter", false, "disable change filtering, analyze all files")
 	fs.IntVar(&cfg.PRNumber, "pr", 0, "analyze GitHub PR by number")
 	fs.StringVar(&cfg.RemoteName, "remote", "", "git remote name (auto-detect: origin > upstream)")
 	fs.StringVar(&cfg.BaseBranch, "base", "", "base branch (auto-detect from git config or 'main')")
-	fs.StringVar(&cfg.DiffFile, "diff", "", "read diff from file instead of git")
+	fs.StringVar(&cfg.DiffFile, "diff", "", "read diff from file instead of git (- reads stdin; git format-patch mbox files are accepted)")
+	fs.BoolVar(&cfg.Staged, "staged", false, "only check changes staged for commit, as a pre-commit hook")
+	fs.StringVar(&cfg.Range, "range", "", "check the changes between two commits, as A..B or A...B")
+
+	fs.BoolVar(&cfg.KeepGoing, "keep-going", false, "skip packages that fail to load or type check, and the packages depending on them, instead of stopping")
+
+	// Cache flags
+	fs.BoolVar(&cfg.NoCache, "no-cache", false, "analyze all packages instead of reusing the cached results of unchanged packages")
+
+	// Profiling flags
+	fs.BoolVar(&cfg.Profile, "profile", false, "report package load time, time per analyzer and package, and peak heap size")
+	fs.StringVar(&cfg.CPUProfile, "cpuprofile", "", "write a CPU profile in pprof format to this file")
+	fs.StringVar(&cfg.MemProfile, "memprofile", "", "write a heap profile in pprof format to this file")
+
+	// GitHub flags
+	fs.StringVar(&cfg.Repo, "repo", os.Getenv(EnvRepo), "GitHub repository of the PR as owner/name (env "+EnvRepo+", auto-detect from the git remote)")
+	fs.StringVar(&cfg.GitHubAPIURL, "github-api-url", os.Getenv(EnvGitHubAPIURL), "GitHub API base URL, e.g. https://github.example.com/api/v3 (env "+EnvGitHubAPIURL+", default https://api.github.com for a github.com remote)")
+	fs.StringVar(&cfg.GitHubHost, "github-host", os.Getenv(EnvGitHubHost), "GitHub Enterprise Server host whose API is at https://<host>/api/v3, used when --github-api-url is not set (env "+EnvGitHubHost+")")
+	fs.BoolVar(&cfg.PostReview, "post-review", false, "post findings as a review of the --pr pull request (requires GITHUB_TOKEN)")
 
 	fs.Usage = func() {
 		cfg.PrintHelp()
@@ -106,17 +196,154 @@ func ParseFlags() (*Config, error) {
 		return nil, err
 	}
 
+	switch cfg.OutputFormat {
+	case OutputText, OutputJSON, OutputSARIF:
+	default:
+		return nil, fmt.Errorf("invalid --output %q: must be one of text, json or sarif", cfg.OutputFormat)
+	}
+	switch cfg.Format {
+	case FormatPlain, FormatPretty:
+	default:
+		return nil, fmt.Errorf("invalid --format %q: must be plain or pretty", cfg.Format)
+	}
+	if cfg.Format == FormatPretty && cfg.OutputFormat != OutputText {
+		return nil, fmt.Errorf("--format=pretty only supports --output=text")
+	}
+
+	if cfg.FailOn != FailOnNone {
+		severity, err := rules.ParseSeverity(cfg.FailOn)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --fail-on %q: must be one of error, warning, info or none", cfg.FailOn)
+		}
+		cfg.FailOn = string(severity)
+	}
+	if cfg.ListChecks && cfg.OutputFormat == OutputSARIF {
+		return nil, fmt.Errorf("--list only supports --output=text or --output=json")
+	}
+
+	if cfg.BaselineFile != "" && cfg.WriteBaselineFile != "" {
+		return nil, fmt.Errorf("--baseline and --write-baseline cannot be used together")
+	}
+
+	sources := 0
+	for _, selected := range []bool{cfg.NoFilter, cfg.PRNumber > 0, cfg.DiffFile != "", cfg.Staged, cfg.Range != ""} {
+		if selected {
+			sources++
+		}
+	}
+	if sources > 1 && (cfg.Staged || cfg.Range != "") {
+		return nil, fmt.Errorf("--staged and --range cannot be used with each other, --pr, --diff or --no-filter")
+	}
+	if cfg.Range != "" {
+		if err := loader.ValidateCommitRange(cfg.Range); err != nil {
+			return nil, fmt.Errorf("invalid --range: %w", err)
+		}
+	}
+
+	if cfg.KeepGoing && cfg.WriteBaselineFile != "" {
+		return nil, fmt.Errorf("--keep-going cannot be used with --write-baseline")
+	}
+
+	if cfg.Fix && cfg.DiffFix {
+		return nil, fmt.Errorf("--fix and --diff-fix cannot be used together")
+	}
+	if (cfg.Fix || cfg.DiffFix) && cfg.WriteBaselineFile != "" {
+		return nil, fmt.Errorf("--fix and --diff-fix cannot be used with --write-baseline")
+	}
+	if cfg.DiffFix && cfg.OutputFormat != OutputText {
+		return nil, fmt.Errorf("--diff-fix only supports --output=text")
+	}
+
+	if cfg.PostReview {
+		switch {
+		case cfg.PRNumber == 0:
+			return nil, fmt.Errorf("--post-review requires --pr")
+		case cfg.NoFilter:
+			return nil, fmt.Errorf("--post-review cannot be used with --no-filter")
+		case cfg.WriteBaselineFile != "" || cfg.DiffFix:
+			return nil, fmt.Errorf("--post-review cannot be used with --write-baseline or --diff-fix")
+		}
+	}
+
+	if cfg.Repo != "" {
+		if _, _, err := loader.ParseRepo(cfg.Repo); err != nil {
+			return nil, fmt.Errorf("invalid --repo: %w", err)
+		}
+	}
+	if cfg.GitHubAPIURL != "" {
+		apiURL, err := loader.NormalizeAPIURL(cfg.GitHubAPIURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --github-api-url: %w", err)
+		}
+		cfg.GitHubAPIURL = apiURL
+	} else if cfg.GitHubHost != "" {
+		apiURL, err := loader.EnterpriseAPIURL(cfg.GitHubHost)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --github-host: %w", err)
+		}
+		cfg.GitHubAPIURL = apiURL
+	}
+
 	args := fs.Args()
 	if len(args) > 0 && args[0] == "version" {
 		cfg.ShowVersion = true
 		return cfg, nil
 	}
+	if len(args) > 0 && args[0] == "lsp" {
+		if len(args) > 1 {
+			return nil, fmt.Errorf("lsp takes no arguments")
+		}
+		cfg.LSP = true
+		return cfg, nil
+	}
+	if len(args) > 0 && args[0] == "install-hook" {
+		if len(args) > 1 {
+			return nil, fmt.Errorf("install-hook takes no arguments")
+		}
+		cfg.InstallHook = true
+		return cfg, nil
+	}
+	if len(args) > 0 && args[0] == "explain" {
+		if err := cfg.parseExplain(args[1:]); err != nil {
+			return nil, err
+		}
+		return cfg, nil
+	}
+	if len(args) > 0 && args[0] == "cache" {
+		if len(args) != 2 || args[1] != "clean" {
+			return nil, fmt.Errorf("unknown cache command: use 'cache clean'")
+		}
+		cfg.CleanCache = true
+		return cfg, nil
+	}
 
 	cfg.Patterns = args
 
 	return cfg, nil
 }
 
+// parseExplain parses the arguments of the explain subcommand
+func (c *Config) parseExplain(args []string) error {
+	fs := flag.NewFlagSet("explain", flag.ContinueOnError)
+	fs.StringVar(&c.MarkdownDir, "markdown", "", "write one Markdown page per check, and an index of all checks, to this directory")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	c.Explain = true
+	for _, id := range fs.Args() {
+		id = strings.ToUpper(id)
+		if _, ok := rules.Lookup(id); !ok {
+			return fmt.Errorf("unknown check %q: use --list to see the available checks", id)
+		}
+		c.ExplainChecks = append(c.ExplainChecks, id)
+	}
+	if c.MarkdownDir == "" && len(c.ExplainChecks) != 1 {
+		return fmt.Errorf("explain takes one check ID, or --markdown=<dir> to document several")
+	}
+	return nil
+}
+
 // ShortVersion returns a compact version string (e.g. "v0.4.2" or "dev")
 func ShortVersion() string {
 	v := Version
@@ -126,28 +353,73 @@ func ShortVersion() string {
 	return v
 }
 
+// EnabledChecks returns the checks selected by the project configuration
+func (c *Config) EnabledChecks() []*analysis.Analyzer {
+	if c.Checks == nil {
+		return passes.AllChecks
+	}
+	return c.Checks
+}
+
+// Rule returns the metadata of a check, with the severity set by the config file
+func (c *Config) Rule(checkID string) (rules.Rule, bool) {
+	rule, ok := rules.Lookup(checkID)
+	if severity, set := c.Severities[checkID]; set {
+		rule.Severity = severity
+	}
+	return rule, ok
+}
+
+// Severity returns the severity of the findings of a check
+func (c *Config) Severity(checkID string) rules.Severity {
+	rule, _ := c.Rule(checkID)
+	if rule.Severity == "" {
+		return rules.SeverityWarning
+	}
+	return rule.Severity
+}
+
+// Fails reports whether a finding of the check fails the run under --fail-on
+func (c *Config) Fails(checkID string) bool {
+	if c.FailOn == FailOnNone {
+		return false
+	}
+	threshold := rules.Severity(c.FailOn)
+	if threshold == "" {
+		threshold = rules.SeverityWarning
+	}
+	return c.Severity(checkID).AtLeast(threshold)
+}
+
 // PrintHelp prints the help message
 func (c *Config) PrintHelp() {
 	fmt.Println(`azurerm-linter - AzureRM Provider code linting tool
 
 Usage:
   azurerm-linter [flags] <package patterns>
+  azurerm-linter [--config=file] lsp
+  azurerm-linter cache clean
+  azurerm-linter install-hook
+  azurerm-linter explain <check>
+  azurerm-linter explain --markdown=<dir> [checks]
 
 Examples:
   azurerm-linter ./internal/services/compute/...
   azurerm-linter --pr=12345
+  GITHUB_TOKEN=... azurerm-linter --pr=12345 --post-review
+  azurerm-linter --pr=123 --repo=myorg/terraform-provider-azurerm --github-api-url=https://github.example.com/api/v3
   azurerm-linter --diff=changes.txt
+  azurerm-linter --staged
+  azurerm-linter --range=v4.0.0..v4.1.0
   azurerm-linter --no-filter ./internal/services/...
+  azurerm-linter --fail-on=error ./internal/services/...
+  azurerm-linter --format=pretty ./internal/services/compute/...
+  azurerm-linter --diff-fix ./internal/services/compute/...
+  azurerm-linter lsp
+  azurerm-linter cache clean
+  azurerm-linter explain AZNR002
+  azurerm-linter explain --markdown=docs/rules
 
 Flags:`)
 	c.flagSet.PrintDefaults()
 }
-
-// PrintChecks prints all available checks
-func PrintChecks() {
-	fmt.Println("Available checks:")
-	for _, analyzer := range passes.AllChecks {
-		title := strings.Split(analyzer.Doc, "\n")[0]
-		fmt.Printf("  %-10s  %s\n", analyzer.Name, title)
-	}
-}
@@ -0,0 +1,291 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/qixialu/azurerm-linter/passes"
+	"github.com/qixialu/azurerm-linter/rules"
+	"golang.org/x/tools/go/analysis"
+)
+
+// rulesIndexFile is the page listing all checks in a --markdown directory
+const rulesIndexFile = "README.md"
+
+// exampleHeadingRegex matches the lines of analyzer documentation that introduce an example
+var exampleHeadingRegex = regexp.MustCompile(`^(Example violations?|Valid usage|Correct usage|Legitimate use cases)\b.*:$`)
+
+// Explain prints the documentation of a check, or writes the Markdown pages of checks with --markdown
+func Explain(cfg *Config) ExitCode {
+	if cfg.MarkdownDir != "" {
+		if err := writeRuleDocs(cfg.MarkdownDir, cfg.ExplainChecks); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return ExitError
+		}
+		return ExitSuccess
+	}
+
+	for _, id := range cfg.ExplainChecks {
+		rule, _ := rules.Lookup(id)
+		explainRule(os.Stdout, rule)
+	}
+	return ExitSuccess
+}
+
+// findAnalyzer returns the analyzer of a check, including deprecated checks
+func findAnalyzer(id string) *analysis.Analyzer {
+	for _, analyzer := range append(append([]*analysis.Analyzer(nil), passes.AllChecks...), passes.DeprecatedChecks...) {
+		if analyzer.Name == id {
+			return analyzer
+		}
+	}
+	return nil
+}
+
+// ruleDoc returns the documentation of a check without its title line
+func ruleDoc(id string) string {
+	analyzer := findAnalyzer(id)
+	if analyzer == nil {
+		return ""
+	}
+	_, body, _ := strings.Cut(analyzer.Doc, "\n")
+	return strings.Trim(body, "\n")
+}
+
+// explainRule prints the documentation of a check for the terminal
+func explainRule(w io.Writer, rule rules.Rule) {
+	fmt.Fprintf(w, "%s: %s\n\n", rule.ID, rule.PlainTitle())
+	fmt.Fprintf(w, "  Category:   %s\n", rule.Category.Title())
+	fmt.Fprintf(w, "  Severity:   %s\n", rule.Severity)
+	fmt.Fprintf(w, "  Since:      %s\n", rule.Since)
+	if rule.IsDeprecated() {
+		fmt.Fprintf(w, "  Deprecated: %s, the check no longer runs\n", rule.Deprecated)
+	}
+	if rule.Notes != "" {
+		fmt.Fprintf(w, "  Notes:      %s\n", rule.Notes)
+	}
+	fmt.Fprintf(w, "  Reference:  %s\n", rule.Reference)
+	fmt.Fprintf(w, "  Docs:       %s\n", ruleDocsURL(rule.ID))
+	fmt.Fprintf(w, "  Source:     %s\n", ruleSourceURL(rule.ID))
+
+	if doc := ruleDoc(rule.ID); doc != "" {
+		fmt.Fprintf(w, "\n%s\n", doc)
+	}
+}
+
+// writeRuleDocs writes the Markdown page of each check in ids to dir. Without ids it writes
+// every check, including deprecated ones, and the index page.
+func writeRuleDocs(dir string, ids []string) error {
+	selected := rules.All()
+	if len(ids) > 0 {
+		selected = nil
+		for _, id := range ids {
+			rule, _ := rules.Lookup(id)
+			selected = append(selected, rule)
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create docs directory: %w", err)
+	}
+	for _, rule := range selected {
+		if err := os.WriteFile(filepath.Join(dir, rule.ID+".md"), []byte(ruleMarkdown(rule)), 0o644); err != nil {
+			return fmt.Errorf("failed to write docs of %s: %w", rule.ID, err)
+		}
+	}
+	if len(ids) == 0 {
+		if err := os.WriteFile(filepath.Join(dir, rulesIndexFile), []byte(rulesIndexMarkdown()), 0o644); err != nil {
+			return fmt.Errorf("failed to write docs index: %w", err)
+		}
+	}
+	return nil
+}
+
+// ruleMarkdown renders the docs page of a check
+func ruleMarkdown(rule rules.Rule) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n%s\n\n", rule.ID, rule.Title)
+	if rule.IsDeprecated() {
+		fmt.Fprintf(&b, "> **Deprecated** in %s: this check no longer runs.\n\n", rule.Deprecated)
+	}
+
+	b.WriteString("| | |\n|---|---|\n")
+	fmt.Fprintf(&b, "| Category | [%s](%s#%s) |\n", rule.Category.Title(), rulesIndexFile, markdownAnchor(rule.Category.Title()))
+	fmt.Fprintf(&b, "| Severity | %s |\n", rule.Severity)
+	fmt.Fprintf(&b, "| Since | %s |\n", rule.Since)
+	if rule.IsDeprecated() {
+		fmt.Fprintf(&b, "| Deprecated | %s |\n", rule.Deprecated)
+	}
+	if rule.RemovedIn != "" {
+		fmt.Fprintf(&b, "| Removed in | %s |\n", rule.RemovedIn)
+	}
+	fmt.Fprintf(&b, "| Filtered runs | %s |\n", matchModeDescription(rule.MatchMode))
+	fmt.Fprintf(&b, "| Reference | <%s> |\n", rule.Reference)
+	fmt.Fprintf(&b, "| Source | [passes/%s.go](%s) |\n", rule.ID, ruleSourceURL(rule.ID))
+
+	if rule.Notes != "" {
+		fmt.Fprintf(&b, "\n%s.\n", rule.Notes)
+	}
+	if doc := ruleDoc(rule.ID); doc != "" {
+		fmt.Fprintf(&b, "\n## Documentation\n\n%s", docMarkdown(doc))
+	}
+	return b.String()
+}
+
+// matchModeDescription explains which changes keep the findings of a check in filtered runs
+func matchModeDescription(mode string) string {
+	switch mode {
+	case "new-file":
+		return "reported in new files only"
+	case "same-hunk":
+		return "reported when a hunk of the diff touches the finding"
+	case "file-changed":
+		return "reported in changed files"
+	default:
+		return "reported on added lines"
+	}
+}
+
+// docMarkdown converts analyzer documentation to Markdown: indented blocks become Go code
+// blocks and the lines introducing examples become headings
+func docMarkdown(doc string) string {
+	lines := strings.Split(doc, "\n")
+	var b strings.Builder
+	inList := false
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			inList = false
+			b.WriteString("\n")
+		case isCodeLine(line) && !(inList && !strings.HasPrefix(line, "\t")):
+			// Take the block up to the next unindented line, keeping blank lines within it
+			end := i
+			for j := i; j < len(lines); j++ {
+				if strings.TrimSpace(lines[j]) == "" {
+					continue
+				}
+				if !isCodeLine(lines[j]) {
+					break
+				}
+				end = j
+			}
+			b.WriteString("```go\n")
+			b.WriteString(dedent(lines[i : end+1]))
+			b.WriteString("```\n")
+			i = end
+		case exampleHeadingRegex.MatchString(trimmed):
+			fmt.Fprintf(&b, "### %s\n\n", strings.TrimSuffix(trimmed, ":"))
+			// The heading replaces the blank line Markdown needs before the code block
+			if i+1 < len(lines) && strings.TrimSpace(lines[i+1]) == "" {
+				i++
+			}
+		case strings.HasPrefix(trimmed, "- "):
+			inList = true
+			fmt.Fprintf(&b, "%s\n", trimmed)
+		case inList:
+			// Continuation of a list item
+			fmt.Fprintf(&b, "  %s\n", trimmed)
+		default:
+			fmt.Fprintf(&b, "%s\n", line)
+		}
+	}
+	return b.String()
+}
+
+// isCodeLine reports whether a documentation line is part of an indented example
+func isCodeLine(line string) bool {
+	return strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "  ")
+}
+
+// dedent removes the indentation common to the non-blank lines and joins them
+func dedent(lines []string) string {
+	prefix := ""
+	first := true
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if first {
+			prefix, first = indent, false
+			continue
+		}
+		for !strings.HasPrefix(indent, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(strings.TrimRight(strings.TrimPrefix(line, prefix), " \t"))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// rulesIndexMarkdown renders the page listing all checks by category
+func rulesIndexMarkdown() string {
+	var b strings.Builder
+	b.WriteString("# Lint Checks\n\n")
+	b.WriteString("Generated by `azurerm-linter explain --markdown=docs/rules`; do not edit.\n")
+	for _, category := range rules.Categories() {
+		fmt.Fprintf(&b, "\n## %s\n\nFollows <%s>.\n\n%s", category.Title(), category.Guide(), rulesTable(category, ""))
+	}
+	return b.String()
+}
+
+// readmeRulesTables renders the check tables of the README, which link to the pages in docs/rules
+func readmeRulesTables() string {
+	var b strings.Builder
+	for i, category := range rules.Categories() {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "### %s\n\n%s", category.Title(), rulesTable(category, "docs/rules/"))
+	}
+	return b.String()
+}
+
+// rulesTable renders the table of the checks of a category, linking each check to its page
+// under linkPrefix. A comments column is added when a check of the category has notes.
+func rulesTable(category rules.Category, linkPrefix string) string {
+	var checks []rules.Rule
+	withNotes := false
+	for _, rule := range rules.All() {
+		if rule.Category == category {
+			checks = append(checks, rule)
+			withNotes = withNotes || rule.Notes != ""
+		}
+	}
+
+	var b strings.Builder
+	if withNotes {
+		b.WriteString("| Check | Description | Comments |\n|-------|-------------|----------|\n")
+	} else {
+		b.WriteString("| Check | Description |\n|-------|-------------|\n")
+	}
+	for _, rule := range checks {
+		id := fmt.Sprintf("[%s](%s%s.md)", rule.ID, linkPrefix, rule.ID)
+		if rule.IsDeprecated() {
+			id += " (DEPRECATED)"
+		}
+		if withNotes {
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", id, rule.Title, rule.Notes)
+		} else {
+			fmt.Fprintf(&b, "| %s | %s |\n", id, rule.Title)
+		}
+	}
+	return b.String()
+}
+
+// markdownAnchor returns the anchor GitHub generates for a heading
+func markdownAnchor(heading string) string {
+	return strings.ReplaceAll(strings.ToLower(heading), " ", "-")
+}
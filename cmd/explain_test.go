@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qixialu/azurerm-linter/rules"
+)
+
+func TestRuleDocsAreUpToDate(t *testing.T) {
+	dir := t.TempDir()
+	if err := writeRuleDocs(dir, nil); err != nil {
+		t.Fatalf("writeRuleDocs() error = %v", err)
+	}
+
+	committed := filepath.Join("..", "docs", "rules")
+	generated, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	existing, err := os.ReadDir(committed)
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	if len(existing) != len(generated) {
+		t.Errorf("docs/rules has %d files, want %d", len(existing), len(generated))
+	}
+
+	for _, entry := range generated {
+		want, _ := os.ReadFile(filepath.Join(dir, entry.Name()))
+		got, err := os.ReadFile(filepath.Join(committed, entry.Name()))
+		if err != nil || !bytes.Equal(got, want) {
+			t.Errorf("docs/rules/%s is out of date: run azurerm-linter explain --markdown=docs/rules", entry.Name())
+		}
+	}
+}
+
+func TestReadmeListsEveryCheck(t *testing.T) {
+	readme, err := os.ReadFile(filepath.Join("..", "README.md"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if !strings.Contains(strings.ReplaceAll(string(readme), "\r\n", "\n"), readmeRulesTables()) {
+		t.Fatalf("README check tables differ from the rules registry, want:\n%s", readmeRulesTables())
+	}
+}
+
+func TestExplainRuleShowsMetadataAndDocumentation(t *testing.T) {
+	rule, _ := rules.Lookup("AZNR007")
+	var buf bytes.Buffer
+	explainRule(&buf, rule)
+
+	out := buf.String()
+	for _, want := range []string{"AZNR007: check that resource names", "Deprecated: v0.1.9", "reference-acceptance-testing.md", "docs/rules/AZNR007.md", "passes/AZNR007.go", "Example violation"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("explain output is missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestDocMarkdownFencesExamples(t *testing.T) {
+	doc := "Reports things.\n\nSpecial cases:\n- first\n  continued\n\nExample violation:\n  if a {\n\n      b()\n  }\n\nValid usage:\n\tc()\n"
+	want := "Reports things.\n\nSpecial cases:\n- first\n  continued\n\n### Example violation\n\n```go\nif a {\n\n    b()\n}\n```\n\n### Valid usage\n\n```go\nc()\n```\n\n"
+	if got := docMarkdown(doc); got != want {
+		t.Fatalf("docMarkdown() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestParseExplain(t *testing.T) {
+	cfg := &Config{}
+	if err := cfg.parseExplain([]string{"aznr002"}); err != nil || len(cfg.ExplainChecks) != 1 || cfg.ExplainChecks[0] != "AZNR002" {
+		t.Fatalf("parseExplain(aznr002) = %v, checks %v, want AZNR002", err, cfg.ExplainChecks)
+	}
+	if err := (&Config{}).parseExplain([]string{"AZXX001"}); err == nil {
+		t.Fatalf("parseExplain(AZXX001) error = nil, want unknown check")
+	}
+	if err := (&Config{}).parseExplain(nil); err == nil {
+		t.Fatalf("parseExplain() error = nil, want a check ID to be required")
+	}
+	cfg = &Config{}
+	if err := cfg.parseExplain([]string{"--markdown=docs/rules"}); err != nil || cfg.MarkdownDir != "docs/rules" {
+		t.Fatalf("parseExplain(--markdown) = %v, dir %q", err, cfg.MarkdownDir)
+	}
+}
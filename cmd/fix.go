@@ -0,0 +1,335 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/qixialu/azurerm-linter/lint"
+	"github.com/qixialu/azurerm-linter/loader"
+	"github.com/qixialu/azurerm-linter/reporting"
+	"golang.org/x/tools/imports"
+)
+
+type (
+	// Fix is a suggested fix resolved to byte offsets so it can be applied after analysis
+	Fix = lint.Fix
+	// FixEdit replaces the bytes [Offset, End) of Path with NewText
+	FixEdit = lint.FixEdit
+)
+
+// fixPlan is the set of edits selected for --fix and --diff-fix
+type fixPlan struct {
+	edits   map[string][]FixEdit // selected edits by file
+	fixed   map[string][]Finding // findings resolved by the selected edits, by file
+	unfixed []Finding
+}
+
+// planFixes selects the first suggested fix of each finding, skipping fixes that touch lines
+// outside the change filter or overlap an edit that was already selected
+func planFixes(findings []Finding, changes *loader.ChangeSet) fixPlan {
+	plan := fixPlan{
+		edits: make(map[string][]FixEdit),
+		fixed: make(map[string][]Finding),
+	}
+	sources := make(map[string][]byte)
+
+	for _, f := range findings {
+		if len(f.Fixes) == 0 || !fixAllowed(f, changes, sources) {
+			plan.unfixed = append(plan.unfixed, f)
+			continue
+		}
+
+		var added []FixEdit
+		conflict := false
+		for _, edit := range f.Fixes[0].Edits {
+			duplicate := false
+			for _, selected := range plan.edits[edit.Path] {
+				if selected == edit {
+					duplicate = true
+					break
+				}
+				if editsOverlap(selected, edit) {
+					conflict = true
+					break
+				}
+			}
+			if conflict {
+				break
+			}
+			if !duplicate {
+				added = append(added, edit)
+			}
+		}
+		if conflict {
+			plan.unfixed = append(plan.unfixed, f)
+			continue
+		}
+
+		for _, edit := range added {
+			plan.edits[edit.Path] = append(plan.edits[edit.Path], edit)
+		}
+		plan.fixed[f.Path] = append(plan.fixed[f.Path], f)
+	}
+
+	return plan
+}
+
+// fixAllowed reports whether every edit of the first fix of f stays within lines added by the
+// current diff. The one exception is a finding of a same-hunk check, such as an AZNR005 registration
+// literal that is no longer sorted after an entry was appended: its fix may reorder whole lines of
+// the hunk that are unchanged, as long as part of the edit was changed, since sorting moves code
+// without rewriting it. Any other edit of unchanged lines makes the fix unavailable.
+func fixAllowed(f Finding, changes *loader.ChangeSet, sources map[string][]byte) bool {
+	for _, edit := range f.Fixes[0].Edits {
+		if edit.InHeader {
+			continue
+		}
+		if changes.IsEditAllowed(edit.Path, edit.StartLine, edit.EndLine) {
+			continue
+		}
+		if f.MatchMode == reporting.MatchModeSameHunk && isLineReorder(edit, sources) && changes.TouchesChanges(edit.Path, edit.StartLine, edit.EndLine) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// isLineReorder reports whether the edit replaces a block of lines with the same lines in another order
+func isLineReorder(edit FixEdit, sources map[string][]byte) bool {
+	content, ok := sources[edit.Path]
+	if !ok {
+		content, _ = os.ReadFile(edit.Path)
+		sources[edit.Path] = content
+	}
+	if edit.Offset < 0 || edit.End > len(content) || edit.End <= edit.Offset {
+		return false
+	}
+
+	before := strings.Split(string(content[edit.Offset:edit.End]), "\n")
+	after := strings.Split(edit.NewText, "\n")
+	if len(before) < 2 || len(before) != len(after) {
+		return false
+	}
+	for i := range before {
+		before[i], after[i] = strings.TrimSpace(before[i]), strings.TrimSpace(after[i])
+	}
+	sort.Strings(before)
+	sort.Strings(after)
+	for i := range before {
+		if before[i] != after[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// editsOverlap reports whether two edits touch the same bytes. Two insertions at the same
+// offset overlap too, since their order would be ambiguous.
+func editsOverlap(a, b FixEdit) bool {
+	if a.Path != b.Path {
+		return false
+	}
+	if a.Offset == b.Offset {
+		return true
+	}
+	return a.Offset < b.End && b.Offset < a.End
+}
+
+// applyEdits applies non-overlapping edits to content and formats the result with goimports,
+// which also adds imports the fixes need and drops the ones they made unused. Formatting only
+// changes lines the edits wrote, the package clause and imports, and lines of content for
+// which lineAllowed reports true; other lines are kept as they are, even if badly formatted.
+func applyEdits(path string, content []byte, edits []FixEdit, lineAllowed func(line int) bool) ([]byte, error) {
+	sorted := append([]FixEdit(nil), edits...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Offset < sorted[j].Offset
+	})
+
+	var buf bytes.Buffer
+	last := 0
+	for _, edit := range sorted {
+		if edit.Offset < last || edit.End < edit.Offset || edit.End > len(content) {
+			return nil, fmt.Errorf("edit %d-%d is out of range for %s", edit.Offset, edit.End, path)
+		}
+		buf.Write(content[last:edit.Offset])
+		buf.WriteString(edit.NewText)
+		last = edit.End
+	}
+	buf.Write(content[last:])
+
+	formatted, err := imports.Process(path, buf.Bytes(), &imports.Options{
+		Comments:  true,
+		TabIndent: true,
+		TabWidth:  8,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fixed %s is not valid Go: %w", path, err)
+	}
+	return keepAllowedFormatting(path, content, buf.Bytes(), formatted, lineAllowed), nil
+}
+
+// keepAllowedFormatting returns formatted with the formatting changes of lines of edited that may
+// not be rewritten undone. edited is content with the edits applied. Lines written by the edits,
+// the package clause and import declarations may be rewritten, lines copied from content only if
+// lineAllowed reports true for their line. A change that also touches other lines is undone as a
+// whole; lines inserted by formatting are kept next to a line that may be rewritten.
+func keepAllowedFormatting(path string, content, edited, formatted []byte, lineAllowed func(line int) bool) []byte {
+	editedLines := splitLines(string(edited))
+
+	// allowed[i] reports whether line i of edited may be rewritten
+	allowed := make([]bool, len(editedLines))
+	original, i := 0, 0
+	for _, op := range diffLines(splitLines(string(content)), editedLines) {
+		switch op.kind {
+		case '-':
+			original++
+		case '+':
+			allowed[i] = true
+			i++
+		default:
+			original++
+			allowed[i] = lineAllowed(original)
+			i++
+		}
+	}
+	fset := token.NewFileSet()
+	if file, err := parser.ParseFile(fset, path, edited, parser.ImportsOnly); err == nil {
+		end := fset.Position(file.Name.End()).Line
+		for _, decl := range file.Decls {
+			end = fset.Position(decl.End()).Line
+		}
+		for line := fset.Position(file.Package).Line; line <= end && line <= len(allowed); line++ {
+			allowed[line-1] = true
+		}
+	}
+
+	ops := diffLines(editedLines, splitLines(string(formatted)))
+	var b strings.Builder
+	line := 0 // lines of edited before the current op
+	for start := 0; start < len(ops); {
+		if ops[start].kind == ' ' {
+			b.WriteString(ops[start].text)
+			line++
+			start++
+			continue
+		}
+
+		end := start
+		for end < len(ops) && ops[end].kind != ' ' {
+			end++
+		}
+		removed := 0
+		keep := true
+		for _, op := range ops[start:end] {
+			if op.kind == '-' {
+				keep = keep && allowed[line+removed]
+				removed++
+			}
+		}
+		if removed == 0 {
+			keep = (line > 0 && allowed[line-1]) || (line < len(allowed) && allowed[line])
+		}
+
+		for _, op := range ops[start:end] {
+			if (op.kind == '+') == keep {
+				b.WriteString(op.text)
+			}
+		}
+		line += removed
+		start = end
+	}
+	return []byte(b.String())
+}
+
+// runFixes applies (--fix) or prints as a unified diff (--diff-fix) the suggested fixes of the
+// findings and returns the findings that were left unfixed
+func (r *Runner) runFixes(findings []Finding) []Finding {
+	plan := planFixes(findings, r.changes())
+	unfixed := plan.unfixed
+
+	paths := make([]string, 0, len(plan.edits))
+	for path := range plan.edits {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	root := r.root()
+	fixedFindings, fixedFiles := 0, 0
+	for _, path := range paths {
+		content, fixed, err := fixFile(path, plan.edits[path], r.changes())
+		if err != nil {
+			log.Printf("Warning: skipping fixes for %s: %v", path, err)
+			unfixed = append(unfixed, plan.fixed[path]...)
+			continue
+		}
+		if bytes.Equal(content, fixed) {
+			continue
+		}
+
+		if r.Config.DiffFix {
+			fmt.Print(unifiedDiff(displayPath(root, path), content, fixed))
+		} else if err := writeFixedFile(path, fixed); err != nil {
+			log.Printf("Warning: %v", err)
+			unfixed = append(unfixed, plan.fixed[path]...)
+			continue
+		}
+		fixedFindings += len(plan.fixed[path])
+		fixedFiles++
+	}
+
+	if r.Config.DiffFix {
+		log.Printf("Suggested fixes for %d issue(s) in %d file(s)", fixedFindings, fixedFiles)
+	} else {
+		log.Printf("✓ Applied fixes for %d issue(s) in %d file(s)", fixedFindings, fixedFiles)
+	}
+
+	sort.SliceStable(unfixed, func(i, j int) bool {
+		if unfixed[i].Path != unfixed[j].Path {
+			return unfixed[i].Path < unfixed[j].Path
+		}
+		return unfixed[i].Line < unfixed[j].Line
+	})
+	return unfixed
+}
+
+// fixFile applies edits to the file at path, letting formatting touch the lines changes allows edits of
+func fixFile(path string, edits []FixEdit, changes *loader.ChangeSet) ([]byte, []byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	fixed, err := applyEdits(path, content, edits, func(line int) bool {
+		return changes.IsEditAllowed(path, line, line)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return content, fixed, nil
+}
+
+func writeFixedFile(path string, content []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to write fixes: %w", err)
+	}
+	if err := os.WriteFile(path, content, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write fixes: %w", err)
+	}
+	return nil
+}
+
+// displayPath returns path relative to root when it lies inside it
+func displayPath(root, path string) string {
+	if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(path)
+}
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qixialu/azurerm-linter/loader"
+	"github.com/qixialu/azurerm-linter/reporting"
+)
+
+func TestPlanFixesSkipsOverlappingEditsAndDeduplicatesImports(t *testing.T) {
+	importEdit := FixEdit{Path: "a.go", Offset: 20, End: 20, NewText: "\"errors\"\n\t", InHeader: true}
+	findings := []Finding{
+		{CheckID: "AZRE001", Path: "a.go", Line: 5, Fixes: []Fix{{Edits: []FixEdit{
+			{Path: "a.go", Offset: 50, End: 70, StartLine: 5, EndLine: 5, NewText: "errors.New(\"a\")"},
+			importEdit,
+		}}}},
+		{CheckID: "AZRE001", Path: "a.go", Line: 6, Fixes: []Fix{{Edits: []FixEdit{
+			{Path: "a.go", Offset: 80, End: 100, StartLine: 6, EndLine: 6, NewText: "errors.New(\"b\")"},
+			importEdit,
+		}}}},
+		{CheckID: "AZBP007", Path: "a.go", Line: 6, Fixes: []Fix{{Edits: []FixEdit{
+			{Path: "a.go", Offset: 90, End: 95, StartLine: 6, EndLine: 6, NewText: "x"},
+		}}}},
+		{CheckID: "AZNR002", Path: "a.go", Line: 9},
+	}
+
+	plan := planFixes(findings, nil)
+
+	if got := len(plan.edits["a.go"]); got != 3 {
+		t.Fatalf("len(edits) = %d, want 3 (two replacements and one shared import)", got)
+	}
+	if got := len(plan.fixed["a.go"]); got != 2 {
+		t.Fatalf("len(fixed) = %d, want 2", got)
+	}
+	if len(plan.unfixed) != 2 || plan.unfixed[0].CheckID != "AZBP007" || plan.unfixed[1].CheckID != "AZNR002" {
+		t.Fatalf("unfixed = %+v, want the overlapping AZBP007 fix and the finding without a fix", plan.unfixed)
+	}
+}
+
+func TestApplyEditsRunsGoimports(t *testing.T) {
+	src := `package cdn
+
+import (
+	"fmt"
+)
+
+func check() error {
+	return fmt.Errorf("profile is missing")
+}
+`
+	start := strings.Index(src, `fmt.Errorf(`)
+	end := strings.Index(src, "\n}")
+	edits := []FixEdit{{Path: "cdn.go", Offset: start, End: end, NewText: `errors.New("profile is missing")`}}
+
+	got, err := applyEdits("cdn.go", []byte(src), edits, func(int) bool { return true })
+	if err != nil {
+		t.Fatalf("applyEdits() error = %v", err)
+	}
+
+	want := `package cdn
+
+import "errors"
+
+func check() error {
+	return errors.New("profile is missing")
+}
+`
+	if string(got) != want {
+		t.Fatalf("applyEdits() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestApplyEditsOnlyFormatsAllowedLines(t *testing.T) {
+	src := `package cdn
+
+import (
+	"fmt"
+)
+
+func check() error {
+	return fmt.Errorf("profile is missing")
+}
+
+func   unchanged( )   int {
+	x  :=  1
+	return x
+}
+`
+	start := strings.Index(src, `fmt.Errorf(`)
+	end := strings.Index(src, "\n}")
+	edits := []FixEdit{{Path: "cdn.go", Offset: start, End: end, NewText: `errors.New( "profile is missing" )`}}
+
+	// Only the line of the fix, line 8, is in the diff
+	got, err := applyEdits("cdn.go", []byte(src), edits, func(line int) bool { return line == 8 })
+	if err != nil {
+		t.Fatalf("applyEdits() error = %v", err)
+	}
+
+	want := `package cdn
+
+import "errors"
+
+func check() error {
+	return errors.New("profile is missing")
+}
+
+func   unchanged( )   int {
+	x  :=  1
+	return x
+}
+`
+	if string(got) != want {
+		t.Fatalf("applyEdits() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	after := "a\nb\nC\nd\ne\nf\ng\nh\ni\nj\nk\n"
+
+	want := `--- a/x.go
++++ b/x.go
+@@ -1,6 +1,6 @@
+ a
+ b
+-c
++C
+ d
+ e
+ f
+@@ -8,3 +8,4 @@
+ h
+ i
+ j
++k
+`
+	if got := unifiedDiff("x.go", []byte(before), []byte(after)); got != want {
+		t.Fatalf("unifiedDiff() =\n%s\nwant\n%s", got, want)
+	}
+	if got := unifiedDiff("x.go", []byte(before), []byte(before)); got != "" {
+		t.Fatalf("unifiedDiff() of equal content = %q, want empty", got)
+	}
+}
+
+func TestIsLineReorder(t *testing.T) {
+	src := "\t\t\"b\": nil, // note\n\t\t\"a\": nil,\n\n\t\t\"c\": nil,\n"
+	path := "registration.go"
+	sources := map[string][]byte{path: []byte(src)}
+	end := strings.LastIndex(src, ",") + 1
+
+	sorted := FixEdit{Path: path, Offset: 2, End: end, NewText: "\"a\": nil,\n\t\t\"b\": nil, // note\n\n\t\t\"c\": nil,"}
+	if !isLineReorder(sorted, sources) {
+		t.Fatalf("isLineReorder() = false for a pure reordering")
+	}
+
+	rewritten := sorted
+	rewritten.NewText = "\"a\": nil,\n\t\t\"b\": nil,\n\n\t\t\"c\": nil,"
+	if isLineReorder(rewritten, sources) {
+		t.Fatalf("isLineReorder() = true for an edit that drops a comment")
+	}
+}
+
+func TestFixAllowedLimitsReordersOfUnchangedLinesToSameHunkChecks(t *testing.T) {
+	t.Chdir(t.TempDir())
+	path := filepath.Join("internal", "services", "cdn", "registration.go")
+	src := "var resources = map[string]any{\n\t\"b\": nil,\n\t\"c\": nil,\n\t\"a\": nil,\n}\n"
+	diff := `diff --git a/internal/services/cdn/registration.go b/internal/services/cdn/registration.go
+--- a/internal/services/cdn/registration.go
++++ b/internal/services/cdn/registration.go
+@@ -1,4 +1,5 @@
+ var resources = map[string]any{
+ 	"b": nil,
+ 	"c": nil,
++	"a": nil,
+ }
+`
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(path, []byte(src), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := os.WriteFile("changes.diff", []byte(diff), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	changes, err := loader.LoadChanges(loader.LoaderOptions{DiffFile: "changes.diff"})
+	if err != nil {
+		t.Fatalf("LoadChanges() error = %v", err)
+	}
+
+	// Sorting the literal moves the unchanged lines 2-3 along with the appended line 4
+	start := strings.Index(src, `"b"`)
+	end := strings.LastIndex(src, ",") + 1
+	reorder := FixEdit{Path: path, Offset: start, End: end, StartLine: 2, EndLine: 4, NewText: "\"a\": nil,\n\t\"b\": nil,\n\t\"c\": nil,"}
+	finding := Finding{CheckID: "AZNR005", Path: path, Line: 4, Fixes: []Fix{{Edits: []FixEdit{reorder}}}}
+
+	finding.MatchMode = reporting.MatchModeSameHunk
+	if !fixAllowed(finding, changes, map[string][]byte{}) {
+		t.Fatalf("fixAllowed() = false for a same-hunk reorder touching the added line")
+	}
+
+	finding.MatchMode = reporting.MatchModeExactAdded
+	if fixAllowed(finding, changes, map[string][]byte{}) {
+		t.Fatalf("fixAllowed() = true for an exact-added finding editing unchanged lines")
+	}
+}
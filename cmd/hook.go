@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// hookMarker identifies pre-commit hooks written by install-hook, which it may overwrite
+const hookMarker = "# Installed by azurerm-linter install-hook"
+
+// InstallHook writes a git pre-commit hook that runs the linter on the staged changes
+func InstallHook() ExitCode {
+	hooksDir, err := gitHooksDir()
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return ExitError
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		log.Printf("Error: failed to locate the linter executable: %v", err)
+		return ExitError
+	}
+
+	path, err := installHook(hooksDir, executable)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return ExitError
+	}
+	log.Printf("✓ Installed pre-commit hook %s", path)
+	return ExitSuccess
+}
+
+// gitHooksDir returns the hooks directory of the current repository, honoring core.hooksPath
+func gitHooksDir() (string, error) {
+	output, err := exec.Command("git", "rev-parse", "--git-path", "hooks").CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("not in a git repository: %s", strings.TrimSpace(string(output)))
+	}
+	return filepath.Abs(strings.TrimSpace(string(output)))
+}
+
+// installHook writes the pre-commit hook into hooksDir. An existing hook is only replaced
+// when install-hook wrote it.
+func installHook(hooksDir, executable string) (string, error) {
+	path := filepath.Join(hooksDir, "pre-commit")
+	if existing, err := os.ReadFile(path); err == nil && !bytes.Contains(existing, []byte(hookMarker)) {
+		return "", fmt.Errorf("a pre-commit hook already exists at %s; add '%s --staged' to it instead", path, executable)
+	}
+
+	script := fmt.Sprintf("#!/bin/sh\n%s\nexec %s --staged\n", hookMarker, shellQuote(filepath.ToSlash(executable)))
+	if err := os.MkdirAll(hooksDir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		return "", fmt.Errorf("failed to write pre-commit hook: %w", err)
+	}
+	// WriteFile keeps the mode of an existing file
+	if err := os.Chmod(path, 0o755); err != nil {
+		return "", fmt.Errorf("failed to make pre-commit hook executable: %w", err)
+	}
+	return path, nil
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
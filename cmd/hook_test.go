@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInstallHookWritesExecutableHook(t *testing.T) {
+	hooksDir := filepath.Join(t.TempDir(), "hooks")
+
+	path, err := installHook(hooksDir, "/opt/go bin/azurerm-linter")
+	if err != nil {
+		t.Fatalf("installHook() error = %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if !strings.HasPrefix(string(content), "#!/bin/sh\n") || !strings.Contains(string(content), "exec '/opt/go bin/azurerm-linter' --staged\n") {
+		t.Fatalf("hook = %q, want a shell script running the linter with --staged", content)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm()&0o111 == 0 {
+		t.Fatalf("hook mode = %v (%v), want executable", info.Mode(), err)
+	}
+
+	// Reinstalling replaces the hook written before
+	if _, err := installHook(hooksDir, "/usr/local/bin/azurerm-linter"); err != nil {
+		t.Fatalf("installHook() over its own hook error = %v", err)
+	}
+}
+
+func TestInstallHookKeepsForeignHook(t *testing.T) {
+	hooksDir := t.TempDir()
+	path := filepath.Join(hooksDir, "pre-commit")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\nmake fmt\n"), 0o755); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	if _, err := installHook(hooksDir, "/usr/local/bin/azurerm-linter"); err == nil {
+		t.Fatalf("installHook() error = nil, want error for an existing hook")
+	}
+	if content, _ := os.ReadFile(path); string(content) != "#!/bin/sh\nmake fmt\n" {
+		t.Fatalf("existing hook was modified: %q", content)
+	}
+}
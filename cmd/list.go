@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/qixialu/azurerm-linter/passes"
+	"github.com/qixialu/azurerm-linter/rules"
+)
+
+// JSONCheckList is the output of --list --output=json: every check, including deprecated ones
+type JSONCheckList struct {
+	Version string     `json:"version"`
+	Rules   []JSONRule `json:"rules"`
+}
+
+// PrintChecks prints all available checks in the output format of cfg, text or json, with the
+// severities set by the config file
+func PrintChecks(cfg *Config) ExitCode {
+	if err := printChecks(os.Stdout, cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return ExitError
+	}
+	return ExitSuccess
+}
+
+func printChecks(w io.Writer, cfg *Config) error {
+	if cfg.OutputFormat == OutputJSON {
+		list := JSONCheckList{Version: ShortVersion(), Rules: []JSONRule{}}
+		for _, rule := range rules.All() {
+			rule, _ = cfg.Rule(rule.ID)
+			list.Rules = append(list.Rules, jsonRule(rule))
+		}
+		data, err := json.MarshalIndent(list, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal check list: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	}
+
+	fmt.Fprintln(w, "Available checks:")
+	for _, analyzer := range passes.AllChecks {
+		rule, _ := cfg.Rule(analyzer.Name)
+		fmt.Fprintf(w, "  %-10s  %-8s  %s\n", analyzer.Name, rule.Severity, rule.PlainTitle())
+	}
+
+	fmt.Fprintln(w, "\nDeprecated checks:")
+	for _, rule := range rules.All() {
+		if rule.IsDeprecated() {
+			fmt.Fprintf(w, "  %-10s  %s (deprecated in %s)\n", rule.ID, rule.PlainTitle(), rule.Deprecated)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/qixialu/azurerm-linter/passes"
+	"github.com/qixialu/azurerm-linter/rules"
+)
+
+func TestPrintChecksText(t *testing.T) {
+	var buf bytes.Buffer
+	if err := printChecks(&buf, &Config{OutputFormat: OutputText}); err != nil {
+		t.Fatalf("printChecks() error = %v", err)
+	}
+
+	active, deprecated, _ := strings.Cut(buf.String(), "Deprecated checks:")
+	if !strings.Contains(active, "AZRE001") || !strings.Contains(active, "instead of errors.New") {
+		t.Fatalf("active checks = %q, want AZRE001 with its title", active)
+	}
+	if strings.Contains(active, "AZNR007") || !strings.Contains(deprecated, "AZNR007") || !strings.Contains(deprecated, "deprecated in v0.1.9") {
+		t.Fatalf("output = %q, want AZNR007 only among the deprecated checks", buf.String())
+	}
+}
+
+func TestPrintChecksJSON(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := &Config{OutputFormat: OutputJSON, Severities: map[string]rules.Severity{"AZRE001": rules.SeverityInfo}}
+	if err := printChecks(&buf, cfg); err != nil {
+		t.Fatalf("printChecks() error = %v", err)
+	}
+
+	var list JSONCheckList
+	if err := json.Unmarshal(buf.Bytes(), &list); err != nil {
+		t.Fatalf("invalid JSON %s: %v", buf.String(), err)
+	}
+	if len(list.Rules) <= len(passes.AllChecks) {
+		t.Fatalf("len(Rules) = %d, want the %d active checks and the deprecated ones", len(list.Rules), len(passes.AllChecks))
+	}
+	for _, rule := range list.Rules {
+		if rule.ID == "AZNR001" && (rule.Category != "new-resource" || rule.MatchMode != "new-file" || rule.Since != "v0.1.0") {
+			t.Fatalf("AZNR001 = %+v, want category new-resource, match mode new-file and since v0.1.0", rule)
+		}
+		if rule.ID == "AZNR001" && rule.Severity != "error" || rule.ID == "AZRE001" && rule.Severity != "info" {
+			t.Fatalf("%s has severity %q, want the registry severity unless the config overrides it", rule.ID, rule.Severity)
+		}
+		if rule.ID == "AZBP015" && rule.Deprecated != "v0.1.7" {
+			t.Fatalf("AZBP015 = %+v, want deprecated in v0.1.7", rule)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/qixialu/azurerm-linter/lsp"
+)
+
+// RunLSP serves diagnostics over the Language Server Protocol on stdin and stdout until the
+// client exits. The checks and exclude-paths of the project configuration apply.
+func RunLSP(ctx context.Context, cfg *Config) ExitCode {
+	// stdout carries the protocol; messages must not contain color codes
+	color.NoColor = true
+
+	runner := NewRunner(cfg)
+	server := lsp.NewServer(lsp.Options{
+		Analyzers:   cfg.EnabledChecks(),
+		CheckConfig: cfg.CheckConfig,
+		RuleURL:     ruleDocsURL,
+		Severity:    cfg.Severity,
+		Exclude:     runner.isExcludedPath,
+		Version:     ShortVersion(),
+	})
+
+	if err := server.Serve(ctx, os.Stdin, os.Stdout); err != nil {
+		log.Printf("Error: %v", err)
+		return ExitError
+	}
+	return ExitSuccess
+}
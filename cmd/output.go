@@ -5,13 +5,24 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"sort"
 	"strings"
 
-	"github.com/qixialu/azurerm-linter/loader"
+	"github.com/fatih/color"
+	"github.com/qixialu/azurerm-linter/lint"
+	"github.com/qixialu/azurerm-linter/reporting"
+	"github.com/qixialu/azurerm-linter/rules"
 )
 
 var ansiRegex = regexp.MustCompile(`\x1b\[[0-9;]*m`)
 
+// Output formats accepted by --output
+const (
+	OutputText  = "text"
+	OutputJSON  = "json"
+	OutputSARIF = "sarif"
+)
+
 // FilterMode describes how the analysis scope was determined
 type FilterMode string
 
@@ -22,19 +33,35 @@ const (
 	ModeUnfiltered FilterMode = "unfiltered" // --no-filter: all files analyzed
 	ModeDiff       FilterMode = "diff"       // --diff: changes from a diff file
 	ModePR         FilterMode = "pr"         // --pr: changes from a GitHub PR
+	ModeStaged     FilterMode = "staged"     // --staged: changes staged for commit
+	ModeRange      FilterMode = "range"      // --range: changes between two commits
 	ModeLocal      FilterMode = "local"      // default: local git diff
 
 	StatusSuccess Status = "success"
 	StatusIssues  Status = "issues_found"
+	StatusPartial Status = "partial" // --keep-going skipped packages that failed to load
 	StatusError   Status = "error"
 )
 
+// JSONSchemaVersion is the version of the JSON envelope. It is bumped when a field is removed or
+// changes meaning; version 2 made paths repository-relative and added positions, evidence and
+// fingerprints to findings.
+const JSONSchemaVersion = 2
+
+// jsonSchemaURI is the JSON Schema of the envelope, published from docs/json-output.schema.json
+const jsonSchemaURI = "https://raw.githubusercontent.com/qixialu/azurerm-linter/main/docs/json-output.schema.json"
+
 type JSONOutput struct {
-	Version  string        `json:"version"`
-	Status   Status        `json:"status"`
-	Scope    JSONScope     `json:"scope"`
-	Summary  JSONSummary   `json:"summary"`
-	Findings []JSONFinding `json:"findings"`
+	Schema        string          `json:"$schema"`
+	SchemaVersion int             `json:"schema_version"`
+	Version       string          `json:"version"`
+	Status        Status          `json:"status"`
+	Scope         JSONScope       `json:"scope"`
+	Summary       JSONSummary     `json:"summary"`
+	Findings      []JSONFinding   `json:"findings"`
+	Rules         []JSONRule      `json:"rules"`
+	LoadErrors    []JSONLoadError `json:"load_errors,omitempty"`
+	Timings       *JSONTimings    `json:"timings,omitempty"`
 }
 
 type JSONScope struct {
@@ -43,47 +70,175 @@ type JSONScope struct {
 }
 
 type JSONSummary struct {
-	ChangedFiles int `json:"changed_files"`
-	ChangedLines int `json:"changed_lines"`
-	IssueCount   int `json:"issue_count"`
+	ChangedFiles int                    `json:"changed_files"`
+	ChangedLines int                    `json:"changed_lines"`
+	IssueCount   int                    `json:"issue_count"`
+	BySeverity   map[rules.Severity]int `json:"by_severity"`
+	FailOn       string                 `json:"fail_on"` // lowest severity that fails the run, or none
+	Baseline     *BaselineSummary       `json:"baseline,omitempty"`
+}
+
+// JSONLoadError lists why a package skipped by --keep-going could not be analyzed
+type JSONLoadError struct {
+	Package string   `json:"package"`
+	Errors  []string `json:"errors"`
 }
 
-// JSONFinding represents a single diagnostic finding
+// JSONRule is the metadata of a check, see package rules
+type JSONRule struct {
+	ID         string `json:"id"`
+	Category   string `json:"category"`
+	Title      string `json:"title"`
+	Severity   string `json:"severity"`
+	Reference  string `json:"reference"`
+	Docs       string `json:"docs"`
+	MatchMode  string `json:"match_mode"`
+	Notes      string `json:"notes,omitempty"`
+	Since      string `json:"since"`
+	Deprecated string `json:"deprecated,omitempty"`
+	RemovedIn  string `json:"removed_in,omitempty"`
+}
+
+func jsonRule(rule rules.Rule) JSONRule {
+	return JSONRule{
+		ID:         rule.ID,
+		Category:   string(rule.Category),
+		Title:      rule.PlainTitle(),
+		Severity:   string(rule.Severity),
+		Reference:  rule.Reference,
+		Docs:       ruleDocsURL(rule.ID),
+		MatchMode:  rule.MatchMode,
+		Notes:      rule.Notes,
+		Since:      rule.Since,
+		Deprecated: rule.Deprecated,
+		RemovedIn:  rule.RemovedIn,
+	}
+}
+
+// jsonRules returns the metadata of the checks that reported findings, by ID, with the
+// severities set by the config file
+func jsonRules(cfg *Config, findings []Finding) []JSONRule {
+	seen := make(map[string]bool)
+	result := []JSONRule{}
+	for _, f := range findings {
+		if seen[f.CheckID] {
+			continue
+		}
+		seen[f.CheckID] = true
+		if rule, ok := cfg.Rule(f.CheckID); ok {
+			result = append(result, jsonRule(rule))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// Finding is a single diagnostic kept after change filtering and deduplication
+type Finding = lint.Finding
+
+// JSONFinding represents a single diagnostic finding. Paths are relative to the repository root.
 type JSONFinding struct {
-	CheckID string `json:"check_id"`
-	Path    string `json:"path"`
-	Line    int    `json:"line"`
-	Message string `json:"message"`
+	CheckID   string         `json:"check_id"`
+	Severity  rules.Severity `json:"severity"`
+	Path      string         `json:"path"`
+	Line      int            `json:"line"`
+	Column    int            `json:"column"`
+	EndLine   int            `json:"end_line"`
+	EndColumn int            `json:"end_column"`
+	Package   string         `json:"package"`
+	MatchMode string         `json:"match_mode"`
+	Evidence  JSONEvidence   `json:"evidence"`
+
+	// Fingerprint identifies the finding across runs regardless of its line, as in baseline files and SARIF
+	Fingerprint string `json:"fingerprint"`
+	Message     string `json:"message"`
 }
 
-// emitJSON writes the JSON envelope to stdout
-func (r *Runner) emitJSON(status Status, mode FilterMode, patterns []string, findings []JSONFinding) {
-	if findings == nil {
-		findings = []JSONFinding{}
+// JSONEvidence lists the lines whose changes keep a finding in filtered runs
+type JSONEvidence struct {
+	Path  string `json:"path"`
+	Lines []int  `json:"lines"`
+}
+
+// jsonFinding converts a finding, filling in the position and evidence of findings whose
+// check did not record them
+func jsonFinding(cfg *Config, root string, f Finding) JSONFinding {
+	endLine, endColumn := f.EndLine, f.EndColumn
+	if endLine == 0 {
+		endLine, endColumn = f.Line, f.Column
+	}
+
+	evidenceFile, evidenceLines := f.EvidenceFile, f.EvidenceLines
+	if evidenceFile == "" {
+		evidenceFile = f.Path
+	}
+	if len(evidenceLines) == 0 {
+		evidenceLines = []int{f.Line}
 	}
+
+	rule, _ := cfg.Rule(f.CheckID)
+	matchMode := f.MatchMode
+	if matchMode == "" {
+		matchMode = rule.MatchMode
+	}
+	if matchMode == "" {
+		matchMode = reporting.MatchModeExactAdded
+	}
+
+	return JSONFinding{
+		CheckID:     f.CheckID,
+		Severity:    cfg.Severity(f.CheckID),
+		Path:        displayPath(root, f.Path),
+		Line:        f.Line,
+		Column:      f.Column,
+		EndLine:     endLine,
+		EndColumn:   endColumn,
+		Package:     f.PkgPath,
+		MatchMode:   matchMode,
+		Evidence:    JSONEvidence{Path: displayPath(root, evidenceFile), Lines: evidenceLines},
+		Fingerprint: f.Fingerprint,
+		Message:     stripANSI(f.Message),
+	}
+}
+
+// emitStructured writes the findings in the machine-readable format selected by --output
+func (r *Runner) emitStructured(status Status, mode FilterMode, patterns []string, findings []Finding) {
+	switch r.Config.OutputFormat {
+	case OutputSARIF:
+		r.emitSARIF(status, mode, patterns, findings)
+	default:
+		r.emitJSON(status, mode, patterns, findings)
+	}
+}
+
+// emitJSON writes the JSON envelope to stdout
+func (r *Runner) emitJSON(status Status, mode FilterMode, patterns []string, findings []Finding) {
 	if patterns == nil {
 		patterns = []string{}
 	}
 
-	// Sanitize findings for JSON: strip ANSI codes
+	// Sanitize findings for JSON: strip ANSI codes and make paths repository-relative
 	clean := make([]JSONFinding, len(findings))
+	bySeverity := make(map[rules.Severity]int)
+	for _, severity := range rules.Severities() {
+		bySeverity[severity] = 0
+	}
+	root := r.root()
 	for i, f := range findings {
-		clean[i] = JSONFinding{
-			CheckID: f.CheckID,
-			Path:    f.Path,
-			Line:    f.Line,
-			Message: stripANSI(f.Message),
-		}
+		clean[i] = jsonFinding(r.Config, root, f)
+		bySeverity[clean[i].Severity]++
 	}
 
 	var changedFiles, changedLines int
-	if loader.IsEnabled() {
-		changedFiles, changedLines = loader.GetStats()
+	if changes := r.changes(); changes.IsEnabled() {
+		changedFiles, changedLines = changes.GetStats()
 	}
 
 	output := JSONOutput{
-		Version: ShortVersion(),
-		Status:  status,
+		Schema:        jsonSchemaURI,
+		SchemaVersion: JSONSchemaVersion,
+		Version:       ShortVersion(),
+		Status:        status,
 		Scope: JSONScope{
 			Mode:     mode,
 			Patterns: patterns,
@@ -92,8 +247,14 @@ func (r *Runner) emitJSON(status Status, mode FilterMode, patterns []string, fin
 			ChangedFiles: changedFiles,
 			ChangedLines: changedLines,
 			IssueCount:   len(clean),
+			BySeverity:   bySeverity,
+			FailOn:       r.Config.FailOn,
+			Baseline:     r.baselineSummary,
 		},
-		Findings: clean,
+		Findings:   clean,
+		Rules:      jsonRules(r.Config, findings),
+		LoadErrors: r.jsonLoadErrors(),
+		Timings:    jsonTimings(r.timings()),
 	}
 
 	data, err := json.MarshalIndent(output, "", "  ")
@@ -104,6 +265,40 @@ func (r *Runner) emitJSON(status Status, mode FilterMode, patterns []string, fin
 	fmt.Println(string(data))
 }
 
+func (r *Runner) jsonLoadErrors() []JSONLoadError {
+	var loadErrors []JSONLoadError
+	for _, e := range r.loadErrors() {
+		loadErrors = append(loadErrors, JSONLoadError{Package: e.Package, Errors: e.Errors})
+	}
+	return loadErrors
+}
+
+// severityCounts describes how many findings have each severity, most serious first,
+// as " (1 error, 2 warning)"
+func severityCounts(cfg *Config, findings []Finding) string {
+	counts := make(map[rules.Severity]int)
+	for _, f := range findings {
+		counts[cfg.Severity(f.CheckID)]++
+	}
+
+	var parts []string
+	for _, severity := range rules.Severities() {
+		if counts[severity] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[severity], severity))
+		}
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+// textMessage returns a message for text output, without the colors checks embed in messages
+// when color is disabled. Messages of cached findings keep the colors of the run that analyzed them.
+func textMessage(message string) string {
+	if color.NoColor {
+		return ansiRegex.ReplaceAllString(message, "")
+	}
+	return message
+}
+
 // stripANSI removes ANSI escape codes and trims whitespace from a string
 func stripANSI(s string) string {
 	return strings.TrimSpace(ansiRegex.ReplaceAllString(s, ""))
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestJSONFindingIsRepositoryRelative(t *testing.T) {
+	root := t.TempDir()
+	cfg := &Config{}
+	f := Finding{
+		CheckID:       "AZNR001",
+		PkgPath:       "example.com/provider/internal/services/cdn",
+		Scope:         "CdnProfileResource.Arguments",
+		Path:          filepath.Join(root, "internal", "services", "cdn", "cdn_profile_resource.go"),
+		Line:          40,
+		Column:        9,
+		EndLine:       72,
+		EndColumn:     3,
+		Message:       "AZNR001: schema fields are not in the correct order\n",
+		EvidenceFile:  filepath.Join(root, "internal", "services", "cdn", "cdn_profile_resource.go"),
+		EvidenceLines: []int{40, 41},
+		MatchMode:     "new-file",
+		Fingerprint:   "5d41402abc4b2a76b9719d911017c592",
+	}
+
+	got := jsonFinding(cfg, root, f)
+	want := JSONFinding{
+		CheckID:     "AZNR001",
+		Severity:    "error",
+		Path:        "internal/services/cdn/cdn_profile_resource.go",
+		Line:        40,
+		Column:      9,
+		EndLine:     72,
+		EndColumn:   3,
+		Package:     "example.com/provider/internal/services/cdn",
+		MatchMode:   "new-file",
+		Evidence:    JSONEvidence{Path: "internal/services/cdn/cdn_profile_resource.go", Lines: []int{40, 41}},
+		Fingerprint: "5d41402abc4b2a76b9719d911017c592",
+		Message:     "AZNR001: schema fields are not in the correct order",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("jsonFinding() = %+v\nwant %+v", got, want)
+	}
+
+	// Findings without recorded metadata fall back to their position and the rule's match mode
+	bare := jsonFinding(cfg, root, Finding{CheckID: "AZRE001", Path: f.Path, Line: 5, Column: 2, Message: "AZRE001: x"})
+	if bare.EndLine != 5 || bare.EndColumn != 2 || bare.MatchMode != "exact-added" || !reflect.DeepEqual(bare.Evidence.Lines, []int{5}) {
+		t.Fatalf("jsonFinding() without metadata = %+v", bare)
+	}
+}
+
+// TestJSONSchemaDescribesEnvelope checks that docs/json-output.schema.json has a property for
+// every field of the envelope and requires the fields that are always present
+func TestJSONSchemaDescribesEnvelope(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "docs", "json-output.schema.json"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("invalid JSON Schema: %v", err)
+	}
+
+	if schema["$id"] != jsonSchemaURI {
+		t.Errorf("$id = %v, want %s", schema["$id"], jsonSchemaURI)
+	}
+	version := schema["properties"].(map[string]interface{})["schema_version"].(map[string]interface{})
+	if version["const"] != float64(JSONSchemaVersion) {
+		t.Errorf("schema_version const = %v, want %d", version["const"], JSONSchemaVersion)
+	}
+
+	defs, _ := schema["$defs"].(map[string]interface{})
+	compareSchema(t, "envelope", reflect.TypeOf(JSONOutput{}), schema, defs)
+}
+
+func compareSchema(t *testing.T, path string, typ reflect.Type, schema, defs map[string]interface{}) {
+	t.Helper()
+	if ref, ok := schema["$ref"].(string); ok {
+		def, ok := defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]interface{})
+		if !ok {
+			t.Errorf("%s: unresolved $ref %s", path, ref)
+			return
+		}
+		schema = def
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	switch typ.Kind() {
+	case reflect.Slice:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			compareSchema(t, path+"[]", typ.Elem(), items, defs)
+		} else {
+			t.Errorf("%s: schema has no items", path)
+		}
+	case reflect.Struct:
+		properties, _ := schema["properties"].(map[string]interface{})
+		required := make(map[string]bool)
+		for _, name := range schema["required"].([]interface{}) {
+			required[name.(string)] = true
+		}
+
+		fields := make(map[string]bool)
+		for i := 0; i < typ.NumField(); i++ {
+			name, options, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+			fields[name] = true
+			property, ok := properties[name].(map[string]interface{})
+			if !ok {
+				t.Errorf("%s: schema has no property %q", path, name)
+				continue
+			}
+			if omitted := options == "omitempty"; omitted == required[name] {
+				t.Errorf("%s: property %q is required = %v, want %v", path, name, required[name], !omitted)
+			}
+			compareSchema(t, path+"."+name, typ.Field(i).Type, property, defs)
+		}
+
+		var extra []string
+		for name := range properties {
+			if !fields[name] {
+				extra = append(extra, name)
+			}
+		}
+		sort.Strings(extra)
+		if len(extra) > 0 {
+			t.Errorf("%s: schema properties %v are not in the output", path, extra)
+		}
+	}
+}
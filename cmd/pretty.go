@@ -0,0 +1,268 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/qixialu/azurerm-linter/rules"
+)
+
+// Text formats accepted by --format
+const (
+	FormatPlain  = "plain"  // path:line: severity: message, one finding per line
+	FormatPretty = "pretty" // findings grouped by file with source snippets
+)
+
+// maxEvidenceLines caps the evidence lines shown under a finding, as rules such as AZNR001
+// record every field of a schema
+const maxEvidenceLines = 8
+
+// tabWidth is the width tabs in source lines are expanded to
+const tabWidth = 4
+
+var (
+	prettyPath   = color.New(color.Bold).Sprint
+	prettyGutter = color.New(color.FgBlue, color.Bold).Sprint
+	prettyNote   = color.New(color.Faint).Sprint
+
+	severityColors = map[rules.Severity]*color.Color{
+		rules.SeverityError:   color.New(color.FgRed, color.Bold),
+		rules.SeverityWarning: color.New(color.FgYellow, color.Bold),
+		rules.SeverityInfo:    color.New(color.FgCyan, color.Bold),
+	}
+)
+
+// prettyPrinter renders findings with the source lines they point at. Colors follow color.NoColor,
+// which is set when stdout is not a terminal or NO_COLOR is set.
+type prettyPrinter struct {
+	w      io.Writer
+	cfg    *Config
+	root   string
+	files  map[string][]string // source lines by path; nil when the file cannot be read
+	gutter int                 // width of the line numbers of the current file
+}
+
+// printPretty writes findings grouped by file, each with its source lines and a caret under the
+// reported range, followed by the number of findings of each check
+func printPretty(w io.Writer, cfg *Config, root string, findings []Finding) {
+	p := &prettyPrinter{w: w, cfg: cfg, root: root, files: make(map[string][]string)}
+
+	sorted := append([]Finding(nil), findings...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Path != sorted[j].Path {
+			return sorted[i].Path < sorted[j].Path
+		}
+		if sorted[i].Line != sorted[j].Line {
+			return sorted[i].Line < sorted[j].Line
+		}
+		return sorted[i].Column < sorted[j].Column
+	})
+
+	for i, f := range sorted {
+		if i == 0 || f.Path != sorted[i-1].Path {
+			fmt.Fprintf(w, "%s\n", prettyPath(displayPath(root, f.Path)))
+			p.gutter = gutterWidth(sorted[i:])
+		}
+		p.finding(f)
+	}
+
+	fmt.Fprintf(w, "Found %d issue(s)%s\n", len(findings), severityCounts(cfg, findings))
+	p.checkCounts(findings)
+}
+
+// finding prints the header, source snippet and message details of one finding
+func (p *prettyPrinter) finding(f Finding) {
+	severity := p.cfg.Severity(f.CheckID)
+	message := strings.TrimSpace(strings.TrimPrefix(textMessage(f.Message), f.CheckID+":"))
+	summary, details, _ := strings.Cut(message, "\n")
+
+	fmt.Fprintf(p.w, "  %s %s\n", severityColors[severity].Sprintf("%s[%s]:", severity, f.CheckID), summary)
+
+	evidence, hidden := p.evidenceLines(f)
+
+	fmt.Fprintf(p.w, "  %s %s:%d:%d\n", prettyGutter(strings.Repeat(" ", p.gutter)+"-->"), displayPath(p.root, f.Path), f.Line, f.Column)
+	if lines := p.source(f.Path); f.Line >= 1 && f.Line <= len(lines) {
+		p.blank()
+		p.snippet(f, severity, append([]int{f.Line}, evidence...))
+		if hidden > 0 {
+			p.moreLines(hidden)
+		}
+	}
+	if f.EvidenceFile != "" && f.EvidenceFile != f.Path && len(f.EvidenceLines) > 0 {
+		p.otherFileEvidence(f)
+	}
+
+	if details != "" {
+		p.blank()
+		for _, line := range strings.Split(details, "\n") {
+			fmt.Fprintf(p.w, "  %s %s\n", prettyGutter(strings.Repeat(" ", p.gutter)+" ="), line)
+		}
+	}
+	fmt.Fprintln(p.w)
+}
+
+// snippet prints the lines of the finding's file, in order, with the reported range underlined
+// and the evidence lines marked. Gaps between lines are shown as an ellipsis.
+func (p *prettyPrinter) snippet(f Finding, severity rules.Severity, lineNumbers []int) {
+	lines := p.files[f.Path]
+	sort.Ints(lineNumbers)
+
+	previous := 0
+	for _, n := range lineNumbers {
+		if n == previous || n < 1 || n > len(lines) {
+			continue
+		}
+		if previous != 0 && n > previous+1 {
+			fmt.Fprintf(p.w, "  %s\n", prettyGutter(strings.Repeat(" ", p.gutter)+"..."))
+		}
+		previous = n
+
+		source := expandTabs(lines[n-1])
+		if n != f.Line {
+			fmt.Fprintf(p.w, "  %s %s\n", prettyGutter(fmt.Sprintf("%*d ~", p.gutter, n)), source)
+			continue
+		}
+		fmt.Fprintf(p.w, "  %s %s\n", prettyGutter(fmt.Sprintf("%*d |", p.gutter, n)), source)
+
+		start := displayColumn(lines[n-1], f.Column)
+		end := start + 1
+		if f.EndLine == f.Line && f.EndColumn > f.Column {
+			end = displayColumn(lines[n-1], f.EndColumn)
+		} else if f.EndLine > f.Line {
+			end = max(end, len([]rune(source)))
+		}
+		underline := strings.Repeat(" ", start) + strings.Repeat("^", end-start)
+		fmt.Fprintf(p.w, "  %s %s\n", prettyGutter(strings.Repeat(" ", p.gutter)+" |"), severityColors[severity].Sprint(underline))
+	}
+}
+
+// otherFileEvidence prints the evidence lines of a finding whose evidence is in another file
+func (p *prettyPrinter) otherFileEvidence(f Finding) {
+	lines := p.source(f.EvidenceFile)
+	fmt.Fprintf(p.w, "  %s %s\n", prettyGutter(strings.Repeat(" ", p.gutter)+"-->"), displayPath(p.root, f.EvidenceFile))
+	if lines == nil {
+		return
+	}
+	p.blank()
+	for i, n := range f.EvidenceLines {
+		if i == maxEvidenceLines {
+			p.moreLines(len(f.EvidenceLines) - i)
+			break
+		}
+		if n >= 1 && n <= len(lines) {
+			fmt.Fprintf(p.w, "  %s %s\n", prettyGutter(fmt.Sprintf("%*d ~", p.gutter, n)), expandTabs(lines[n-1]))
+		}
+	}
+}
+
+// evidenceLines returns the evidence lines of a finding in its own file other than its line,
+// capped at maxEvidenceLines, and the number of lines left out
+func (p *prettyPrinter) evidenceLines(f Finding) (lines []int, hidden int) {
+	if p.source(f.Path) == nil || (f.EvidenceFile != "" && f.EvidenceFile != f.Path) {
+		return nil, 0
+	}
+
+	for _, n := range f.EvidenceLines {
+		if n != f.Line {
+			lines = append(lines, n)
+		}
+	}
+	if len(lines) > maxEvidenceLines {
+		return lines[:maxEvidenceLines], len(lines) - maxEvidenceLines
+	}
+	return lines, 0
+}
+
+// checkCounts prints the number of findings of each check, by check ID
+func (p *prettyPrinter) checkCounts(findings []Finding) {
+	counts := make(map[string]int)
+	var ids []string
+	for _, f := range findings {
+		if counts[f.CheckID] == 0 {
+			ids = append(ids, f.CheckID)
+		}
+		counts[f.CheckID]++
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		rule, _ := p.cfg.Rule(id)
+		severity := p.cfg.Severity(id)
+		fmt.Fprintf(p.w, "  %-8s %s %4d  %s\n", id, severityColors[severity].Sprintf("%-7s", severity), counts[id], prettyNote(rule.PlainTitle()))
+	}
+}
+
+// gutterWidth returns the width of the largest line number shown for the findings of the file of
+// the first finding, which come first in findings
+func gutterWidth(findings []Finding) int {
+	largest := 0
+	for _, f := range findings {
+		if f.Path != findings[0].Path {
+			break
+		}
+		largest = max(largest, f.Line)
+		for _, n := range f.EvidenceLines {
+			largest = max(largest, n)
+		}
+	}
+	return len(strconv.Itoa(largest))
+}
+
+func (p *prettyPrinter) moreLines(n int) {
+	fmt.Fprintf(p.w, "  %s %s\n", prettyGutter(strings.Repeat(" ", p.gutter)+"..."), prettyNote(fmt.Sprintf("%d more line(s)", n)))
+}
+
+func (p *prettyPrinter) blank() {
+	fmt.Fprintf(p.w, "  %s\n", prettyGutter(strings.Repeat(" ", p.gutter)+" |"))
+}
+
+// source returns the lines of a file, reading it once
+func (p *prettyPrinter) source(path string) []string {
+	if lines, ok := p.files[path]; ok {
+		return lines
+	}
+	var lines []string
+	if data, err := os.ReadFile(path); err == nil {
+		lines = strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	}
+	p.files[path] = lines
+	return lines
+}
+
+// expandTabs replaces tabs with spaces up to the next tab stop
+func expandTabs(line string) string {
+	if !strings.Contains(line, "\t") {
+		return line
+	}
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		if r == '\t' {
+			spaces := tabWidth - width%tabWidth
+			b.WriteString(strings.Repeat(" ", spaces))
+			width += spaces
+			continue
+		}
+		b.WriteRune(r)
+		width++
+	}
+	return b.String()
+}
+
+// displayColumn converts a 1-based byte column of line into a 0-based column of the line with
+// tabs expanded
+func displayColumn(line string, column int) int {
+	if column < 1 {
+		return 0
+	}
+	prefix := line
+	if column-1 < len(line) {
+		prefix = line[:column-1]
+	}
+	return len([]rune(expandTabs(prefix))) + max(0, column-1-len(line))
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestPrintPrettyShowsSnippetsAndEvidence(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = true
+	t.Cleanup(func() { color.NoColor = noColor })
+
+	root := t.TempDir()
+	path := filepath.Join(root, "internal", "services", "cdn", "cdn_profile_resource.go")
+	source := "package cdn\n\nfunc (r CdnProfileResource) Arguments() map[string]*pluginsdk.Schema {\n" +
+		"\treturn map[string]*pluginsdk.Schema{\n" +
+		"\t\t\"sku_name\": {},\n" +
+		"\t\t\"name\": {},\n" +
+		"\t}\n}\n\nfunc flatten() error {\n\treturn fmt.Errorf(\"x\")\n}\n"
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(path, []byte(source), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	findings := []Finding{
+		{
+			CheckID: "AZRE001", Path: path, Line: 11, Column: 9, EndLine: 11, EndColumn: 25,
+			Message: "AZRE001: fixed error strings should use \x1b[32merrors.New()\x1b[0m instead of fmt.Errorf()",
+		},
+		{
+			CheckID: "AZNR001", Path: path, Line: 4, Column: 9, EndLine: 7, EndColumn: 3,
+			Message:      "AZNR001: schema fields are not in the correct order\nExpected order:\n  name, sku_name",
+			EvidenceFile: path, EvidenceLines: []int{5, 6},
+		},
+	}
+
+	var buf bytes.Buffer
+	printPretty(&buf, &Config{}, root, findings)
+
+	want := `internal/services/cdn/cdn_profile_resource.go
+  error[AZNR001]: schema fields are not in the correct order
+    --> internal/services/cdn/cdn_profile_resource.go:4:9
+     |
+   4 |     return map[string]*pluginsdk.Schema{
+     |            ^^^^^^^^^^^^^^^^^^^^^^^^^^^^^
+   5 ~         "sku_name": {},
+   6 ~         "name": {},
+     |
+     = Expected order:
+     =   name, sku_name
+
+  warning[AZRE001]: fixed error strings should use errors.New() instead of fmt.Errorf()
+    --> internal/services/cdn/cdn_profile_resource.go:11:9
+     |
+  11 |     return fmt.Errorf("x")
+     |            ^^^^^^^^^^^^^^^^
+
+Found 2 issue(s) (1 error, 1 warning)
+  AZNR001  error      1  check for Schema field ordering
+  AZRE001  warning    1  check for fixed error strings using fmt.Errorf instead of errors.New
+`
+	if got := buf.String(); got != want {
+		t.Fatalf("printPretty() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDisplayColumnExpandsTabs(t *testing.T) {
+	line := "\t\tx := \"é\" + y"
+	if got := displayColumn(line, 3); got != 8 {
+		t.Fatalf("displayColumn(x) = %d, want 8", got)
+	}
+	// é is two bytes but one column
+	if got := displayColumn(line, strings.Index(line, "+")+1); got != 17 {
+		t.Fatalf("displayColumn(+) = %d, want 17", got)
+	}
+}
+
+func TestPrintPrettyCapsEvidenceAndShowsOtherFiles(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = true
+	t.Cleanup(func() { color.NoColor = noColor })
+
+	root := t.TempDir()
+	path := filepath.Join(root, "registration.go")
+	other := filepath.Join(root, "resource_test.go")
+	if err := os.WriteFile(path, []byte(strings.Repeat("\"x\",\n", 20)), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := os.WriteFile(other, []byte("package cdn\n\nfunc TestX() {}\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	findings := []Finding{
+		{CheckID: "AZNR005", Path: path, Line: 1, Column: 1, Message: "AZNR005: not sorted",
+			EvidenceFile: path, EvidenceLines: []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}},
+		{CheckID: "AZSD001", Path: path, Line: 15, Column: 1, Message: "AZSD001: flatten",
+			EvidenceFile: other, EvidenceLines: []int{3}},
+	}
+
+	var buf bytes.Buffer
+	printPretty(&buf, &Config{}, root, findings)
+	out := buf.String()
+
+	for _, want := range []string{" 9 ~ \"x\",\n", "... 3 more line(s)\n", "--> resource_test.go\n", " 3 ~ func TestX() {}\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output is missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "10 ~") {
+		t.Errorf("output shows more than %d evidence lines:\n%s", maxEvidenceLines, out)
+	}
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"text/tabwriter"
+	"time"
+
+	"github.com/qixialu/azurerm-linter/lint"
+)
+
+// JSONTimings is the --profile report in the JSON envelope
+type JSONTimings struct {
+	LoadMS        float64              `json:"load_ms"`
+	PeakHeapBytes uint64               `json:"peak_heap_bytes"`
+	Analyzers     []JSONAnalyzerTiming `json:"analyzers"`
+}
+
+// JSONAnalyzerTiming is the time an analyzer spent in total and on each package, slowest first
+type JSONAnalyzerTiming struct {
+	Analyzer string              `json:"analyzer"`
+	TotalMS  float64             `json:"total_ms"`
+	Packages []JSONPackageTiming `json:"packages"`
+}
+
+type JSONPackageTiming struct {
+	Package string  `json:"package"`
+	MS      float64 `json:"ms"`
+}
+
+// startProfiles starts the CPU profile of --cpuprofile. The returned function stops it and
+// writes the heap profile of --memprofile.
+func (r *Runner) startProfiles() (stop func(), err error) {
+	var cpuFile *os.File
+	if r.Config.CPUProfile != "" {
+		if cpuFile, err = os.Create(r.Config.CPUProfile); err != nil {
+			return nil, fmt.Errorf("failed to create CPU profile: %w", err)
+		}
+		if err := pprof.StartCPUProfile(cpuFile); err != nil {
+			_ = cpuFile.Close()
+			return nil, fmt.Errorf("failed to start CPU profile: %w", err)
+		}
+	}
+
+	return func() {
+		if cpuFile != nil {
+			pprof.StopCPUProfile()
+			if err := cpuFile.Close(); err != nil {
+				log.Printf("Warning: failed to write CPU profile: %v", err)
+			}
+		}
+		if r.Config.MemProfile != "" {
+			if err := writeMemProfile(r.Config.MemProfile); err != nil {
+				log.Printf("Warning: failed to write memory profile: %v", err)
+			}
+		}
+	}, nil
+}
+
+func writeMemProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	// Collect garbage first so the profile shows up-to-date allocation statistics
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// timings returns the --profile report of the run, or nil without --profile
+func (r *Runner) timings() *lint.Timings {
+	if !r.Config.Profile || r.result == nil {
+		return nil
+	}
+	return r.result.Timings
+}
+
+func jsonTimings(t *lint.Timings) *JSONTimings {
+	if t == nil {
+		return nil
+	}
+
+	result := &JSONTimings{
+		LoadMS:        milliseconds(t.Load),
+		PeakHeapBytes: t.PeakHeap,
+		Analyzers:     make([]JSONAnalyzerTiming, len(t.Analyzers)),
+	}
+	for i, a := range t.Analyzers {
+		timing := JSONAnalyzerTiming{
+			Analyzer: a.Analyzer,
+			TotalMS:  milliseconds(a.Total),
+			Packages: make([]JSONPackageTiming, len(a.Packages)),
+		}
+		for j, p := range a.Packages {
+			timing.Packages[j] = JSONPackageTiming{Package: p.Package, MS: milliseconds(p.Duration)}
+		}
+		result.Analyzers[i] = timing
+	}
+	return result
+}
+
+// printTimings prints the --profile report as a table of analyzers, slowest first
+func printTimings(w io.Writer, t *lint.Timings) {
+	fmt.Fprintf(w, "Profile: packages loaded in %s, peak heap %s\n", roundDuration(t.Load), formatBytes(t.PeakHeap))
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ANALYZER\tTOTAL\tPACKAGES\tSLOWEST PACKAGE")
+	for _, a := range t.Analyzers {
+		slowest := "-"
+		if len(a.Packages) > 0 {
+			slowest = fmt.Sprintf("%s (%s)", a.Packages[0].Package, roundDuration(a.Packages[0].Duration))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", a.Analyzer, roundDuration(a.Total), len(a.Packages), slowest)
+	}
+	_ = tw.Flush()
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+func roundDuration(d time.Duration) time.Duration {
+	if d < time.Millisecond {
+		return d.Round(time.Microsecond)
+	}
+	return d.Round(time.Millisecond)
+}
+
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qixialu/azurerm-linter/lint"
+)
+
+func TestPrintTimingsListsSlowestAnalyzersFirst(t *testing.T) {
+	var out bytes.Buffer
+	printTimings(&out, &lint.Timings{
+		Load:     1500 * time.Millisecond,
+		PeakHeap: 3 << 20,
+		Analyzers: []lint.AnalyzerTiming{
+			{Analyzer: "AZNR002", Total: 2 * time.Second, Packages: []lint.PackageTiming{
+				{Package: "example.com/network", Duration: 1200 * time.Millisecond},
+				{Package: "example.com/compute", Duration: 800 * time.Millisecond},
+			}},
+			{Analyzer: "AZBP001", Total: 3 * time.Millisecond},
+		},
+	})
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("printTimings() printed %d lines, want 4:\n%s", len(lines), out.String())
+	}
+	if lines[0] != "Profile: packages loaded in 1.5s, peak heap 3.0 MiB" {
+		t.Errorf("summary = %q", lines[0])
+	}
+	if fields := strings.Fields(lines[2]); strings.Join(fields, " ") != "AZNR002 2s 2 example.com/network (1.2s)" {
+		t.Errorf("first row = %q, want AZNR002 with its slowest package", lines[2])
+	}
+	if fields := strings.Fields(lines[3]); strings.Join(fields, " ") != "AZBP001 3ms 0 -" {
+		t.Errorf("second row = %q, want AZBP001 without packages", lines[3])
+	}
+}
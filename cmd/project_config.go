@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/qixialu/azurerm-linter/passes"
+	"github.com/qixialu/azurerm-linter/rules"
+	"github.com/qixialu/azurerm-linter/session"
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectConfigFileName is the configuration file discovered at the repository root
+const ProjectConfigFileName = ".azurerm-linter.yaml"
+
+// ProjectConfig is the content of a .azurerm-linter.yaml file
+type ProjectConfig struct {
+	// Enable lists check IDs or category prefixes (e.g. AZBP) to run; empty runs all checks
+	Enable []string `yaml:"enable"`
+	// Disable lists check IDs or category prefixes to skip; takes precedence over Enable
+	Disable []string `yaml:"disable"`
+	// ExcludePaths lists repository-relative path globs whose findings are dropped; ** matches any number of directories
+	ExcludePaths []string `yaml:"exclude-paths"`
+	// SkipPackages replaces the package path fragments skipped by resource analysis
+	SkipPackages []string `yaml:"skip-packages"`
+	// Settings holds per-check options, keyed by check ID and then by analyzer flag name
+	Settings map[string]map[string]interface{} `yaml:"settings"`
+	// Severity overrides the severity of checks, keyed by check ID or category prefix;
+	// a check ID takes precedence over its category
+	Severity map[string]string `yaml:"severity"`
+}
+
+// LoadProjectConfig reads the project configuration file and applies it to the Config.
+// The file given by --config is used when set, otherwise .azurerm-linter.yaml is looked
+// up at the root of the enclosing git repository. A missing discovered file is not an error.
+func (c *Config) LoadProjectConfig() error {
+	configPath := c.ConfigFile
+	if configPath == "" {
+		configPath = discoverProjectConfig()
+		if configPath == "" {
+			return nil
+		}
+	}
+
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	projectCfg, err := parseProjectConfig(content)
+	if err != nil {
+		return fmt.Errorf("invalid config file %s: %w", configPath, err)
+	}
+
+	if err := c.applyProjectConfig(projectCfg); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", configPath, err)
+	}
+
+	c.ConfigFile = configPath
+	return nil
+}
+
+// discoverProjectConfig walks up from the working directory to the repository root
+// and returns the config file path if one exists there
+func discoverProjectConfig() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			candidate := filepath.Join(dir, ProjectConfigFileName)
+			if _, err := os.Stat(candidate); err == nil {
+				return candidate
+			}
+			return ""
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func parseProjectConfig(content []byte) (*ProjectConfig, error) {
+	projectCfg := &ProjectConfig{}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(projectCfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	return projectCfg, nil
+}
+
+// applyProjectConfig validates the project configuration and resolves it into the Config
+func (c *Config) applyProjectConfig(projectCfg *ProjectConfig) error {
+	checks, err := passes.SelectChecks(projectCfg.Enable, projectCfg.Disable)
+	if err != nil {
+		return err
+	}
+
+	for _, pattern := range projectCfg.ExcludePaths {
+		if err := validatePathGlob(pattern); err != nil {
+			return fmt.Errorf("exclude-paths: %w", err)
+		}
+	}
+
+	settings, err := passes.ResolveSettings(projectCfg.Settings)
+	if err != nil {
+		return err
+	}
+
+	severities, err := resolveSeverities(projectCfg.Severity)
+	if err != nil {
+		return fmt.Errorf("severity: %w", err)
+	}
+
+	c.Checks = checks
+	c.ExcludePaths = projectCfg.ExcludePaths
+	c.Severities = severities
+	c.CheckConfig = session.Config{Settings: settings, SkipPackages: projectCfg.SkipPackages}
+	return nil
+}
+
+// resolveSeverities resolves severity overrides keyed by check ID or category prefix into
+// severities by check ID. Shorter selectors apply first, so check IDs override categories.
+func resolveSeverities(overrides map[string]string) (map[string]rules.Severity, error) {
+	selectors := make([]string, 0, len(overrides))
+	for selector := range overrides {
+		selectors = append(selectors, selector)
+	}
+	sort.Slice(selectors, func(i, j int) bool {
+		if len(selectors[i]) != len(selectors[j]) {
+			return len(selectors[i]) < len(selectors[j])
+		}
+		return selectors[i] < selectors[j]
+	})
+
+	var severities map[string]rules.Severity
+	for _, selector := range selectors {
+		severity, err := rules.ParseSeverity(overrides[selector])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", selector, err)
+		}
+
+		matched := false
+		for _, rule := range rules.All() {
+			if !rules.MatchesSelector(rule.ID, selector) {
+				continue
+			}
+			if severities == nil {
+				severities = make(map[string]rules.Severity)
+			}
+			severities[rule.ID] = severity
+			matched = true
+		}
+		if !matched {
+			return nil, fmt.Errorf("unknown check or category %q", selector)
+		}
+	}
+	return severities, nil
+}
+
+func validatePathGlob(pattern string) error {
+	if strings.TrimSpace(pattern) == "" {
+		return errors.New("empty path glob")
+	}
+	for _, segment := range strings.Split(filepath.ToSlash(pattern), "/") {
+		if segment == "**" {
+			continue
+		}
+		if _, err := path.Match(segment, ""); err != nil {
+			return fmt.Errorf("invalid path glob %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// matchPathGlob matches a slash-separated path against a glob where ** spans any number of directories
+func matchPathGlob(pattern, name string) bool {
+	return matchGlobSegments(strings.Split(filepath.ToSlash(pattern), "/"), strings.Split(filepath.ToSlash(name), "/"))
+}
+
+func matchGlobSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchGlobSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+
+		pattern, name = pattern[1:], name[1:]
+	}
+
+	return len(name) == 0
+}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qixialu/azurerm-linter/helper"
+	"github.com/qixialu/azurerm-linter/passes"
+	"github.com/qixialu/azurerm-linter/rules"
+)
+
+func TestLoadProjectConfigSelectsChecksByIDAndCategory(t *testing.T) {
+	configPath := writeProjectConfig(t, `
+enable:
+  - AZNR
+  - azbp005
+disable:
+  - AZNR002
+exclude-paths:
+  - internal/services/legacy/**
+`)
+
+	cfg := &Config{ConfigFile: configPath}
+	if err := cfg.LoadProjectConfig(); err != nil {
+		t.Fatalf("LoadProjectConfig() error = %v", err)
+	}
+
+	var names []string
+	for _, analyzer := range cfg.EnabledChecks() {
+		names = append(names, analyzer.Name)
+	}
+	got := strings.Join(names, ",")
+	if got != "AZBP005,AZNR001,AZNR004,AZNR005,AZNR006,AZNR008" {
+		t.Fatalf("EnabledChecks() = %s", got)
+	}
+	if len(cfg.ExcludePaths) != 1 {
+		t.Fatalf("ExcludePaths = %v, want one glob", cfg.ExcludePaths)
+	}
+}
+
+func TestLoadProjectConfigResolvesCheckSettingsAndSkipPackages(t *testing.T) {
+	flag := passes.AZBP005Analyzer.Flags.Lookup("license-header")
+	original := flag.Value.String()
+
+	configPath := writeProjectConfig(t, `
+skip-packages:
+  - /sdk
+settings:
+  AZBP005:
+    license-header: |
+      // Copyright (c) Contoso
+      // SPDX-License-Identifier: MPL-2.0
+`)
+
+	cfg := &Config{ConfigFile: configPath}
+	if err := cfg.LoadProjectConfig(); err != nil {
+		t.Fatalf("LoadProjectConfig() error = %v", err)
+	}
+
+	if got := cfg.CheckConfig.Settings["AZBP005"]["license-header"]; !strings.HasPrefix(got, "// Copyright (c) Contoso\n") {
+		t.Fatalf("license-header = %q, want configured header", got)
+	}
+	if got := flag.Value.String(); got != original {
+		t.Fatalf("analyzer flag license-header = %q, want it unchanged as %q", got, original)
+	}
+
+	skipPackages := cfg.CheckConfig.SkipPackages
+	if !helper.ShouldSkipPackageForResourceAnalysis("example.com/internal/services/cdn/sdk", skipPackages) {
+		t.Fatalf("configured skip-packages entry was not applied")
+	}
+	if helper.ShouldSkipPackageForResourceAnalysis("example.com/internal/services/cdn/validate", skipPackages) {
+		t.Fatalf("skip-packages should replace the default list")
+	}
+}
+
+func TestLoadProjectConfigOverridesSeverities(t *testing.T) {
+	configPath := writeProjectConfig(t, `
+severity:
+  AZBP: info
+  azbp005: Error
+  AZRE001: error
+`)
+
+	cfg := &Config{ConfigFile: configPath, FailOn: "warning"}
+	if err := cfg.LoadProjectConfig(); err != nil {
+		t.Fatalf("LoadProjectConfig() error = %v", err)
+	}
+
+	tests := map[string]rules.Severity{
+		"AZBP001": rules.SeverityInfo,  // category override
+		"AZBP005": rules.SeverityError, // the check ID takes precedence
+		"AZRE001": rules.SeverityError,
+		"AZNR001": rules.SeverityError, // registry default
+		"AZSD001": rules.SeverityWarning,
+	}
+	for checkID, want := range tests {
+		if got := cfg.Severity(checkID); got != want {
+			t.Errorf("Severity(%s) = %s, want %s", checkID, got, want)
+		}
+	}
+
+	if cfg.Fails("AZBP001") || !cfg.Fails("AZSD001") || !cfg.Fails("AZRE001") {
+		t.Fatalf("--fail-on=warning should fail on warnings and errors only")
+	}
+	cfg.FailOn = "error"
+	if cfg.Fails("AZSD001") || !cfg.Fails("AZBP005") {
+		t.Fatalf("--fail-on=error should fail on errors only")
+	}
+	cfg.FailOn = FailOnNone
+	if cfg.Fails("AZBP005") {
+		t.Fatalf("--fail-on=none should never fail")
+	}
+}
+
+func TestLoadProjectConfigRejectsInvalidContent(t *testing.T) {
+	tests := map[string]string{
+		"unknown key":            "enabled: [AZBP001]\n",
+		"unknown check":          "enable: [AZXX]\n",
+		"partial check ID":       "enable: [AZNR00]\n",
+		"partial prefix":         "disable: [AZ]\n",
+		"empty selector":         "enable: ['']\n",
+		"nothing enabled":        "enable: [AZRE001]\ndisable: [AZRE]\n",
+		"unknown setting":        "settings:\n  AZBP005:\n    header: x\n",
+		"bad glob":               "exclude-paths: ['internal/[services']\n",
+		"bad severity":           "severity:\n  AZBP005: fatal\n",
+		"unknown severity check": "severity:\n  AZXX001: error\n",
+		"partial severity check": "severity:\n  AZBP00: error\n",
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := &Config{ConfigFile: writeProjectConfig(t, content)}
+			if err := cfg.LoadProjectConfig(); err == nil {
+				t.Fatalf("LoadProjectConfig() error = nil, want validation error")
+			}
+		})
+	}
+}
+
+func TestMatchPathGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"internal/services/legacy/**", "internal/services/legacy/a/b_resource.go", true},
+		{"internal/services/legacy/**", "internal/services/legacyx/a.go", false},
+		{"**/*_test.go", "internal/services/cdn/cdn_test.go", true},
+		{"internal/*/cdn/*.go", "internal/services/cdn/registration.go", true},
+		{"internal/*/cdn/*.go", "internal/services/cdn/client/client.go", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchPathGlob(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("matchPathGlob(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func writeProjectConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	configPath := filepath.Join(t.TempDir(), ProjectConfigFileName)
+	if err := os.WriteFile(configPath, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return configPath
+}
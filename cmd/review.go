@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/qixialu/azurerm-linter/loader"
+)
+
+// postReview posts the findings as a review of the PR given by --pr. Nothing is posted without findings.
+func (r *Runner) postReview(findings []Finding) error {
+	if len(findings) == 0 {
+		log.Println("No issues found, not posting a review")
+		return nil
+	}
+
+	changes := r.changes()
+	return loader.PostReview(changes.PullRequest(), buildReview(r.root(), findings, changes))
+}
+
+// buildReview turns findings into review comments. Findings on lines GitHub can comment on,
+// those within a hunk of the PR diff, become inline comments; the rest go to the review body.
+func buildReview(root string, findings []Finding, changes *loader.ChangeSet) loader.Review {
+	review := loader.Review{
+		Summary: fmt.Sprintf("azurerm-linter found %d issue(s) in this PR.", len(findings)),
+	}
+
+	for _, f := range findings {
+		comment := loader.ReviewComment{
+			Path: displayPath(root, f.Path),
+			Line: f.Line,
+			Body: fmt.Sprintf("**%s**: %s", f.CheckID, stripANSI(f.Message)),
+		}
+
+		if changes.TouchesChanges(f.Path, f.Line, f.Line) {
+			review.Comments = append(review.Comments, comment)
+		} else {
+			review.Outside = append(review.Outside, comment)
+		}
+	}
+
+	return review
+}
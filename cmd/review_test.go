@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/qixialu/azurerm-linter/loader"
+)
+
+func TestBuildReviewPostsOnlyDiffLinesInline(t *testing.T) {
+	diffPath := filepath.Join(t.TempDir(), "pr.diff")
+	diff := `diff --git a/internal/services/cdn/cdn_profile_resource.go b/internal/services/cdn/cdn_profile_resource.go
+index 1111111..2222222 100644
+--- a/internal/services/cdn/cdn_profile_resource.go
++++ b/internal/services/cdn/cdn_profile_resource.go
+@@ -10,3 +10,4 @@ func (r CdnProfileResource) Arguments() map[string]*pluginsdk.Schema {
+ 		"name": {
++			Optional: true,
+ 			Type:     pluginsdk.TypeString,
+ 		},
+`
+	if err := os.WriteFile(diffPath, []byte(diff), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	cs, err := loader.LoadChanges(loader.LoaderOptions{DiffFile: diffPath})
+	if err != nil {
+		t.Fatalf("LoadChanges() error = %v", err)
+	}
+
+	root := "repo"
+	file := filepath.Join(root, "internal", "services", "cdn", "cdn_profile_resource.go")
+	review := buildReview(root, []Finding{
+		{CheckID: "AZNR001", Path: file, Line: 11, Message: "\x1b[33mname\x1b[0m: fields out of order"},
+		{CheckID: "AZBP002", Path: file, Line: 40, Message: "Optional field has no default"},
+	}, cs)
+
+	if len(review.Comments) != 1 || len(review.Outside) != 1 {
+		t.Fatalf("review = %+v, want one inline comment and one finding outside the diff", review)
+	}
+	want := loader.ReviewComment{Path: "internal/services/cdn/cdn_profile_resource.go", Line: 11, Body: "**AZNR001**: name: fields out of order"}
+	if review.Comments[0] != want {
+		t.Fatalf("inline comment = %+v, want %+v", review.Comments[0], want)
+	}
+	if review.Outside[0].Line != 40 {
+		t.Fatalf("outside comment line = %d, want 40", review.Outside[0].Line)
+	}
+}
+
+func TestPostReviewSkipsRunsWithoutFindings(t *testing.T) {
+	// No PR is loaded, so any attempt to post would fail
+	r := NewRunner(&Config{PostReview: true})
+	if err := r.postReview(nil); err != nil {
+		t.Fatalf("postReview() error = %v, want nothing posted for zero findings", err)
+	}
+}
@@ -3,15 +3,13 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"go/token"
 	"log"
+	"os"
+	"path/filepath"
+	"strings"
 
-	"github.com/qixialu/azurerm-linter/helper"
+	"github.com/qixialu/azurerm-linter/lint"
 	"github.com/qixialu/azurerm-linter/loader"
-	"github.com/qixialu/azurerm-linter/passes"
-	"github.com/qixialu/azurerm-linter/reporting"
-	"golang.org/x/tools/go/analysis/checker"
-	"golang.org/x/tools/go/packages"
 )
 
 // ExitCode represents program exit codes
@@ -25,6 +23,12 @@ const (
 
 type Runner struct {
 	Config *Config
+
+	// baselineSummary is set when findings were compared against --baseline
+	baselineSummary *BaselineSummary
+
+	// result is set once the analysis ran
+	result *lint.Result
 }
 
 // NewRunner creates a new Runner with the given config
@@ -36,124 +40,195 @@ func NewRunner(cfg *Config) *Runner {
 
 // Run executes the linter and returns an exit code
 func (r *Runner) Run(ctx context.Context) ExitCode {
-	defer loader.CleanupWorktree()
-	reporting.Reset()
+	stopProfiles, err := r.startProfiles()
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return ExitError
+	}
+	defer stopProfiles()
+
+	exitCode := r.run(ctx)
+	if t := r.timings(); t != nil && r.Config.OutputFormat != OutputJSON {
+		printTimings(os.Stderr, t)
+	}
+	return exitCode
+}
 
-	isJSON := r.Config.OutputFormat == "json"
+func (r *Runner) run(ctx context.Context) ExitCode {
+	structured := r.Config.OutputFormat != OutputText
 	scopeMode := r.detectFilterMode()
 
-	loaderOpts := loader.LoaderOptions{
-		NoFilter:   r.Config.NoFilter,
-		PRNumber:   r.Config.PRNumber,
-		RemoteName: r.Config.RemoteName,
-		BaseBranch: r.Config.BaseBranch,
-		DiffFile:   r.Config.DiffFile,
+	var baseline *Baseline
+	if r.Config.BaselineFile != "" {
+		var err error
+		baseline, err = readBaseline(r.Config.BaselineFile)
+		if err != nil {
+			if structured {
+				r.emitStructured(StatusError, scopeMode, r.Config.Patterns, nil)
+			} else {
+				log.Printf("Error: %v", err)
+			}
+			return ExitError
+		}
 	}
 
-	_, err := loader.LoadChanges(loaderOpts)
+	res, err := lint.Run(ctx, lint.Options{
+		Patterns:    r.Config.Patterns,
+		Analyzers:   r.Config.EnabledChecks(),
+		CheckConfig: r.Config.CheckConfig,
+		Cache:       r.openCache(),
+		Version:     Version,
+		Profile:     r.Config.Profile,
+		KeepGoing:   r.Config.KeepGoing,
+		Changes: loader.LoaderOptions{
+			NoFilter:     r.Config.NoFilter,
+			PRNumber:     r.Config.PRNumber,
+			RemoteName:   r.Config.RemoteName,
+			BaseBranch:   r.Config.BaseBranch,
+			DiffFile:     r.Config.DiffFile,
+			Staged:       r.Config.Staged,
+			Range:        r.Config.Range,
+			Repo:         r.Config.Repo,
+			GitHubAPIURL: r.Config.GitHubAPIURL,
+		},
+	})
 	if err != nil {
-		log.Printf("Warning: failed to load changed lines filter: %v", err)
-	}
-
-	// Determine package patterns to analyze
-	patterns := r.Config.Patterns
-	if loader.IsEnabled() {
-		files, lines := loader.GetStats()
-		log.Printf("Changed lines filter: tracking %d files with %d changed lines", files, lines)
-
-		// If change tracking is enabled and no patterns specified, use changed packages
-		if len(r.Config.Patterns) == 0 {
-			changedPackages := loader.GetChangedPackages()
-			if len(changedPackages) > 0 {
-				patterns = changedPackages
-				log.Printf("Auto-detected %d changed packages:", len(patterns))
-				for _, pkg := range patterns {
-					log.Printf("  %s", pkg)
-				}
-			}
+		if structured {
+			r.emitStructured(StatusError, scopeMode, r.Config.Patterns, nil)
+		} else {
+			log.Printf("Error: %v", err)
 		}
+		return ExitError
 	}
+	defer func() {
+		if err := res.Close(); err != nil {
+			log.Printf("Warning: failed to cleanup worktree: %v", err)
+		}
+	}()
+	r.result = res
+	patterns := res.Patterns
 
 	// Validate we have patterns to analyze
 	if len(patterns) == 0 {
-		if isJSON {
-			r.emitJSON(StatusSuccess, scopeMode, patterns, nil)
+		if structured {
+			r.emitStructured(StatusSuccess, scopeMode, patterns, nil)
 		} else {
 			log.Println("✓ no service package to analyze")
 		}
 		return ExitSuccess
 	}
 
-	log.Printf("Loading packages...")
-	cfg := &packages.Config{
-		Mode:  packages.LoadAllSyntax,
-		Tests: true,
-	}
-	pkgs, err := packages.Load(cfg, patterns...)
-	if err != nil {
-		if isJSON {
-			r.emitJSON(StatusError, scopeMode, patterns, nil)
-		} else {
-			log.Printf("Error: failed to load packages: %v", err)
-		}
-		return ExitError
-	}
+	findings := r.excludePaths(res.Findings)
 
-	// Check for package loading errors
-	var hasLoadErrors bool
-	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
-		for _, err := range pkg.Errors {
-			log.Printf("Error: failed to load package: %v", err)
-			hasLoadErrors = true
+	if r.Config.WriteBaselineFile != "" {
+		if err := writeBaseline(r.Config.WriteBaselineFile, r.root(), findings); err != nil {
+			if structured {
+				r.emitStructured(StatusError, scopeMode, patterns, nil)
+			} else {
+				log.Printf("Error: %v", err)
+			}
+			return ExitError
 		}
-	})
-	if hasLoadErrors {
-		if isJSON {
-			r.emitJSON(StatusError, scopeMode, patterns, nil)
+		log.Printf("✓ Recorded %d finding(s) in baseline %s", len(findings), r.Config.WriteBaselineFile)
+		if structured {
+			r.emitStructured(StatusSuccess, scopeMode, patterns, nil)
 		}
-		return ExitError
+		return ExitSuccess
 	}
 
-	// Provide loaded packages to analyzers for cross-package schema resolution
-	helper.SetGlobalPackages(pkgs)
-
-	log.Printf("Running analysis...")
-	graph, err := checker.Analyze(passes.AllChecks, pkgs, nil)
-	if err != nil {
-		if isJSON {
-			r.emitJSON(StatusError, scopeMode, patterns, nil)
+	if baseline != nil {
+		var summary BaselineSummary
+		filtered := res.Changes.IsEnabled()
+		findings, summary = applyBaseline(baseline, findings, res.Packages, filtered)
+		summary.File = r.Config.BaselineFile
+		r.baselineSummary = &summary
+		if filtered {
+			log.Printf("Baseline: %d pre-existing finding(s) suppressed", summary.Suppressed)
 		} else {
-			log.Printf("Error: analysis failed: %v", err)
+			log.Printf("Baseline: %d pre-existing finding(s) suppressed, %d fixed since baseline", summary.Suppressed, summary.Fixed)
 		}
-		return ExitError
 	}
 
-	// Collect and report diagnostics
-	findings := r.collectFindings(graph)
+	if r.Config.Fix || r.Config.DiffFix {
+		unfixed := r.runFixes(findings)
+		if r.Config.DiffFix {
+			// The diff is the output; findings without an applicable fix are only counted
+			if len(unfixed) > 0 {
+				log.Printf("%d issue(s) have no fix that can be applied within the current diff", len(unfixed))
+			}
+			if r.failing(findings) > 0 {
+				return ExitIssuesFound
+			}
+			return ExitSuccess
+		}
+		findings = unfixed
+	}
 
-	if isJSON {
+	partial := len(r.loadErrors()) > 0
+	if structured {
 		status := StatusSuccess
-		if len(findings) > 0 {
+		switch {
+		case partial:
+			status = StatusPartial
+		case len(findings) > 0:
 			status = StatusIssues
 		}
-		r.emitJSON(status, scopeMode, patterns, findings)
+		r.emitStructured(status, scopeMode, patterns, findings)
 	} else {
-		for _, f := range findings {
-			fmt.Printf("%s:%d: %s\n", f.Path, f.Line, f.Message)
-		}
-		if len(findings) > 0 {
-			fmt.Printf("Found %d issue(s)\n", len(findings))
-		} else {
+		switch {
+		case len(findings) > 0 && r.Config.Format == FormatPretty:
+			printPretty(os.Stdout, r.Config, r.root(), findings)
+		case len(findings) > 0:
+			for _, f := range findings {
+				fmt.Printf("%s:%d: %s: %s\n", f.Path, f.Line, r.Config.Severity(f.CheckID), textMessage(f.Message))
+			}
+			fmt.Printf("Found %d issue(s)%s\n", len(findings), severityCounts(r.Config, findings))
+		case !partial:
 			log.Printf("✓ Analysis completed successfully with no issues found")
 		}
+		if partial {
+			log.Printf("Warning: %d package(s) could not be analyzed:", len(r.loadErrors()))
+			for _, e := range r.loadErrors() {
+				log.Printf("  %s", e.Package)
+			}
+		}
+	}
+
+	if r.Config.PostReview {
+		if err := r.postReview(findings); err != nil {
+			log.Printf("Error: failed to post review: %v", err)
+			return ExitError
+		}
 	}
 
-	if len(findings) > 0 {
+	if r.failing(findings) > 0 {
 		return ExitIssuesFound
 	}
+	if partial {
+		return ExitError
+	}
 	return ExitSuccess
 }
 
+// failing counts the findings whose severity fails the run under --fail-on
+func (r *Runner) failing(findings []Finding) int {
+	count := 0
+	for _, f := range findings {
+		if r.Config.Fails(f.CheckID) {
+			count++
+		}
+	}
+	return count
+}
+
+// loadErrors returns the packages --keep-going skipped
+func (r *Runner) loadErrors() []lint.PackageError {
+	if r.result == nil {
+		return nil
+	}
+	return r.result.LoadErrors
+}
+
 // detectFilterMode returns the FilterMode based on the current config
 func (r *Runner) detectFilterMode() FilterMode {
 	switch {
@@ -163,52 +238,64 @@ func (r *Runner) detectFilterMode() FilterMode {
 		return ModeDiff
 	case r.Config.PRNumber > 0:
 		return ModePR
+	case r.Config.Staged:
+		return ModeStaged
+	case r.Config.Range != "":
+		return ModeRange
 	default:
 		return ModeLocal
 	}
 }
 
-// collectFindings walks the analysis graph and returns deduplicated findings.
-func (r *Runner) collectFindings(graph *checker.Graph) []JSONFinding {
-	var findings []JSONFinding
-	// Deduplicate diagnostics by "file:line:column|message"
-	// When Tests=true, the same source file may be analyzed in both main and test packages
-	// (when user doesn't mark test pkg as *_test), causing identical diagnostics to appear twice
-	seen := make(map[string]bool)
-
-	for act := range graph.All() {
-		if act.Err != nil {
-			continue
-		}
+// changes returns the changes findings were filtered by, or nil when filtering is disabled
+// or the analysis did not run
+func (r *Runner) changes() *loader.ChangeSet {
+	if r.result == nil {
+		return nil
+	}
+	return r.result.Changes
+}
 
-		for _, diag := range act.Diagnostics {
-			pos := act.Package.Fset.Position(diag.Pos)
-			if !shouldKeepDiagnostic(act.Package.PkgPath, pos, diag.Message) {
-				continue
-			}
-			key := fmt.Sprintf("%s:%d:%d|%s", pos.Filename, pos.Line, pos.Column, diag.Message)
+// root returns the directory the packages were loaded from, which finding paths are relative to
+func (r *Runner) root() string {
+	if r.result != nil {
+		return r.result.Root
+	}
+	root, _ := os.Getwd()
+	return root
+}
 
-			if seen[key] {
-				continue
-			}
-			seen[key] = true
-
-			findings = append(findings, JSONFinding{
-				CheckID: act.Analyzer.Name,
-				Path:    pos.Filename,
-				Line:    pos.Line,
-				Message: diag.Message,
-			})
+// excludePaths drops findings in files matching the configured exclude-paths globs
+func (r *Runner) excludePaths(findings []Finding) []Finding {
+	if len(r.Config.ExcludePaths) == 0 {
+		return findings
+	}
+
+	root := r.root()
+	kept := findings[:0:0]
+	for _, f := range findings {
+		if !r.isExcludedPath(root, f.Path) {
+			kept = append(kept, f)
 		}
 	}
-	return findings
+	return kept
 }
 
-func shouldKeepDiagnostic(pkgPath string, pos token.Position, message string) bool {
-	meta, ok := reporting.Lookup(pkgPath, pos.Filename, pos.Line, pos.Column, message)
-	if !ok {
-		return true
+// isExcludedPath reports whether a file matches one of the configured exclude-paths globs
+func (r *Runner) isExcludedPath(root, filename string) bool {
+	if len(r.Config.ExcludePaths) == 0 {
+		return false
 	}
 
-	return loader.ShouldKeepDiagnostic(meta)
+	relPath := filename
+	if rel, err := filepath.Rel(root, filename); err == nil && !strings.HasPrefix(rel, "..") {
+		relPath = rel
+	}
+
+	for _, pattern := range r.Config.ExcludePaths {
+		if matchPathGlob(pattern, relPath) {
+			return true
+		}
+	}
+	return false
 }
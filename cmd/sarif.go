@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/qixialu/azurerm-linter/rules"
+)
+
+const (
+	sarifSchemaURI  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion    = "2.1.0"
+	sarifToolName   = "azurerm-linter"
+	sarifToolURI    = "https://github.com/qixialu/azurerm-linter"
+	sarifSrcRoot    = "%SRCROOT%"
+	fingerprintKey  = "azurermLinterFingerprint/v2"
+	ruleDocsBaseURI = sarifToolURI + "/blob/main/docs/rules/"
+	ruleSourceURI   = sarifToolURI + "/blob/main/passes/"
+)
+
+// ruleDocsURL returns the link to the documentation page of a check, generated into docs/rules
+func ruleDocsURL(checkID string) string {
+	return ruleDocsBaseURI + checkID + ".md"
+}
+
+// ruleSourceURL returns the link to the analyzer source of a check
+func ruleSourceURL(checkID string) string {
+	return ruleSourceURI + checkID + ".go"
+}
+
+type SARIFLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SARIFRun `json:"runs"`
+}
+
+type SARIFRun struct {
+	Tool        SARIFTool         `json:"tool"`
+	Invocations []SARIFInvocation `json:"invocations"`
+	Results     []SARIFResult     `json:"results"`
+	Properties  SARIFRunProps     `json:"properties"`
+}
+
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+type SARIFDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []SARIFRule `json:"rules"`
+}
+
+type SARIFRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     SARIFMessage       `json:"shortDescription"`
+	FullDescription      SARIFMessage       `json:"fullDescription"`
+	Help                 SARIFMessage       `json:"help"`
+	HelpURI              string             `json:"helpUri"`
+	DefaultConfiguration SARIFConfiguration `json:"defaultConfiguration"`
+	Properties           SARIFRuleProps     `json:"properties"`
+}
+
+type SARIFConfiguration struct {
+	Level string `json:"level"`
+}
+
+type SARIFRuleProps struct {
+	Tags      []string `json:"tags"`
+	Reference string   `json:"reference"`
+	Since     string   `json:"since"`
+}
+
+type SARIFInvocation struct {
+	ExecutionSuccessful bool `json:"executionSuccessful"`
+}
+
+type SARIFRunProps struct {
+	FilterMode FilterMode `json:"filterMode"`
+	Patterns   []string   `json:"patterns"`
+	Status     Status     `json:"status"`
+}
+
+type SARIFMessage struct {
+	Text string `json:"text"`
+}
+
+type SARIFResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           *int              `json:"ruleIndex,omitempty"`
+	Level               string            `json:"level"`
+	Message             SARIFMessage      `json:"message"`
+	Locations           []SARIFLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+}
+
+type SARIFLocation struct {
+	PhysicalLocation SARIFPhysicalLocation `json:"physicalLocation"`
+}
+
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+	Region           SARIFRegion           `json:"region"`
+}
+
+type SARIFArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type SARIFRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// emitSARIF writes a SARIF 2.1.0 log to stdout
+func (r *Runner) emitSARIF(status Status, mode FilterMode, patterns []string, findings []Finding) {
+	data, err := json.MarshalIndent(buildSARIF(status, mode, patterns, findings, r.root(), r.Config.Rule), "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to marshal SARIF output: %v\n", err)
+		return
+	}
+	fmt.Println(string(data))
+}
+
+// buildSARIF converts findings into a SARIF log with one rule per active check of the rules
+// registry, the source of --list and of the rules of JSON output as well. File paths are
+// made relative to root so code-scanning can map them onto the repository. lookup returns the
+// metadata of a check with its configured severity, which decides the level of its results.
+func buildSARIF(status Status, mode FilterMode, patterns []string, findings []Finding, root string, lookup func(checkID string) (rules.Rule, bool)) SARIFLog {
+	if patterns == nil {
+		patterns = []string{}
+	}
+
+	sarifRules := []SARIFRule{}
+	ruleIndex := make(map[string]int)
+	for _, rule := range rules.All() {
+		if rule.IsDeprecated() {
+			continue
+		}
+		rule, _ = lookup(rule.ID)
+		ruleIndex[rule.ID] = len(sarifRules)
+		sarifRules = append(sarifRules, sarifRule(rule))
+	}
+
+	results := make([]SARIFResult, 0, len(findings))
+	for _, f := range findings {
+		uri, baseID := sarifArtifactURI(f.Path, root)
+		message := stripANSI(f.Message)
+
+		var index *int
+		if i, ok := ruleIndex[f.CheckID]; ok {
+			index = &i
+		}
+		rule, _ := lookup(f.CheckID)
+
+		results = append(results, SARIFResult{
+			RuleID:    f.CheckID,
+			RuleIndex: index,
+			Level:     sarifLevel(rule.Severity),
+			Message:   SARIFMessage{Text: message},
+			Locations: []SARIFLocation{{
+				PhysicalLocation: SARIFPhysicalLocation{
+					ArtifactLocation: SARIFArtifactLocation{URI: uri, URIBaseID: baseID},
+					Region:           SARIFRegion{StartLine: f.Line, StartColumn: f.Column},
+				},
+			}},
+			PartialFingerprints: map[string]string{
+				fingerprintKey: f.Fingerprint,
+			},
+		})
+	}
+
+	return SARIFLog{
+		Schema:  sarifSchemaURI,
+		Version: sarifVersion,
+		Runs: []SARIFRun{{
+			Tool: SARIFTool{Driver: SARIFDriver{
+				Name:           sarifToolName,
+				Version:        ShortVersion(),
+				InformationURI: sarifToolURI,
+				Rules:          sarifRules,
+			}},
+			Invocations: []SARIFInvocation{{ExecutionSuccessful: status != StatusError}},
+			Results:     results,
+			Properties: SARIFRunProps{
+				FilterMode: mode,
+				Patterns:   patterns,
+				Status:     status,
+			},
+		}},
+	}
+}
+
+// sarifLevel maps a severity to a SARIF level. Checks without metadata report warnings.
+func sarifLevel(severity rules.Severity) string {
+	switch severity {
+	case rules.SeverityError:
+		return "error"
+	case rules.SeverityInfo:
+		return "note"
+	default:
+		return "warning"
+	}
+}
+
+// sarifRule describes a check: its title, with its notes as the full description, and its
+// contributing guide reference as help
+func sarifRule(rule rules.Rule) SARIFRule {
+	description := rule.PlainTitle()
+	if rule.Notes != "" {
+		description += ". " + rule.Notes
+	}
+
+	return SARIFRule{
+		ID:                   rule.ID,
+		ShortDescription:     SARIFMessage{Text: rule.PlainTitle()},
+		FullDescription:      SARIFMessage{Text: description},
+		Help:                 SARIFMessage{Text: description + "\n\nSee " + rule.Reference},
+		HelpURI:              ruleDocsURL(rule.ID),
+		DefaultConfiguration: SARIFConfiguration{Level: sarifLevel(rule.Severity)},
+		Properties: SARIFRuleProps{
+			Tags:      []string{string(rule.Category)},
+			Reference: rule.Reference,
+			Since:     rule.Since,
+		},
+	}
+}
+
+// sarifArtifactURI returns a repository-relative URI for path, or an absolute file URI
+// when the path lies outside root
+func sarifArtifactURI(path, root string) (uri, baseID string) {
+	if root != "" {
+		if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel), sarifSrcRoot
+		}
+	}
+
+	abs := filepath.ToSlash(path)
+	if !strings.HasPrefix(abs, "/") {
+		abs = "/" + abs
+	}
+	return "file://" + abs, ""
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qixialu/azurerm-linter/lint"
+	"github.com/qixialu/azurerm-linter/passes"
+	"github.com/qixialu/azurerm-linter/rules"
+)
+
+func TestBuildSARIFDeclaresOneRulePerCheck(t *testing.T) {
+	log := buildSARIF(StatusSuccess, ModeLocal, nil, nil, t.TempDir(), rules.Lookup)
+
+	if log.Version != sarifVersion {
+		t.Fatalf("Version = %q, want %q", log.Version, sarifVersion)
+	}
+	if len(log.Runs) != 1 {
+		t.Fatalf("len(Runs) = %d, want 1", len(log.Runs))
+	}
+
+	sarifRules := log.Runs[0].Tool.Driver.Rules
+	if len(sarifRules) != len(passes.AllChecks) {
+		t.Fatalf("len(Rules) = %d, want %d", len(sarifRules), len(passes.AllChecks))
+	}
+	// Rules come from the registry in its order, the source of --list and JSON rules too
+	i := 0
+	for _, rule := range rules.All() {
+		if rule.IsDeprecated() {
+			continue
+		}
+		got := sarifRules[i]
+		if got.ID != rule.ID {
+			t.Fatalf("Rules[%d].ID = %q, want %q", i, got.ID, rule.ID)
+		}
+		if got.ShortDescription.Text != rule.PlainTitle() {
+			t.Fatalf("Rules[%d] description differs from the registry: %+v", i, got)
+		}
+		// The help URI is the page explain --markdown generates into docs/rules
+		if page := "docs/rules/" + rule.ID + ".md"; !strings.HasSuffix(got.HelpURI, "/blob/main/"+page) {
+			t.Fatalf("Rules[%d].HelpURI = %q, want the link to %s", i, got.HelpURI, page)
+		} else if _, err := os.Stat(filepath.Join("..", filepath.FromSlash(page))); err != nil {
+			t.Fatalf("Rules[%d].HelpURI links to a missing page: %v", i, err)
+		}
+		if got.DefaultConfiguration.Level != sarifLevel(rule.Severity) || len(got.Properties.Tags) != 1 || got.Properties.Reference != rule.Reference {
+			t.Fatalf("Rules[%d] level, category tag or reference differ from the registry: %+v", i, got)
+		}
+		if !strings.Contains(got.Help.Text, rule.Reference) || (rule.Notes != "" && !strings.Contains(got.FullDescription.Text, rule.Notes)) {
+			t.Fatalf("Rules[%d] help is missing the reference or notes: %+v", i, got)
+		}
+		i++
+	}
+	if log.Runs[0].Results == nil {
+		t.Fatalf("Results = nil, want empty slice")
+	}
+}
+
+func TestBuildSARIFResultUsesRelativePathRegionAndFingerprint(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "internal", "services", "cdn", "cdn_profile_resource.go")
+	findings := []Finding{{
+		CheckID: "AZRE001",
+		Path:    file,
+		Line:    42,
+		Column:  9,
+		Message: "AZRE001: fixed error strings should use \x1b[32merrors.New()\x1b[0m instead of fmt.Errorf()\n",
+	}}
+	lint.AssignFingerprints(root, findings)
+
+	log := buildSARIF(StatusIssues, ModeDiff, []string{"./internal/services/cdn/..."}, findings, root, rules.Lookup)
+	run := log.Runs[0]
+
+	if run.Properties.FilterMode != ModeDiff {
+		t.Fatalf("FilterMode = %q, want %q", run.Properties.FilterMode, ModeDiff)
+	}
+	if len(run.Results) != 1 {
+		t.Fatalf("len(Results) = %d, want 1", len(run.Results))
+	}
+
+	result := run.Results[0]
+	if result.RuleIndex == nil || run.Tool.Driver.Rules[*result.RuleIndex].ID != "AZRE001" {
+		t.Fatalf("RuleIndex does not point at AZRE001: %v", result.RuleIndex)
+	}
+
+	location := result.Locations[0].PhysicalLocation
+	if location.ArtifactLocation.URI != "internal/services/cdn/cdn_profile_resource.go" {
+		t.Fatalf("URI = %q, want repository-relative path", location.ArtifactLocation.URI)
+	}
+	if location.ArtifactLocation.URIBaseID != sarifSrcRoot {
+		t.Fatalf("URIBaseID = %q, want %q", location.ArtifactLocation.URIBaseID, sarifSrcRoot)
+	}
+	if location.Region.StartLine != 42 || location.Region.StartColumn != 9 {
+		t.Fatalf("Region = %+v, want line 42 column 9", location.Region)
+	}
+	if result.Message.Text != "AZRE001: fixed error strings should use errors.New() instead of fmt.Errorf()" {
+		t.Fatalf("Message = %q, want ANSI-free trimmed message", result.Message.Text)
+	}
+
+	if result.PartialFingerprints[fingerprintKey] != findings[0].Fingerprint {
+		t.Fatalf("PartialFingerprints = %v, want the fingerprint of the finding %s", result.PartialFingerprints, findings[0].Fingerprint)
+	}
+}
+
+func TestBuildSARIFLevelsFollowConfiguredSeverities(t *testing.T) {
+	root := t.TempDir()
+	cfg := &Config{Severities: map[string]rules.Severity{"AZRE001": rules.SeverityError}}
+	findings := []Finding{
+		{CheckID: "AZRE001", Path: filepath.Join(root, "a.go"), Line: 1, Message: "AZRE001: a"},
+		{CheckID: "AZBP012", Path: filepath.Join(root, "a.go"), Line: 2, Message: "AZBP012: b"},
+		{CheckID: "AZSD001", Path: filepath.Join(root, "a.go"), Line: 3, Message: "AZSD001: c"},
+	}
+
+	run := buildSARIF(StatusIssues, ModeLocal, nil, findings, root, cfg.Rule).Runs[0]
+	for i, want := range []string{"error", "note", "warning"} {
+		result := run.Results[i]
+		if result.Level != want || run.Tool.Driver.Rules[*result.RuleIndex].DefaultConfiguration.Level != want {
+			t.Errorf("%s has level %q, want %q for the result and its rule", result.RuleID, result.Level, want)
+		}
+	}
+}
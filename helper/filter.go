@@ -2,19 +2,24 @@ package helper
 
 import "strings"
 
+// DefaultSkipPackages lists the package path fragments skipped by resource/data source analysis
+var DefaultSkipPackages = []string{
+	"_test",
+	"/migration",
+	"/client",
+	"/validate",
+	"/test-data",
+	"/parse",
+	"/models",
+}
+
 // ShouldSkipPackageForResourceAnalysis returns true if the package should be skipped
-// during resource/data source analysis (e.g., test, migration, client, validate packages)
-func ShouldSkipPackageForResourceAnalysis(pkgPath string) bool {
-	skipPackages := []string{
-		"_test",
-		"/migration",
-		"/client",
-		"/validate",
-		"/test-data",
-		"/parse",
-		"/models",
+// during resource/data source analysis (e.g., test, migration, client, validate packages):
+// its path contains one of skipPackages, or of DefaultSkipPackages when skipPackages is nil
+func ShouldSkipPackageForResourceAnalysis(pkgPath string, skipPackages []string) bool {
+	if skipPackages == nil {
+		skipPackages = DefaultSkipPackages
 	}
-
 	for _, skip := range skipPackages {
 		if strings.Contains(pkgPath, skip) {
 			return true
@@ -65,6 +65,15 @@ func IsSchemaSchema(typesInfo *types.Info, cl *ast.CompositeLit) bool {
 	return isTypeSchema(t)
 }
 
+// ReturnsSchema checks if a function returns a single schema.Schema or pluginsdk.Schema, or a pointer to one
+func ReturnsSchema(fn *types.Func) bool {
+	sig, ok := fn.Type().(*types.Signature)
+	if !ok || sig.Results().Len() != 1 {
+		return false
+	}
+	return isTypeSchema(sig.Results().At(0).Type())
+}
+
 // isTypeSchema returns if the type is Schema from helper/schema or pluginsdk package
 func isTypeSchema(t types.Type) bool {
 	switch t := t.(type) {
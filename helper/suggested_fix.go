@@ -0,0 +1,247 @@
+package helper
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"strconv"
+	"unicode"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// NodeText returns the formatted source text of node
+func NodeText(fset *token.FileSet, node ast.Node) string {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, node); err != nil {
+		if expr, ok := node.(ast.Expr); ok {
+			return types.ExprString(expr)
+		}
+		return ""
+	}
+	return buf.String()
+}
+
+// ReplaceNodeFix returns a suggested fix that replaces node with newText
+func ReplaceNodeFix(message string, node ast.Node, newText string) analysis.SuggestedFix {
+	return analysis.SuggestedFix{
+		Message: message,
+		TextEdits: []analysis.TextEdit{{
+			Pos:     node.Pos(),
+			End:     node.End(),
+			NewText: []byte(newText),
+		}},
+	}
+}
+
+// AddImportEdit returns an edit adding importPath to file, or nil when the file
+// already imports it under its default name
+func AddImportEdit(file *ast.File, importPath string) *analysis.TextEdit {
+	if findImport(file, importPath) != nil {
+		return nil
+	}
+	quoted := strconv.Quote(importPath)
+
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.IMPORT {
+			continue
+		}
+		if genDecl.Lparen.IsValid() && len(genDecl.Specs) > 0 {
+			// Insert at the top of the block so standard library imports stay grouped
+			return &analysis.TextEdit{
+				Pos:     genDecl.Specs[0].Pos(),
+				End:     genDecl.Specs[0].Pos(),
+				NewText: []byte(quoted + "\n\t"),
+			}
+		}
+		return &analysis.TextEdit{
+			Pos:     genDecl.Pos(),
+			End:     genDecl.Pos(),
+			NewText: []byte("import " + quoted + "\n"),
+		}
+	}
+
+	return &analysis.TextEdit{
+		Pos:     file.Name.End(),
+		End:     file.Name.End(),
+		NewText: []byte("\n\nimport " + quoted),
+	}
+}
+
+// ReplaceImportEdit returns an edit importing newPath in place of oldPath, or nil when the file
+// does not import oldPath under its default name
+func ReplaceImportEdit(file *ast.File, oldPath, newPath string) *analysis.TextEdit {
+	imp := findImport(file, oldPath)
+	if imp == nil {
+		return nil
+	}
+	return &analysis.TextEdit{
+		Pos:     imp.Path.Pos(),
+		End:     imp.Path.End(),
+		NewText: []byte(strconv.Quote(newPath)),
+	}
+}
+
+// DeleteImportEdit returns an edit removing the import of importPath from file, or nil when the
+// file does not import it under its default name
+func DeleteImportEdit(file *ast.File, importPath string) *analysis.TextEdit {
+	imp := findImport(file, importPath)
+	if imp == nil {
+		return nil
+	}
+
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.IMPORT {
+			continue
+		}
+		for i, spec := range genDecl.Specs {
+			if spec != imp {
+				continue
+			}
+			switch {
+			case len(genDecl.Specs) == 1:
+				return &analysis.TextEdit{Pos: genDecl.Pos(), End: genDecl.End()}
+			case i+1 < len(genDecl.Specs):
+				return &analysis.TextEdit{Pos: spec.Pos(), End: genDecl.Specs[i+1].Pos()}
+			default:
+				return &analysis.TextEdit{Pos: genDecl.Specs[i-1].End(), End: spec.End()}
+			}
+		}
+	}
+	return nil
+}
+
+// findImport returns the import of importPath in file under its default name
+func findImport(file *ast.File, importPath string) *ast.ImportSpec {
+	quoted := strconv.Quote(importPath)
+	for _, imp := range file.Imports {
+		if imp.Path.Value == quoted && imp.Name == nil {
+			return imp
+		}
+	}
+	return nil
+}
+
+// ReorderElementsFix returns a suggested fix that rewrites the elements of lit so that the
+// element at order[i] ends up at position i. Each element moves together with its leading
+// comments and trailing line comment, while the whitespace between elements (including blank
+// lines separating groups) stays in place. It returns false when lit cannot be rewritten safely,
+// e.g. a single-line literal whose last element has no trailing comma, or when elements would
+// move between commented sections.
+func ReorderElementsFix(pass *analysis.Pass, lit *ast.CompositeLit, order []int, message string) (analysis.SuggestedFix, bool) {
+	if len(order) != len(lit.Elts) || len(order) < 2 || pass.ReadFile == nil {
+		return analysis.SuggestedFix{}, false
+	}
+
+	file := fileForPos(pass, lit.Pos())
+	tokFile := pass.Fset.File(lit.Pos())
+	if file == nil || tokFile == nil {
+		return analysis.SuggestedFix{}, false
+	}
+	content, err := pass.ReadFile(tokFile.Name())
+	if err != nil || tokFile.Size() != len(content) {
+		return analysis.SuggestedFix{}, false
+	}
+
+	type chunk struct {
+		start, end int
+		commented  bool
+	}
+	chunks := make([]chunk, len(lit.Elts))
+	prevEnd, prevLine := tokFile.Offset(lit.Lbrace)+1, tokFile.Line(lit.Lbrace)
+	for i, elt := range lit.Elts {
+		start, end := tokFile.Offset(elt.Pos()), tokFile.Offset(elt.End())
+
+		// The separating comma must travel with the element
+		end = skipSpaces(content, end)
+		if end >= len(content) || content[end] != ',' {
+			return analysis.SuggestedFix{}, false
+		}
+		end++
+
+		eltStart, eltLine := start, tokFile.Line(elt.End())
+		for _, cg := range file.Comments {
+			// Comments are matched individually: a trailing comment and the next element's
+			// doc comment on the following line share one comment group
+			for _, c := range cg.List {
+				cStart, cEnd := tokFile.Offset(c.Pos()), tokFile.Offset(c.End())
+				switch {
+				case cStart >= prevEnd && cEnd <= eltStart && cStart < start && tokFile.Line(c.Pos()) > prevLine:
+					// Comments on the lines between the previous element and this one document this element
+					start = cStart
+				case cStart >= end && tokFile.Line(c.Pos()) == eltLine && cEnd > end:
+					// Trailing comment on the same line as the element
+					end = cEnd
+				}
+			}
+		}
+
+		chunks[i] = chunk{start: start, end: end, commented: start < eltStart}
+		prevEnd, prevLine = end, tokFile.Line(tokFile.Pos(end))
+	}
+
+	// A comment heading a blank-line separated group of several elements names the section
+	// rather than its first element, so elements must not be moved between such sections
+	groups := make([]int, len(chunks))
+	groupSizes := map[int]int{0: 1}
+	for i := 1; i < len(chunks); i++ {
+		groups[i] = groups[i-1]
+		if bytes.Count(content[chunks[i-1].end:chunks[i].start], []byte("\n")) > 1 {
+			groups[i]++
+		}
+		groupSizes[groups[i]]++
+	}
+	for i, c := range chunks {
+		isSectionHeader := c.commented && (i == 0 || groups[i] != groups[i-1]) && groupSizes[groups[i]] > 1
+		if !isSectionHeader {
+			continue
+		}
+		for pos, idx := range order {
+			if idx >= 0 && idx < len(groups) && groups[idx] != groups[pos] {
+				return analysis.SuggestedFix{}, false
+			}
+		}
+		break
+	}
+
+	var buf bytes.Buffer
+	for i, idx := range order {
+		if idx < 0 || idx >= len(chunks) {
+			return analysis.SuggestedFix{}, false
+		}
+		buf.Write(content[chunks[idx].start:chunks[idx].end])
+		if i < len(chunks)-1 {
+			buf.Write(content[chunks[i].end:chunks[i+1].start])
+		}
+	}
+
+	first, last := chunks[0], chunks[len(chunks)-1]
+	return analysis.SuggestedFix{
+		Message: message,
+		TextEdits: []analysis.TextEdit{{
+			Pos:     tokFile.Pos(first.start),
+			End:     tokFile.Pos(last.end),
+			NewText: buf.Bytes(),
+		}},
+	}, true
+}
+
+func fileForPos(pass *analysis.Pass, pos token.Pos) *ast.File {
+	for _, f := range pass.Files {
+		if f.FileStart <= pos && pos <= f.FileEnd {
+			return f
+		}
+	}
+	return nil
+}
+
+func skipSpaces(content []byte, offset int) int {
+	for offset < len(content) && content[offset] != '\n' && unicode.IsSpace(rune(content[offset])) {
+		offset++
+	}
+	return offset
+}
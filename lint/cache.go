@@ -0,0 +1,343 @@
+package lint
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qixialu/azurerm-linter/cache"
+	"github.com/qixialu/azurerm-linter/helper"
+	"github.com/qixialu/azurerm-linter/reporting"
+	"github.com/qixialu/azurerm-linter/session"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/packages"
+)
+
+// cacheEntry is the cached analysis of a package and its test variants: the findings before change
+// filtering and the metadata needed to filter them. Paths are relative to the root the package was
+// loaded from, so the entry is reused when the same code is checked out elsewhere, as in PR worktrees.
+type cacheEntry struct {
+	Findings    []Finding
+	Diagnostics []reporting.DiagnosticMeta
+}
+
+// cachedPackage is a package pattern whose analysis is cached as one entry
+type cachedPackage struct {
+	path  string // import path of the package, also the pattern it is loaded with
+	key   string
+	entry *cacheEntry // cached or fresh analysis, with absolute paths
+}
+
+// cacheFormat is hashed into every key. It changes with cacheEntry or Finding, so entries
+// written by earlier builds of the same version are not reused.
+const cacheFormat = "2"
+
+// listMode lists packages with their files and dependencies, without parsing or type checking them
+const listMode = packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps |
+	packages.NeedModule | packages.NeedForTest
+
+// analyzeCached analyzes the packages matching patterns, reusing the cached findings of packages
+// whose inputs are unchanged and caching the findings of the others
+func (r *Result) analyzeCached(ctx context.Context, patterns []string, analyzers []*analysis.Analyzer, opts Options) ([]Finding, error) {
+	pkgs, groupOf, err := r.listCachedPackages(ctx, patterns, analyzers, opts)
+	if err != nil {
+		return nil, err
+	}
+	for path := range groupOf {
+		r.Packages = append(r.Packages, path)
+	}
+	sort.Strings(r.Packages)
+
+	sess := session.NewWithConfig(r.Changes, opts.CheckConfig)
+	var misses []*cachedPackage
+	for _, pkg := range pkgs {
+		var entry cacheEntry
+		if !opts.Cache.Get(pkg.key, &entry) {
+			misses = append(misses, pkg)
+			continue
+		}
+		pkg.entry = rebaseEntry(entry, func(path string) string { return absPath(r.Root, path) })
+		for _, meta := range pkg.entry.Diagnostics {
+			sess.RecordDiagnostic(meta)
+		}
+	}
+	log.Printf("Cache: reusing the results of %d of %d package(s)", len(pkgs)-len(misses), len(pkgs))
+
+	// Findings of packages outside the patterns, which are not cached
+	var uncached []Finding
+	if len(misses) > 0 {
+		missed := make(map[string]*cachedPackage, len(misses))
+		paths := make([]string, len(misses))
+		for i, pkg := range misses {
+			pkg.entry = &cacheEntry{}
+			missed[pkg.path] = pkg
+			paths[i] = pkg.path
+		}
+
+		loaded, excluded, err := r.load(ctx, paths, opts.KeepGoing)
+		if err != nil {
+			return nil, err
+		}
+		// Packages that failed to load have no complete findings to cache
+		skipped := make(map[string]bool, len(excluded))
+		for _, pkg := range excluded {
+			delete(missed, groupOf[pkg.PkgPath])
+			skipped[pkg.PkgPath] = true
+		}
+		if len(skipped) > 0 {
+			analyzed := r.Packages[:0]
+			for _, path := range r.Packages {
+				if !skipped[path] {
+					analyzed = append(analyzed, path)
+				}
+			}
+			r.Packages = analyzed
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		findings, err := analyze(loaded, analyzers, sess, r.Timings)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, f := range findings {
+			if pkg := missed[groupOf[f.PkgPath]]; pkg != nil {
+				pkg.entry.Findings = append(pkg.entry.Findings, f)
+			} else {
+				uncached = append(uncached, f)
+			}
+		}
+		for _, meta := range sess.Diagnostics().Entries() {
+			if pkg := missed[groupOf[meta.PkgPath]]; pkg != nil {
+				pkg.entry.Diagnostics = append(pkg.entry.Diagnostics, meta)
+			}
+		}
+
+		for _, pkg := range misses {
+			if missed[pkg.path] == nil {
+				continue
+			}
+			entry := rebaseEntry(*pkg.entry, func(path string) string { return relPath(r.Root, path) })
+			if err := opts.Cache.Put(pkg.key, entry); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+		}
+	}
+
+	var findings []Finding
+	for _, pkg := range pkgs {
+		findings = append(findings, pkg.entry.Findings...)
+	}
+	return keepFindings(append(findings, uncached...), sess), nil
+}
+
+// listCachedPackages lists the packages matching patterns and computes their cache keys. It also
+// returns the import path of the cached package each listed package path belongs to.
+//
+// A key covers the linter version, Go version and check configuration; the files of the package
+// and its test variants, including whether the changes touch them; the files of the dependencies
+// in the main module or replaced by directories; and the versions of the other dependencies.
+func (r *Result) listCachedPackages(ctx context.Context, patterns []string, analyzers []*analysis.Analyzer, opts Options) ([]*cachedPackage, map[string]string, error) {
+	cfg := &packages.Config{
+		Context: ctx,
+		Mode:    listMode,
+		Tests:   true,
+		Dir:     r.Root,
+	}
+	start := time.Now()
+	listed, err := packages.Load(cfg, patterns...)
+	if r.Timings != nil {
+		r.Timings.Load += time.Since(start)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list packages: %w", err)
+	}
+
+	skipPackages := opts.CheckConfig.SkipPackages
+	if skipPackages == nil {
+		skipPackages = helper.DefaultSkipPackages
+	}
+	config := cache.NewHash()
+	config.Add(cacheFormat, opts.Version, runtime.Version(), strings.Join(skipPackages, "\n"))
+	for _, a := range analyzers {
+		config.Add(a.Name)
+		a.Flags.VisitAll(func(f *flag.Flag) {
+			value, ok := opts.CheckConfig.Settings[a.Name][f.Name]
+			if !ok {
+				value = f.Value.String()
+			}
+			config.Add(f.Name, value)
+		})
+	}
+	configKey := config.Sum()
+
+	// Group test variants with the package they test
+	var order []string
+	variants := make(map[string][]*packages.Package)
+	groupOf := make(map[string]string)
+	for _, pkg := range listed {
+		if isTestMain(pkg) {
+			continue
+		}
+		path := pkg.PkgPath
+		if pkg.ForTest != "" {
+			path = pkg.ForTest
+		}
+		if variants[path] == nil {
+			order = append(order, path)
+		}
+		variants[path] = append(variants[path], pkg)
+		groupOf[pkg.PkgPath] = path
+	}
+
+	depIDs := make(map[*packages.Package]string)
+	var result []*cachedPackage
+	for _, path := range order {
+		h := cache.NewHash()
+		h.Add(configKey, path)
+
+		own := make(map[*packages.Package]bool)
+		var files []string
+		for _, pkg := range variants[path] {
+			own[pkg] = true
+			files = append(files, pkg.GoFiles...)
+			files = append(files, pkg.OtherFiles...)
+			if pkg.Module != nil {
+				h.Add(pkg.Module.GoVersion)
+			}
+		}
+		for _, file := range uniqueSorted(files) {
+			changed, isNew := r.Changes.IsFileChanged(file), r.Changes.IsNewFile(file)
+			h.Add(relPath(r.Root, file), strconv.FormatBool(changed), strconv.FormatBool(isNew))
+			if err := h.AddFile(file); err != nil {
+				return nil, nil, fmt.Errorf("failed to hash package %s: %w", path, err)
+			}
+		}
+
+		var deps []string
+		seen := make(map[*packages.Package]bool)
+		var visit func(pkg *packages.Package) error
+		visit = func(pkg *packages.Package) error {
+			for _, dep := range pkg.Imports {
+				if seen[dep] || own[dep] {
+					continue
+				}
+				seen[dep] = true
+				id, err := dependencyID(dep, depIDs)
+				if err != nil {
+					return fmt.Errorf("failed to hash package %s: %w", path, err)
+				}
+				deps = append(deps, id)
+				if err := visit(dep); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		for _, pkg := range variants[path] {
+			if err := visit(pkg); err != nil {
+				return nil, nil, err
+			}
+		}
+		h.Add(uniqueSorted(deps)...)
+
+		result = append(result, &cachedPackage{path: path, key: h.Sum()})
+	}
+	return result, groupOf, nil
+}
+
+// dependencyID identifies the content of a dependency: by module version when the module is
+// immutable, by the hash of its files otherwise. Standard library packages are covered by the Go version.
+func dependencyID(pkg *packages.Package, memo map[*packages.Package]string) (string, error) {
+	if id, ok := memo[pkg]; ok {
+		return id, nil
+	}
+
+	id := pkg.ID
+	if module := pkg.Module; module != nil {
+		if module.Replace != nil {
+			module = module.Replace
+		}
+		if module.Version != "" && !pkg.Module.Main {
+			id = pkg.ID + "@" + module.Version
+		} else {
+			h := cache.NewHash()
+			for _, file := range append(append([]string(nil), pkg.GoFiles...), pkg.OtherFiles...) {
+				h.Add(filepath.Base(file))
+				if err := h.AddFile(file); err != nil {
+					return "", err
+				}
+			}
+			id = pkg.ID + "#" + h.Sum()
+		}
+	}
+
+	memo[pkg] = id
+	return id, nil
+}
+
+// rebaseEntry returns a copy of entry with its file paths converted by rebase
+func rebaseEntry(entry cacheEntry, rebase func(string) string) *cacheEntry {
+	result := &cacheEntry{}
+	for _, f := range entry.Findings {
+		f.Path = rebase(f.Path)
+		f.EvidenceFile = rebase(f.EvidenceFile)
+		fixes := make([]Fix, len(f.Fixes))
+		for i, fix := range f.Fixes {
+			fix.Edits = append([]FixEdit(nil), fix.Edits...)
+			for j := range fix.Edits {
+				fix.Edits[j].Path = rebase(fix.Edits[j].Path)
+			}
+			fixes[i] = fix
+		}
+		if f.Fixes != nil {
+			f.Fixes = fixes
+		}
+		result.Findings = append(result.Findings, f)
+	}
+	for _, meta := range entry.Diagnostics {
+		meta.ReportFile = rebase(meta.ReportFile)
+		meta.EvidenceFile = rebase(meta.EvidenceFile)
+		result.Diagnostics = append(result.Diagnostics, meta)
+	}
+	return result
+}
+
+// relPath returns path relative to root in slash form, or path itself when it lies outside root
+func relPath(root, path string) string {
+	if path == "" || !filepath.IsAbs(path) {
+		return path
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// absPath reverses relPath
+func absPath(root, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(root, filepath.FromSlash(path))
+}
+
+func uniqueSorted(values []string) []string {
+	sort.Strings(values)
+	result := values[:0]
+	for i, v := range values {
+		if i == 0 || v != values[i-1] {
+			result = append(result, v)
+		}
+	}
+	return result
+}
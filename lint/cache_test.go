@@ -0,0 +1,94 @@
+package lint
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/qixialu/azurerm-linter/cache"
+	"github.com/qixialu/azurerm-linter/loader"
+	"github.com/qixialu/azurerm-linter/passes"
+	"golang.org/x/tools/go/analysis"
+)
+
+func TestRunReusesCachedFindingsOfUnchangedPackages(t *testing.T) {
+	source := `package cdn
+
+import "fmt"
+
+func first() error {
+	return fmt.Errorf("first failure")
+}
+`
+	writeModule := func(dir string) {
+		writeFile(t, filepath.Join(dir, "go.mod"), "module example.com/provider\n\ngo 1.21\n")
+		writeFile(t, filepath.Join(dir, "internal", "services", "cdn", "errors.go"), source)
+		writeFile(t, filepath.Join(dir, "internal", "services", "dns", "dns.go"), "package dns\n")
+	}
+
+	c, err := cache.Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	var runs atomic.Int32
+	counting := *passes.AZRE001Analyzer
+	counting.Run = func(pass *analysis.Pass) (interface{}, error) {
+		runs.Add(1)
+		return passes.AZRE001Analyzer.Run(pass)
+	}
+
+	run := func(dir string) []Finding {
+		t.Helper()
+		res, err := Run(context.Background(), Options{
+			Patterns:  []string{"./..."},
+			Analyzers: []*analysis.Analyzer{&counting},
+			Dir:       dir,
+			Changes:   loader.LoaderOptions{NoFilter: true},
+			Cache:     c,
+			Version:   "test",
+		})
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		return res.Findings
+	}
+
+	first := t.TempDir()
+	writeModule(first)
+	findings := run(first)
+	if len(findings) != 1 || findings[0].Line != 6 {
+		t.Fatalf("findings = %+v, want one on line 6", findings)
+	}
+	analyzed := runs.Load()
+	if analyzed == 0 {
+		t.Fatalf("the first run did not analyze any package")
+	}
+
+	// The same code elsewhere, as in a PR worktree, reuses the results with its own paths
+	second := t.TempDir()
+	writeModule(second)
+	findings = run(second)
+	if runs.Load() != analyzed {
+		t.Fatalf("analyzer ran %d more time(s) for unchanged packages", runs.Load()-analyzed)
+	}
+	wantPath := filepath.Join(second, "internal", "services", "cdn", "errors.go")
+	if len(findings) != 1 || findings[0].Path != wantPath || len(findings[0].Fixes) != 1 || findings[0].Fixes[0].Edits[0].Path != wantPath {
+		t.Fatalf("cached findings = %+v, want one in %s", findings, wantPath)
+	}
+
+	// Changing a file analyzes its package again
+	source = "package cdn\n\nimport \"errors\"\n\nfunc first() error {\n\treturn errors.New(\"first failure\")\n}\n"
+	if err := os.WriteFile(filepath.Join(second, "internal", "services", "cdn", "errors.go"), []byte(source), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	findings = run(second)
+	if runs.Load() == analyzed {
+		t.Fatalf("the changed package was not analyzed again")
+	}
+	if len(findings) != 0 {
+		t.Fatalf("findings after the fix = %+v, want none", findings)
+	}
+}
@@ -0,0 +1,198 @@
+package lint
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/packages"
+)
+
+// Finding is a single diagnostic kept after change filtering and deduplication
+type Finding struct {
+	CheckID   string
+	PkgPath   string
+	Scope     string // enclosing function and schema key, see enclosingScope
+	Path      string
+	Line      int
+	Column    int
+	EndLine   int // end of the reported node, see diagnosticEnd
+	EndColumn int
+	Message   string
+	Fixes     []Fix // suggested fixes, applied by the CLI with --fix
+
+	// EvidenceFile and EvidenceLines are the lines whose changes keep the finding in filtered
+	// runs, and MatchMode how they are matched, see package reporting. They are empty when the
+	// check reported the finding without recording them.
+	EvidenceFile  string
+	EvidenceLines []int
+	MatchMode     string
+
+	// Fingerprint identifies the finding across runs regardless of its line, see AssignFingerprints
+	Fingerprint string
+}
+
+// Fix is a suggested fix resolved to byte offsets so it can be applied after analysis
+type Fix struct {
+	Message string
+	Edits   []FixEdit
+}
+
+// FixEdit replaces the bytes [Offset, End) of Path with NewText
+type FixEdit struct {
+	Path      string
+	Offset    int
+	End       int
+	StartLine int
+	EndLine   int
+	NewText   string
+	// InHeader marks edits to the import declarations. goimports rewrites that section
+	// after fixing anyway, so these edits are exempt from the change filter.
+	InHeader bool
+}
+
+// resolveFixes converts the suggested fixes of a diagnostic into offset-based fixes
+func resolveFixes(pkg *packages.Package, fixes []analysis.SuggestedFix) []Fix {
+	var resolved []Fix
+	for _, fix := range fixes {
+		r := Fix{Message: fix.Message}
+		for _, edit := range fix.TextEdits {
+			end := edit.End
+			if !end.IsValid() {
+				end = edit.Pos
+			}
+			start, stop := pkg.Fset.Position(edit.Pos), pkg.Fset.Position(end)
+			if start.Filename == "" || start.Filename != stop.Filename {
+				r.Edits = nil
+				break
+			}
+
+			r.Edits = append(r.Edits, FixEdit{
+				Path:      start.Filename,
+				Offset:    start.Offset,
+				End:       stop.Offset,
+				StartLine: start.Line,
+				EndLine:   stop.Line,
+				NewText:   string(edit.NewText),
+				InHeader:  inImportSection(pkg, edit.Pos),
+			})
+		}
+		if len(r.Edits) > 0 {
+			resolved = append(resolved, r)
+		}
+	}
+	return resolved
+}
+
+// inImportSection reports whether pos lies between the package clause and the end of the last import declaration
+func inImportSection(pkg *packages.Package, pos token.Pos) bool {
+	for _, f := range pkg.Syntax {
+		if pos < f.FileStart || pos > f.FileEnd {
+			continue
+		}
+
+		sectionEnd := f.Name.End()
+		for _, decl := range f.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.IMPORT {
+				break
+			}
+			sectionEnd = genDecl.End()
+		}
+		return pos >= f.Name.End() && pos <= sectionEnd
+	}
+	return false
+}
+
+// enclosingScope describes where pos sits, as the enclosing function (with receiver type)
+// followed by the innermost string key of an enclosing map literal such as a schema field
+func enclosingScope(pkg *packages.Package, pos token.Pos) string {
+	file := fileAt(pkg, pos)
+	if file == nil {
+		return ""
+	}
+
+	path, _ := astutil.PathEnclosingInterval(file, pos, pos)
+
+	var funcName, key string
+	for _, node := range path {
+		switch n := node.(type) {
+		case *ast.KeyValueExpr:
+			if key != "" {
+				continue
+			}
+			if lit, ok := n.Key.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				if value, err := strconv.Unquote(lit.Value); err == nil {
+					key = value
+				}
+			}
+		case *ast.FuncDecl:
+			funcName = n.Name.Name
+			if n.Recv != nil && len(n.Recv.List) > 0 {
+				funcName = receiverTypeName(n.Recv.List[0].Type) + "." + funcName
+			}
+		}
+		if funcName != "" {
+			break
+		}
+	}
+
+	if key == "" {
+		return funcName
+	}
+	return funcName + "#" + key
+}
+
+// diagnosticEnd returns the end of a diagnostic: its End when the check set one, otherwise the
+// end of the outermost expression starting at its position, such as the call or schema field
+// reported, or of the declaration or statement there. Without a node it is the position itself.
+func diagnosticEnd(pkg *packages.Package, diag analysis.Diagnostic) token.Pos {
+	if diag.End.IsValid() && diag.End >= diag.Pos {
+		return diag.End
+	}
+
+	file := fileAt(pkg, diag.Pos)
+	if file == nil {
+		return diag.Pos
+	}
+	path, _ := astutil.PathEnclosingInterval(file, diag.Pos, diag.Pos)
+
+	end := diag.Pos
+	for i, node := range path {
+		if _, isFile := node.(*ast.File); isFile || node.Pos() != diag.Pos {
+			break
+		}
+		if _, isExpr := node.(ast.Expr); !isExpr && i > 0 {
+			break
+		}
+		end = node.End()
+	}
+	return end
+}
+
+// fileAt returns the syntax of the file of pkg containing pos
+func fileAt(pkg *packages.Package, pos token.Pos) *ast.File {
+	for _, f := range pkg.Syntax {
+		if f.FileStart <= pos && pos <= f.FileEnd {
+			return f
+		}
+	}
+	return nil
+}
+
+func receiverTypeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverTypeName(t.X)
+	case *ast.IndexExpr:
+		return receiverTypeName(t.X)
+	case *ast.IndexListExpr:
+		return receiverTypeName(t.X)
+	case *ast.Ident:
+		return t.Name
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,85 @@
+package lint
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/packages"
+)
+
+func TestEnclosingScopeUsesFunctionAndSchemaKey(t *testing.T) {
+	src := `package cdn
+
+type CdnProfileResource struct{}
+
+func (r *CdnProfileResource) Arguments() map[string]interface{} {
+	return map[string]interface{}{
+		"sku_name": struct{ Required bool }{Required: true},
+	}
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "cdn_profile_resource.go", src, 0)
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+	pkg := &packages.Package{Fset: fset, Syntax: []*ast.File{file}}
+
+	var target token.Pos
+	ast.Inspect(file, func(n ast.Node) bool {
+		if kv, ok := n.(*ast.KeyValueExpr); ok {
+			if key, ok := kv.Key.(*ast.Ident); ok && key.Name == "Required" {
+				target = kv.Value.Pos()
+			}
+		}
+		return true
+	})
+
+	if got := enclosingScope(pkg, target); got != "CdnProfileResource.Arguments#sku_name" {
+		t.Fatalf("enclosingScope() = %q", got)
+	}
+	if got := enclosingScope(pkg, file.Name.Pos()); got != "" {
+		t.Fatalf("enclosingScope() outside function = %q, want empty", got)
+	}
+}
+
+func TestDiagnosticEndCoversReportedExpression(t *testing.T) {
+	src := `package cdn
+
+import "fmt"
+
+func first() error {
+	return fmt.Errorf(
+		"first failure",
+	)
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "errors.go", src, 0)
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+	pkg := &packages.Package{Fset: fset, Syntax: []*ast.File{file}}
+
+	var call *ast.CallExpr
+	ast.Inspect(file, func(n ast.Node) bool {
+		if c, ok := n.(*ast.CallExpr); ok {
+			call = c
+		}
+		return true
+	})
+
+	end := fset.Position(diagnosticEnd(pkg, analysis.Diagnostic{Pos: call.Pos()}))
+	if end.Line != 8 || end.Column != 3 {
+		t.Fatalf("end of the call = %d:%d, want 8:3", end.Line, end.Column)
+	}
+	if got := diagnosticEnd(pkg, analysis.Diagnostic{Pos: call.Pos(), End: call.Lparen}); got != call.Lparen {
+		t.Fatalf("diagnosticEnd() = %v, want the End set by the check", got)
+	}
+	if got := diagnosticEnd(pkg, analysis.Diagnostic{Pos: file.Package}); got != file.Package {
+		t.Fatalf("diagnosticEnd() at the package clause = %v, want the position itself", got)
+	}
+}
@@ -0,0 +1,67 @@
+package lint
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ansiRegex        = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	whitespaceRegex  = regexp.MustCompile(`\s+`)
+	positionRefRegex = regexp.MustCompile(`:\d+(:\d+)?\b`)
+)
+
+// AssignFingerprints sets the Fingerprint of findings, which identifies each finding across runs
+// without relying on line numbers, so baselines and code scanning keep matching it when unrelated
+// edits shift code up or down. Run assigns the fingerprints of its findings with root as the root
+// of the run.
+//
+// A fingerprint covers the check, the file relative to root, the package, the enclosing function
+// and schema key, and the message with colors, positions and whitespace normalized. Findings that
+// share these, such as two identical findings in one function, are numbered in line order and
+// every one but the first has its number hashed in, so fingerprints are unique within a run.
+func AssignFingerprints(root string, findings []Finding) {
+	occurrences := make(map[string][]int)
+	for i, f := range findings {
+		fingerprint := baseFingerprint(root, f)
+		occurrences[fingerprint] = append(occurrences[fingerprint], i)
+		findings[i].Fingerprint = fingerprint
+	}
+
+	for fingerprint, indexes := range occurrences {
+		if len(indexes) == 1 {
+			continue
+		}
+		sort.SliceStable(indexes, func(a, b int) bool {
+			fa, fb := findings[indexes[a]], findings[indexes[b]]
+			if fa.Line != fb.Line {
+				return fa.Line < fb.Line
+			}
+			return fa.Column < fb.Column
+		})
+		for n, i := range indexes[1:] {
+			findings[i].Fingerprint = hash(fingerprint, strconv.Itoa(n+2))
+		}
+	}
+}
+
+// baseFingerprint identifies f by everything but its position
+func baseFingerprint(root string, f Finding) string {
+	return hash(f.CheckID, relPath(root, f.Path), f.PkgPath, f.Scope, normalizeMessage(f.Message))
+}
+
+// normalizeMessage strips colors, position references and whitespace differences
+func normalizeMessage(message string) string {
+	message = strings.TrimSpace(ansiRegex.ReplaceAllString(message, ""))
+	message = positionRefRegex.ReplaceAllString(message, "")
+	return whitespaceRegex.ReplaceAllString(message, " ")
+}
+
+func hash(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "|")))
+	return hex.EncodeToString(sum[:])
+}
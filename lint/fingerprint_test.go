@@ -0,0 +1,66 @@
+package lint
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestAssignFingerprintsIgnoresColorsAndPositions(t *testing.T) {
+	findings := []Finding{
+		{CheckID: "AZNR001", PkgPath: "example.com/cdn", Scope: "r.Arguments", Path: "/repo/cdn/resource.go", Line: 12, Message: "AZNR001: \x1b[32mname, location\x1b[0m\n  at file.go:12:3"},
+		{CheckID: "AZNR001", PkgPath: "example.com/cdn", Scope: "r.Arguments", Path: "/repo/cdn/resource.go", Line: 40, Message: "AZNR001: name, location at file.go"},
+	}
+	AssignFingerprints("/repo", findings[:1])
+	AssignFingerprints("/repo", findings[1:])
+	if findings[0].Fingerprint != findings[1].Fingerprint {
+		t.Fatalf("fingerprints differ for equivalent messages")
+	}
+
+	// The same code checked out elsewhere keeps its fingerprints
+	moved := findings[0]
+	moved.Path = "/worktree/cdn/resource.go"
+	AssignFingerprints("/worktree", []Finding{moved})
+	if moved.Fingerprint != findings[0].Fingerprint {
+		t.Fatalf("fingerprint changed with the root")
+	}
+
+	for name, change := range map[string]func(f *Finding){
+		"scope": func(f *Finding) { f.Scope = "r.Attributes" },
+		"file":  func(f *Finding) { f.Path = "/repo/cdn/client.go" },
+	} {
+		other := []Finding{findings[0]}
+		change(&other[0])
+		AssignFingerprints("/repo", other)
+		if other[0].Fingerprint == findings[0].Fingerprint {
+			t.Errorf("fingerprints match for findings in different %ss", name)
+		}
+	}
+}
+
+func TestAssignFingerprintsNumbersIdenticalFindingsInLineOrder(t *testing.T) {
+	file := filepath.Join("/repo", "cdn", "resource.go")
+	finding := func(line int) Finding {
+		return Finding{CheckID: "AZRE001", PkgPath: "example.com/cdn", Scope: "r.Create", Path: file, Line: line, Message: "AZRE001: fixed error strings should use errors.New()"}
+	}
+
+	findings := []Finding{finding(30), finding(10), finding(20)}
+	AssignFingerprints("/repo", findings)
+	if findings[0].Fingerprint == findings[1].Fingerprint || findings[0].Fingerprint == findings[2].Fingerprint || findings[1].Fingerprint == findings[2].Fingerprint {
+		t.Fatalf("identical findings share fingerprints: %+v", findings)
+	}
+
+	// The first finding keeps the fingerprint it has alone, whatever the order of the findings
+	single := []Finding{finding(15)}
+	AssignFingerprints("/repo", single)
+	if findings[1].Fingerprint != single[0].Fingerprint {
+		t.Fatalf("first of identical findings = %s, want %s", findings[1].Fingerprint, single[0].Fingerprint)
+	}
+
+	reordered := []Finding{finding(12), finding(22), finding(32)}
+	AssignFingerprints("/repo", reordered)
+	for i, want := range []string{findings[1].Fingerprint, findings[2].Fingerprint, findings[0].Fingerprint} {
+		if reordered[i].Fingerprint != want {
+			t.Errorf("fingerprint of occurrence %d = %s, want %s", i+1, reordered[i].Fingerprint, want)
+		}
+	}
+}
@@ -0,0 +1,355 @@
+// Package lint runs the AzureRM provider checks as a library. Each call to Run keeps its
+// change set and diagnostic metadata in its own session, so several runs can share a process.
+package lint
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/qixialu/azurerm-linter/cache"
+	"github.com/qixialu/azurerm-linter/loader"
+	"github.com/qixialu/azurerm-linter/passes"
+	"github.com/qixialu/azurerm-linter/session"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/checker"
+	"golang.org/x/tools/go/packages"
+)
+
+// Options configures a run
+type Options struct {
+	// Patterns are the package patterns to analyze. When empty, the service packages
+	// touched by the changes are analyzed.
+	Patterns []string
+
+	// Analyzers are the checks to run; nil runs passes.AllChecks
+	Analyzers []*analysis.Analyzer
+
+	// CheckConfig holds the settings of the checks and the packages resource analysis skips.
+	// It is kept in the session of the run, so runs in one process can configure checks differently.
+	CheckConfig session.Config
+
+	// Dir is the directory patterns are resolved in. It defaults to the working directory,
+	// or to the PR worktree when Changes selects a PR.
+	Dir string
+
+	// Changes selects the changes findings are filtered by
+	Changes loader.LoaderOptions
+
+	// Cache stores the findings of each package. Packages whose files, dependencies and
+	// configuration are unchanged since a cached run are not analyzed again. nil disables caching.
+	Cache *cache.Cache
+
+	// Version identifies the linter build. Cached findings of other versions are not reused.
+	Version string
+
+	// Profile records the time spent loading packages and running each analyzer, and the peak heap size
+	Profile bool
+
+	// KeepGoing analyzes the packages that load when others fail to load or type check. The
+	// failed packages and the packages depending on them are skipped and listed in Result.LoadErrors.
+	KeepGoing bool
+}
+
+// Result is the outcome of a run. Close it to remove the PR worktree of --pr runs.
+type Result struct {
+	// Findings are the diagnostics kept after change filtering and deduplication
+	Findings []Finding
+
+	// Patterns are the package patterns that were analyzed; empty when there was nothing to analyze
+	Patterns []string
+
+	// Root is the directory the packages were loaded from: the PR worktree or the working directory
+	Root string
+
+	// Changes are the changes findings were filtered by, or nil when filtering is disabled
+	Changes *loader.ChangeSet
+
+	// Packages are the import paths of the analyzed packages, including their test packages.
+	// Packages skipped by Options.KeepGoing are not included.
+	Packages []string
+
+	// LoadErrors lists the packages skipped by Options.KeepGoing
+	LoadErrors []PackageError
+
+	// Timings are set when Options.Profile is set. Packages whose findings came from the cache
+	// are not included.
+	Timings *Timings
+}
+
+// Close releases the resources of the run, such as the PR worktree
+func (r *Result) Close() error {
+	return r.Changes.Close()
+}
+
+// Run loads the changes and packages selected by opts and analyzes them
+func Run(ctx context.Context, opts Options) (*Result, error) {
+	cs, err := loader.LoadChanges(opts.Changes)
+	if err != nil {
+		if opts.Changes.Explicit() {
+			return nil, err
+		}
+		log.Printf("Warning: failed to load changed lines filter: %v", err)
+	}
+
+	result := &Result{Changes: cs, Root: cs.Dir()}
+	if result.Root == "" {
+		result.Root = opts.Dir
+	}
+	if result.Root == "" {
+		if result.Root, err = os.Getwd(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := result.analyze(ctx, opts); err != nil {
+		if closeErr := result.Close(); closeErr != nil {
+			log.Printf("Warning: failed to cleanup worktree: %v", closeErr)
+		}
+		return nil, err
+	}
+	AssignFingerprints(result.Root, result.Findings)
+
+	return result, nil
+}
+
+func (r *Result) analyze(ctx context.Context, opts Options) error {
+	// Determine package patterns to analyze
+	patterns := opts.Patterns
+	if r.Changes.IsEnabled() {
+		files, lines := r.Changes.GetStats()
+		log.Printf("Changed lines filter: tracking %d files with %d changed lines", files, lines)
+
+		// If change tracking is enabled and no patterns specified, use changed packages
+		if len(patterns) == 0 {
+			changedPackages := r.Changes.GetChangedPackages()
+			if len(changedPackages) > 0 {
+				patterns = changedPackages
+				log.Printf("Auto-detected %d changed packages:", len(patterns))
+				for _, pkg := range patterns {
+					log.Printf("  %s", pkg)
+				}
+			}
+		}
+	}
+
+	if len(patterns) == 0 {
+		return nil
+	}
+	r.Patterns = patterns
+
+	// Type checking fails on code for a newer Go than the linter was built with
+	if err := loader.CheckGoVersion(r.Root); err != nil {
+		return err
+	}
+
+	analyzers := opts.Analyzers
+	if analyzers == nil {
+		analyzers = passes.AllChecks
+	}
+
+	if opts.Profile {
+		r.Timings = &Timings{}
+		defer r.Timings.sampleHeap(heapSampleInterval)()
+	}
+
+	var err error
+	if opts.Cache != nil {
+		r.Findings, err = r.analyzeCached(ctx, patterns, analyzers, opts)
+		return err
+	}
+
+	pkgs, _, err := r.load(ctx, patterns, opts.KeepGoing)
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.Packages = packagePaths(pkgs)
+
+	sess := session.NewWithConfig(r.Changes, opts.CheckConfig)
+	findings, err := analyze(pkgs, analyzers, sess, r.Timings)
+	if err != nil {
+		return err
+	}
+	r.Findings = keepFindings(findings, sess)
+	return nil
+}
+
+// packagePaths returns the sorted import paths of pkgs, listing test variants once and leaving
+// out generated test main packages
+func packagePaths(pkgs []*packages.Package) []string {
+	seen := make(map[string]bool, len(pkgs))
+	var paths []string
+	for _, pkg := range pkgs {
+		if !isTestMain(pkg) && !seen[pkg.PkgPath] {
+			seen[pkg.PkgPath] = true
+			paths = append(paths, pkg.PkgPath)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// load loads the packages matching patterns from the root of the run, timing it when profiling.
+// With keepGoing, packages that fail to load are excluded and recorded in LoadErrors instead of
+// failing the run.
+func (r *Result) load(ctx context.Context, patterns []string, keepGoing bool) (pkgs, excluded []*packages.Package, err error) {
+	if r.Timings != nil {
+		defer func(start time.Time) { r.Timings.Load += time.Since(start) }(time.Now())
+	}
+	if !keepGoing {
+		pkgs, err = Load(ctx, r.Root, patterns...)
+		return pkgs, nil, err
+	}
+
+	pkgs, err = loadPackages(ctx, r.Root, patterns)
+	if err != nil {
+		return nil, nil, err
+	}
+	pkgs, excluded, loadErrors := excludeBroken(pkgs)
+	for _, e := range loadErrors {
+		log.Printf("Warning: skipping package %s: %s", e.Package, strings.Join(e.Errors, "; "))
+	}
+	r.LoadErrors = append(r.LoadErrors, loadErrors...)
+	return pkgs, excluded, nil
+}
+
+// Load loads the packages matching patterns in dir, including their tests, with the syntax
+// and type information the checks need
+func Load(ctx context.Context, dir string, patterns ...string) ([]*packages.Package, error) {
+	pkgs, err := loadPackages(ctx, dir, patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check for package loading errors
+	loadErrors := 0
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, err := range pkg.Errors {
+			log.Printf("Error: failed to load package: %v", err)
+			loadErrors++
+		}
+	})
+	if loadErrors > 0 {
+		return nil, fmt.Errorf("failed to load packages: %d error(s)", loadErrors)
+	}
+
+	return pkgs, nil
+}
+
+func loadPackages(ctx context.Context, dir string, patterns []string) ([]*packages.Package, error) {
+	log.Printf("Loading packages...")
+	cfg := &packages.Config{
+		Context: ctx,
+		Mode:    packages.LoadAllSyntax,
+		Tests:   true,
+		Dir:     dir,
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load packages: %w", err)
+	}
+	return pkgs, nil
+}
+
+// Analyze runs analyzers configured by config on pkgs and returns the findings kept by changes;
+// nil changes keep all
+func Analyze(pkgs []*packages.Package, analyzers []*analysis.Analyzer, changes *loader.ChangeSet, config session.Config) ([]Finding, error) {
+	sess := session.NewWithConfig(changes, config)
+	findings, err := analyze(pkgs, analyzers, sess, nil)
+	if err != nil {
+		return nil, err
+	}
+	return keepFindings(findings, sess), nil
+}
+
+// analyze runs analyzers on pkgs and returns their findings before change filtering. The session
+// gives analyzers the changes to filter by and collects the metadata of their diagnostics.
+// The durations of the analyzers are added to timings unless it is nil.
+func analyze(pkgs []*packages.Package, analyzers []*analysis.Analyzer, sess *session.Session, timings *Timings) ([]Finding, error) {
+	log.Printf("Running analysis...")
+	graph, err := checker.Analyze(session.Bind(analyzers, sess), pkgs, nil)
+	if err != nil {
+		return nil, fmt.Errorf("analysis failed: %w", err)
+	}
+	if timings != nil {
+		timings.addActions(graph)
+	}
+
+	return collectFindings(graph), nil
+}
+
+// collectFindings walks the analysis graph and returns deduplicated findings.
+func collectFindings(graph *checker.Graph) []Finding {
+	var findings []Finding
+	// Deduplicate diagnostics by "file:line:column|message"
+	// When Tests=true, the same source file may be analyzed in both main and test packages
+	// (when user doesn't mark test pkg as *_test), causing identical diagnostics to appear twice
+	seen := make(map[string]bool)
+
+	for act := range graph.All() {
+		if act.Err != nil {
+			continue
+		}
+
+		for _, diag := range act.Diagnostics {
+			pos := act.Package.Fset.Position(diag.Pos)
+			key := fmt.Sprintf("%s:%d:%d|%s", pos.Filename, pos.Line, pos.Column, diag.Message)
+
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			end := act.Package.Fset.Position(diagnosticEnd(act.Package, diag))
+			findings = append(findings, Finding{
+				CheckID:   act.Analyzer.Name,
+				PkgPath:   act.Package.PkgPath,
+				Scope:     enclosingScope(act.Package, diag.Pos),
+				Path:      pos.Filename,
+				Line:      pos.Line,
+				Column:    pos.Column,
+				EndLine:   end.Line,
+				EndColumn: end.Column,
+				Message:   diag.Message,
+				Fixes:     resolveFixes(act.Package, diag.SuggestedFixes),
+			})
+		}
+	}
+	return findings
+}
+
+// keepFindings returns the findings kept by the changes of sess, with the evidence and match
+// mode their checks recorded
+func keepFindings(findings []Finding, sess *session.Session) []Finding {
+	var kept []Finding
+	for _, f := range findings {
+		pos := token.Position{Filename: f.Path, Line: f.Line, Column: f.Column}
+		if !shouldKeepDiagnostic(sess, f.PkgPath, pos, f.Message) {
+			continue
+		}
+		if meta, ok := sess.Diagnostics().Lookup(f.PkgPath, f.Path, f.Line, f.Column, f.Message); ok {
+			f.EvidenceFile, f.EvidenceLines, f.MatchMode = meta.EvidenceFile, meta.EvidenceLines, meta.MatchMode
+		}
+		kept = append(kept, f)
+	}
+	return kept
+}
+
+func shouldKeepDiagnostic(sess *session.Session, pkgPath string, pos token.Position, message string) bool {
+	meta, ok := sess.Diagnostics().Lookup(pkgPath, pos.Filename, pos.Line, pos.Column, message)
+	if !ok {
+		return true
+	}
+
+	return sess.Changes().ShouldKeepDiagnostic(meta)
+}
@@ -0,0 +1,97 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// PackageError lists why a package could not be analyzed
+type PackageError struct {
+	Package string // import path
+	Errors  []string
+}
+
+// excludeBroken splits roots into the packages that can be analyzed and those that failed to
+// load or type check, or depend on a package that did. It returns the errors of the excluded
+// packages, merging the test variants of a package.
+func excludeBroken(roots []*packages.Package) (healthy, excluded []*packages.Package, loadErrors []PackageError) {
+	// brokenIn maps a package to itself or the first dependency that has errors, or nil
+	brokenIn := make(map[*packages.Package]*packages.Package)
+	var find func(pkg *packages.Package) *packages.Package
+	find = func(pkg *packages.Package) *packages.Package {
+		if broken, ok := brokenIn[pkg]; ok {
+			return broken
+		}
+		brokenIn[pkg] = nil // import cycles are reported as errors of the package itself
+		if len(pkg.Errors) > 0 {
+			brokenIn[pkg] = pkg
+			return pkg
+		}
+		for _, path := range sortedImports(pkg) {
+			if broken := find(pkg.Imports[path]); broken != nil {
+				brokenIn[pkg] = broken
+				return broken
+			}
+		}
+		return nil
+	}
+
+	index := make(map[string]int)
+	for _, pkg := range roots {
+		broken := find(pkg)
+		if broken == nil {
+			healthy = append(healthy, pkg)
+			continue
+		}
+		excluded = append(excluded, pkg)
+		if isTestMain(pkg) {
+			continue
+		}
+
+		var messages []string
+		if broken == pkg {
+			for _, err := range pkg.Errors {
+				messages = append(messages, err.Error())
+			}
+		} else {
+			messages = []string{fmt.Sprintf("depends on %s, which failed to load", broken.PkgPath)}
+		}
+
+		i, ok := index[pkg.PkgPath]
+		if !ok {
+			i = len(loadErrors)
+			index[pkg.PkgPath] = i
+			loadErrors = append(loadErrors, PackageError{Package: pkg.PkgPath})
+		}
+		for _, message := range messages {
+			if !contains(loadErrors[i].Errors, message) {
+				loadErrors[i].Errors = append(loadErrors[i].Errors, message)
+			}
+		}
+	}
+	return healthy, excluded, loadErrors
+}
+
+// isTestMain reports whether pkg is the main package go test generates for a test binary
+func isTestMain(pkg *packages.Package) bool {
+	return pkg.Name == "main" && strings.HasSuffix(pkg.ID, ".test")
+}
+
+func sortedImports(pkg *packages.Package) []string {
+	paths := make([]string, 0, len(pkg.Imports))
+	for path := range pkg.Imports {
+		paths = append(paths, path)
+	}
+	return uniqueSorted(paths)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package lint
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qixialu/azurerm-linter/cache"
+	"github.com/qixialu/azurerm-linter/loader"
+	"github.com/qixialu/azurerm-linter/passes"
+	"golang.org/x/tools/go/analysis"
+)
+
+func TestRunKeepGoingSkipsBrokenPackagesAndDependents(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "go.mod"), "module example.com/provider\n\ngo 1.21\n")
+	writeFile(t, filepath.Join(dir, "internal", "services", "cdn", "errors.go"), `package cdn
+
+import "fmt"
+
+func first() error {
+	return fmt.Errorf("first failure")
+}
+`)
+	writeFile(t, filepath.Join(dir, "internal", "services", "dns", "dns.go"), "package dns\n\nfunc Zone() string {\n\treturn 1\n}\n")
+	writeFile(t, filepath.Join(dir, "internal", "services", "network", "network.go"), `package network
+
+import "example.com/provider/internal/services/dns"
+
+var zone = dns.Zone()
+`)
+
+	opts := Options{
+		Patterns:  []string{"./..."},
+		Analyzers: []*analysis.Analyzer{passes.AZRE001Analyzer},
+		Dir:       dir,
+		Changes:   loader.LoaderOptions{NoFilter: true},
+	}
+	if _, err := Run(context.Background(), opts); err == nil {
+		t.Fatalf("Run() error = nil, want load error without KeepGoing")
+	}
+
+	opts.KeepGoing = true
+	res, err := Run(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	checkKeepGoingResult(t, res)
+
+	// Skipped packages are not cached, so later runs report them again
+	if opts.Cache, err = cache.Open(t.TempDir()); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		res, err := Run(context.Background(), opts)
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		checkKeepGoingResult(t, res)
+	}
+}
+
+func checkKeepGoingResult(t *testing.T, res *Result) {
+	t.Helper()
+	if len(res.Findings) != 1 || res.Findings[0].CheckID != "AZRE001" {
+		t.Fatalf("findings = %+v, want the AZRE001 finding of cdn", res.Findings)
+	}
+
+	if len(res.Packages) != 1 || res.Packages[0] != "example.com/provider/internal/services/cdn" {
+		t.Fatalf("Packages = %q, want cdn only", res.Packages)
+	}
+
+	if len(res.LoadErrors) != 2 {
+		t.Fatalf("LoadErrors = %+v, want dns and network", res.LoadErrors)
+	}
+	for _, e := range res.LoadErrors {
+		switch e.Package {
+		case "example.com/provider/internal/services/dns":
+			if len(e.Errors) != 1 || !strings.Contains(e.Errors[0], "cannot use 1") {
+				t.Errorf("dns errors = %q, want its type error", e.Errors)
+			}
+		case "example.com/provider/internal/services/network":
+			if len(e.Errors) != 1 || e.Errors[0] != "depends on example.com/provider/internal/services/dns, which failed to load" {
+				t.Errorf("network errors = %q, want its broken dependency", e.Errors)
+			}
+		default:
+			t.Errorf("unexpected load error for %s", e.Package)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package lint
+
+import (
+	"runtime/metrics"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/tools/go/analysis/checker"
+)
+
+// Timings records where the time and memory of a run went
+type Timings struct {
+	// Load is the time spent listing, parsing and type checking packages
+	Load time.Duration
+
+	// Analyzers are the analyzers that ran, slowest first. They include the analyzers the
+	// checks require, such as inspect.
+	Analyzers []AnalyzerTiming
+
+	// PeakHeap is the largest heap size sampled during the run, in bytes
+	PeakHeap uint64
+}
+
+// AnalyzerTiming is the time an analyzer spent on each package
+type AnalyzerTiming struct {
+	Analyzer string
+	Total    time.Duration
+	Packages []PackageTiming // slowest first
+}
+
+// PackageTiming is the time an analyzer spent on one package. Test variants are separate packages.
+type PackageTiming struct {
+	Package  string
+	Duration time.Duration
+}
+
+// addActions adds the durations of the actions of graph
+func (t *Timings) addActions(graph *checker.Graph) {
+	index := make(map[string]int, len(t.Analyzers))
+	for i, timing := range t.Analyzers {
+		index[timing.Analyzer] = i
+	}
+
+	for act := range graph.All() {
+		i, ok := index[act.Analyzer.Name]
+		if !ok {
+			i = len(t.Analyzers)
+			index[act.Analyzer.Name] = i
+			t.Analyzers = append(t.Analyzers, AnalyzerTiming{Analyzer: act.Analyzer.Name})
+		}
+		t.Analyzers[i].Total += act.Duration
+		t.Analyzers[i].Packages = append(t.Analyzers[i].Packages, PackageTiming{Package: act.Package.ID, Duration: act.Duration})
+	}
+
+	for _, timing := range t.Analyzers {
+		sort.SliceStable(timing.Packages, func(a, b int) bool {
+			return timing.Packages[a].Duration > timing.Packages[b].Duration
+		})
+	}
+	sort.SliceStable(t.Analyzers, func(i, j int) bool {
+		return t.Analyzers[i].Total > t.Analyzers[j].Total
+	})
+}
+
+// heapSampleInterval is how often the heap size is sampled while profiling
+const heapSampleInterval = 20 * time.Millisecond
+
+// heapMetric is the runtime metric sampled for PeakHeap: the memory occupied by live and
+// not yet swept heap objects
+const heapMetric = "/memory/classes/heap/objects:bytes"
+
+// sampleHeap records the peak heap size in t until the returned function is called
+func (t *Timings) sampleHeap(interval time.Duration) (stop func()) {
+	sample := []metrics.Sample{{Name: heapMetric}}
+	read := func() {
+		metrics.Read(sample)
+		if sample[0].Value.Kind() == metrics.KindUint64 && sample[0].Value.Uint64() > t.PeakHeap {
+			t.PeakHeap = sample[0].Value.Uint64()
+		}
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			read()
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
@@ -0,0 +1,45 @@
+package lint
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/qixialu/azurerm-linter/loader"
+	"github.com/qixialu/azurerm-linter/passes"
+	"golang.org/x/tools/go/analysis"
+)
+
+func TestRunRecordsTimingsWhenProfiling(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "go.mod"), "module example.com/provider\n\ngo 1.21\n")
+	writeFile(t, filepath.Join(dir, "internal", "services", "cdn", "cdn.go"), "package cdn\n")
+
+	res, err := Run(context.Background(), Options{
+		Patterns:  []string{"./..."},
+		Analyzers: []*analysis.Analyzer{passes.AZRE001Analyzer},
+		Dir:       dir,
+		Changes:   loader.LoaderOptions{NoFilter: true},
+		Profile:   true,
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	timings := res.Timings
+	if timings == nil || timings.Load <= 0 || timings.PeakHeap == 0 {
+		t.Fatalf("Timings = %+v, want load time and peak heap", timings)
+	}
+	var found bool
+	for _, a := range timings.Analyzers {
+		if a.Analyzer == "AZRE001" {
+			found = true
+			if len(a.Packages) == 0 || a.Packages[0].Package != "example.com/provider/internal/services/cdn" {
+				t.Fatalf("AZRE001 timings = %+v, want the cdn package", a)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("Timings.Analyzers = %+v, want AZRE001", timings.Analyzers)
+	}
+}
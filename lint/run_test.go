@@ -0,0 +1,137 @@
+package lint
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/qixialu/azurerm-linter/cache"
+	"github.com/qixialu/azurerm-linter/loader"
+	"github.com/qixialu/azurerm-linter/passes"
+	"github.com/qixialu/azurerm-linter/session"
+	"golang.org/x/tools/go/analysis"
+)
+
+func TestRunKeepsChangesPerRun(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "go.mod"), "module example.com/provider\n\ngo 1.21\n")
+	writeFile(t, filepath.Join(dir, "internal", "services", "cdn", "errors.go"), `package cdn
+
+import "fmt"
+
+func first() error {
+	return fmt.Errorf("first failure")
+}
+
+func second() error {
+	return fmt.Errorf("second failure")
+}
+`)
+
+	// Each run only keeps the finding on the line its diff adds
+	diffFor := func(line string) string {
+		path := filepath.Join(t.TempDir(), "changes.diff")
+		writeFile(t, path, `diff --git a/internal/services/cdn/errors.go b/internal/services/cdn/errors.go
+index 1111111..2222222 100644
+--- a/internal/services/cdn/errors.go
++++ b/internal/services/cdn/errors.go
+@@ -`+line+`,0 +`+line+`,1 @@
++	return fmt.Errorf("failure")
+`)
+		return path
+	}
+
+	wantLines := map[string]int{"6": 6, "10": 10}
+	results := make(map[string]*Result)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for line := range wantLines {
+		diffPath := diffFor(line)
+		wg.Add(1)
+		go func(line string) {
+			defer wg.Done()
+			res, err := Run(context.Background(), Options{
+				Analyzers: []*analysis.Analyzer{passes.AZRE001Analyzer},
+				Dir:       dir,
+				Changes:   loader.LoaderOptions{DiffFile: diffPath},
+			})
+			if err != nil {
+				t.Errorf("Run() error = %v", err)
+				return
+			}
+			mu.Lock()
+			results[line] = res
+			mu.Unlock()
+		}(line)
+	}
+	wg.Wait()
+
+	for line, want := range wantLines {
+		res := results[line]
+		if res == nil {
+			continue
+		}
+		if len(res.Findings) != 1 || res.Findings[0].Line != want || res.Findings[0].CheckID != "AZRE001" {
+			t.Errorf("run for line %s: findings = %+v, want one AZRE001 finding on line %d", line, res.Findings, want)
+		} else if f := res.Findings[0]; f.EndLine != want || f.EndColumn <= f.Column || f.EvidenceFile != f.Path || len(f.EvidenceLines) != 1 || f.EvidenceLines[0] != want {
+			t.Errorf("run for line %s: finding = %+v, want it to end on its line with the line as evidence", line, f)
+		}
+		if err := res.Close(); err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+	}
+}
+
+func TestRunKeepsCheckConfigPerRun(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "go.mod"), "module example.com/provider\n\ngo 1.21\n")
+	writeFile(t, filepath.Join(dir, "internal", "services", "cdn", "cdn.go"), "// Copyright (c) Contoso\n\npackage cdn\n")
+
+	store, err := cache.Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	// The runs share the process and the cache; only the one configured with the header accepts it
+	contoso := session.Config{Settings: map[string]map[string]string{
+		"AZBP005": {"license-header": "// Copyright (c) Contoso"},
+	}}
+	for _, tt := range []struct {
+		config session.Config
+		want   int
+	}{
+		{config: session.Config{}, want: 1},
+		{config: contoso, want: 0},
+		{config: session.Config{}, want: 1},
+	} {
+		res, err := Run(context.Background(), Options{
+			Patterns:    []string{"./..."},
+			Analyzers:   []*analysis.Analyzer{passes.AZBP005Analyzer},
+			CheckConfig: tt.config,
+			Cache:       store,
+			Dir:         dir,
+			Changes:     loader.LoaderOptions{NoFilter: true},
+		})
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		if len(res.Findings) != tt.want {
+			t.Errorf("Run() with settings %v: findings = %+v, want %d", tt.config.Settings, res.Findings, tt.want)
+		}
+		if err := res.Close(); err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
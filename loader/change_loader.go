@@ -2,9 +2,9 @@ package loader
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"log"
-	"os"
 	"path/filepath"
 	"regexp"
 	"strconv"
@@ -18,14 +18,6 @@ const servicePathPrefix = "internal/services/"
 
 var (
 	hunkRegex = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
-
-	// globalChangeSet holds the current loaded ChangeSet
-	// Set once by LoadChanges() before analyzers run, then only read by analyzers
-	globalChangeSet *ChangeSet
-
-	// worktreeCleanup holds the cleanup function for PR worktree
-	worktreeCleanup func() error
-	originalDir     string
 )
 
 type Hunk struct {
@@ -38,12 +30,18 @@ type Hunk struct {
 	ContextNewLines map[int]bool
 }
 
-// ChangeSet represents a set of changes loaded from a source
+// ChangeSet represents a set of changes loaded from a source.
+// A nil ChangeSet means change filtering is disabled: every file counts as changed.
 type ChangeSet struct {
 	changedLines map[string]map[int]bool
 	changedFiles map[string]bool
 	newFiles     map[string]bool
 	hunks        map[string][]Hunk
+	files        map[string]FileChange // status of every changed file, including deleted files
+
+	// pullRequest and worktree are set when the changes of a GitHub PR were loaded with --pr
+	pullRequest *PullRequest
+	worktree    *WorktreeLoader
 }
 
 // NewChangeSet creates a new empty ChangeSet
@@ -53,6 +51,7 @@ func NewChangeSet() *ChangeSet {
 		changedFiles: make(map[string]bool),
 		newFiles:     make(map[string]bool),
 		hunks:        make(map[string][]Hunk),
+		files:        make(map[string]FileChange),
 	}
 }
 
@@ -63,11 +62,21 @@ type ChangeLoader interface {
 
 // LoaderOptions holds configuration for change loading
 type LoaderOptions struct {
-	NoFilter   bool
-	PRNumber   int
-	RemoteName string
-	BaseBranch string
-	DiffFile   string
+	NoFilter     bool
+	PRNumber     int
+	RemoteName   string
+	BaseBranch   string
+	DiffFile     string
+	Staged       bool   // compare the index with HEAD
+	Range        string // compare two commits, as A..B or A...B
+	Repo         string // GitHub repository as owner/name; auto-detected from the remote when empty
+	GitHubAPIURL string // GitHub API base URL; auto-detected from the remote when empty
+}
+
+// Explicit reports whether the change source was chosen with --diff, --pr, --staged or --range
+// rather than detected from the local branch. Failing to load explicit changes is an error.
+func (o LoaderOptions) Explicit() bool {
+	return o.DiffFile != "" || o.PRNumber > 0 || o.Staged || o.Range != ""
 }
 
 // LoadChanges determines the appropriate ChangeLoader based on options
@@ -75,7 +84,6 @@ type LoaderOptions struct {
 func LoadChanges(opts LoaderOptions) (*ChangeSet, error) {
 	// Check if user explicitly disabled filtering
 	if opts.NoFilter {
-		globalChangeSet = nil
 		log.Println("Change filtering disabled (--no-filter) - analyzing all files")
 		return nil, nil
 	}
@@ -84,10 +92,20 @@ func LoadChanges(opts LoaderOptions) (*ChangeSet, error) {
 
 	switch {
 	case opts.DiffFile != "":
-		log.Printf("Using diff file: %s", opts.DiffFile)
+		if opts.DiffFile == StdinDiffFile {
+			log.Println("Using diff from stdin")
+		} else {
+			log.Printf("Using diff file: %s", opts.DiffFile)
+		}
 		loader = &DiffFileLoader{filePath: opts.DiffFile}
 	case opts.PRNumber > 0:
-		loader = selectGitLoader(opts)
+		return loadPullRequest(opts)
+	case opts.Staged:
+		log.Println("Using staged changes")
+		loader = &StagedGitLoader{}
+	case opts.Range != "":
+		log.Printf("Using commit range: %s", opts.Range)
+		loader = &RangeGitLoader{commitRange: opts.Range}
 	default:
 		if _, err := git.PlainOpen("."); err == nil {
 			log.Println("Using local git diff mode")
@@ -100,126 +118,76 @@ func LoadChanges(opts LoaderOptions) (*ChangeSet, error) {
 		}
 	}
 
-	var cs *ChangeSet
-	var err error
+	return loader.Load()
+}
 
-	if loader != nil {
-		cs, err = loader.Load()
+// loadPullRequest checks out the PR into a temporary worktree and loads its changed lines
+// from the GitHub API. The worktree is removed by Close.
+func loadPullRequest(opts LoaderOptions) (*ChangeSet, error) {
+	var owner, repo string
+	if opts.Repo != "" {
+		var err error
+		owner, repo, err = ParseRepo(opts.Repo)
 		if err != nil {
 			return nil, err
 		}
-	} else {
-		// Return empty ChangeSet if no loader is selected
-		cs = NewChangeSet()
-	}
-
-	// Set global ChangeSet for package-level functions
-	globalChangeSet = cs
-
-	return cs, nil
-}
-
-// selectGitLoader selects the appropriate git-based loader
-func selectGitLoader(opts LoaderOptions) ChangeLoader {
-	if opts.PRNumber > 0 {
-		setupPRWorktree(opts.PRNumber, opts.RemoteName, opts.BaseBranch)
-
-		log.Printf("Using GitHub API for PR #%d changed lines", opts.PRNumber)
-		return &GitHubLoader{prNumber: opts.PRNumber}
-	}
-
-	return &LocalGitLoader{
-		remoteName: opts.RemoteName,
-		baseBranch: opts.BaseBranch,
 	}
-}
 
-func setupPRWorktree(prNum int, remoteName, baseBranch string) {
-	worktreeLoader := NewWorktreeLoader(prNum, remoteName, baseBranch)
+	worktreeLoader := NewWorktreeLoader(opts.PRNumber, opts.RemoteName, opts.BaseBranch, owner, repo, opts.GitHubAPIURL)
 
-	// Setup worktree
 	worktreePath, err := worktreeLoader.Setup()
 	if err != nil {
-		log.Fatalf("Failed to setup worktree: %v", err)
+		return nil, fmt.Errorf("failed to setup worktree: %w", err)
 	}
 
-	worktreeCleanup = worktreeLoader.Cleanup
+	log.Printf("Using GitHub API for PR #%d changed lines", opts.PRNumber)
+	owner, repo, apiURL := worktreeLoader.GetRepository()
+	githubLoader := NewGitHubLoader(opts.PRNumber, owner, repo, apiURL)
+	githubLoader.dir = worktreePath
 
-	// Save current directory and switch to worktree
-	originalDir, err = os.Getwd()
+	cs, err := githubLoader.Load()
 	if err != nil {
-		log.Fatalf("Failed to get current directory: %v", err)
-	}
-
-	if err := os.Chdir(worktreePath); err != nil {
-		log.Fatalf("Failed to change to worktree directory: %v", err)
-	}
-}
-
-// IsFileChanged checks if a file has any changes
-func IsFileChanged(filename string) bool {
-	if globalChangeSet == nil {
-		return true
+		if cleanupErr := worktreeLoader.Cleanup(); cleanupErr != nil {
+			log.Printf("Warning: failed to cleanup worktree: %v", cleanupErr)
+		}
+		return nil, err
 	}
-	return globalChangeSet.IsFileChanged(filename)
-}
 
-// IsNewFile checks if a file is newly added
-func IsNewFile(filename string) bool {
-	if globalChangeSet == nil {
-		return true
-	}
-	return globalChangeSet.IsNewFile(filename)
+	cs.pullRequest = &PullRequest{Number: opts.PRNumber, Owner: owner, Repo: repo, APIURL: apiURL}
+	cs.worktree = worktreeLoader
+	return cs, nil
 }
 
-// IsEnabled checks if change tracking is enabled and has data
-func IsEnabled() bool {
-	if globalChangeSet == nil {
-		return false
+// PullRequest returns the GitHub PR the changes were loaded from, or nil
+func (cs *ChangeSet) PullRequest() *PullRequest {
+	if cs == nil {
+		return nil
 	}
-	return globalChangeSet.isEnabled()
+	return cs.pullRequest
 }
 
-// GetStats returns statistics about tracked changes
-func GetStats() (filesCount int, totalLines int) {
-	if globalChangeSet == nil {
-		return 0, 0
+// Dir returns the worktree the PR was checked out to, or "" when the changes apply to the current directory
+func (cs *ChangeSet) Dir() string {
+	if cs == nil || cs.worktree == nil {
+		return ""
 	}
-	return globalChangeSet.getStats()
+	return cs.worktree.GetWorktreePath()
 }
 
-// GetChangedPackages returns a list of package paths based on changed files
-func GetChangedPackages() []string {
-	if globalChangeSet == nil || len(globalChangeSet.changedFiles) == 0 {
+// Close removes the PR worktree, if any
+func (cs *ChangeSet) Close() error {
+	if cs == nil || cs.worktree == nil {
 		return nil
 	}
-	return globalChangeSet.getChangedPackages()
+	return cs.worktree.Cleanup()
 }
 
-// ShouldKeepDiagnostic applies metadata-backed filtering to a diagnostic.
-func ShouldKeepDiagnostic(meta reporting.DiagnosticMeta) bool {
-	if globalChangeSet == nil {
+// IsFileChanged checks if a file has any changes
+func (cs *ChangeSet) IsFileChanged(filename string) bool {
+	if cs == nil {
 		return true
 	}
-	return globalChangeSet.ShouldKeepDiagnostic(meta)
-}
-
-// CleanupWorktree cleans up the PR worktree and restores original directory
-func CleanupWorktree() {
-	if originalDir != "" {
-		if err := os.Chdir(originalDir); err != nil {
-			log.Printf("Warning: failed to return to original directory: %v", err)
-		}
-	}
-	if worktreeCleanup != nil {
-		if err := worktreeCleanup(); err != nil {
-			log.Printf("Warning: failed to cleanup worktree: %v", err)
-		}
-	}
-}
 
-// IsFileChanged checks if a file has any changes
-func (cs *ChangeSet) IsFileChanged(filename string) bool {
 	if len(cs.changedFiles) == 0 {
 		return false
 	}
@@ -234,6 +202,10 @@ func (cs *ChangeSet) IsFileChanged(filename string) bool {
 
 // IsNewFile checks if a file is newly added
 func (cs *ChangeSet) IsNewFile(filename string) bool {
+	if cs == nil {
+		return true
+	}
+
 	if len(cs.newFiles) == 0 {
 		return false
 	}
@@ -246,8 +218,63 @@ func (cs *ChangeSet) IsNewFile(filename string) bool {
 	return cs.newFiles[relPath]
 }
 
+// IsEditAllowed checks if every line in startLine..endLine was added by the diff,
+// so fixes never rewrite code the change did not touch. Any line of a new file may be edited.
+func (cs *ChangeSet) IsEditAllowed(filename string, startLine, endLine int) bool {
+	if cs == nil {
+		return true
+	}
+
+	relPath := normalizeFilePath(filename)
+	if !isServiceFile(relPath) || !cs.changedFiles[relPath] {
+		return false
+	}
+	if cs.newFiles[relPath] {
+		return true
+	}
+
+	lineMap := cs.changedLines[relPath]
+	for line := startLine; line <= endLine; line++ {
+		if !lineMap[line] {
+			return false
+		}
+	}
+	return true
+}
+
+// TouchesChanges checks if any line in startLine..endLine was added or lies within a changed hunk
+func (cs *ChangeSet) TouchesChanges(filename string, startLine, endLine int) bool {
+	if cs == nil {
+		return true
+	}
+
+	relPath := normalizeFilePath(filename)
+	if !isServiceFile(relPath) || !cs.changedFiles[relPath] {
+		return false
+	}
+	if cs.newFiles[relPath] {
+		return true
+	}
+
+	for line := startLine; line <= endLine; line++ {
+		if cs.changedLines[relPath][line] {
+			return true
+		}
+		for _, hunk := range cs.hunks[relPath] {
+			if hunk.ContainsNewLine(line) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // ShouldKeepDiagnostic applies metadata-backed filtering to a diagnostic.
 func (cs *ChangeSet) ShouldKeepDiagnostic(meta reporting.DiagnosticMeta) bool {
+	if cs == nil {
+		return true
+	}
+
 	evidenceFile := meta.EvidenceFile
 	if evidenceFile == "" {
 		evidenceFile = meta.ReportFile
@@ -275,13 +302,16 @@ func (cs *ChangeSet) ShouldKeepDiagnostic(meta reporting.DiagnosticMeta) bool {
 	}
 }
 
-// isEnabled checks if change tracking is enabled and has data
-func (cs *ChangeSet) isEnabled() bool {
-	return len(cs.changedFiles) > 0
+// IsEnabled checks if change tracking is enabled and has data
+func (cs *ChangeSet) IsEnabled() bool {
+	return cs != nil && len(cs.changedFiles) > 0
 }
 
-// getStats returns statistics about tracked changes
-func (cs *ChangeSet) getStats() (filesCount int, totalLines int) {
+// GetStats returns statistics about tracked changes
+func (cs *ChangeSet) GetStats() (filesCount int, totalLines int) {
+	if cs == nil {
+		return 0, 0
+	}
 	filesCount = len(cs.changedFiles)
 	totalLines = cs.getTotalChangedLines()
 	return
@@ -296,8 +326,12 @@ func (cs *ChangeSet) getTotalChangedLines() int {
 	return total
 }
 
-// getChangedPackages returns a list of unique package paths based on changed files
-func (cs *ChangeSet) getChangedPackages() []string {
+// GetChangedPackages returns a list of unique package paths based on changed files
+func (cs *ChangeSet) GetChangedPackages() []string {
+	if cs == nil || len(cs.changedFiles) == 0 {
+		return nil
+	}
+
 	packageSet := make(map[string]bool)
 
 	for filePath := range cs.changedFiles {
@@ -361,11 +395,28 @@ func (h Hunk) ContainsNewLine(line int) bool {
 	return line >= h.NewStart && line < h.NewStart+h.NewCount
 }
 
-// parsePatch parses a patch string and extracts changed line numbers into the ChangeSet
+// HunkError reports a hunk whose lines disagree with its @@ header
+type HunkError struct {
+	File string // file the hunk belongs to
+	Line int    // line of the patch where the problem was found, starting at 1
+	Msg  string
+}
+
+func (e *HunkError) Error() string {
+	return fmt.Sprintf("line %d: malformed hunk for %s: %s", e.Line, e.File, e.Msg)
+}
+
+// parsePatch parses a patch string and extracts changed line numbers into the ChangeSet.
+// Hunks end after the number of lines given in their header, so anything following the
+// last hunk, such as the signature of a format-patch email, is ignored.
 func (cs *ChangeSet) parsePatch(filePath string, patchContent string) error {
 	scanner := bufio.NewScanner(strings.NewReader(patchContent))
 	var oldLine int
 	var newLine int
+	var oldLeft, newLeft int // lines of the current hunk not yet seen
+	var header string
+	var headerLine int
+	lineNum := 0
 	inHunk := false
 	var currentHunk Hunk
 
@@ -374,41 +425,54 @@ func (cs *ChangeSet) parsePatch(filePath string, patchContent string) error {
 		cs.changedLines[filePath] = make(map[int]bool)
 	}
 
-	finishHunk := func() {
+	hunkError := func(line int, format string, args ...interface{}) error {
+		return &HunkError{File: filePath, Line: line, Msg: fmt.Sprintf(format, args...)}
+	}
+	finishHunk := func() error {
 		if !inHunk {
-			return
+			return nil
+		}
+		inHunk = false
+		if oldLeft > 0 || newLeft > 0 {
+			return hunkError(headerLine, "%q is missing %d old and %d new line(s)", header, oldLeft, newLeft)
 		}
 		cs.hunks[filePath] = append(cs.hunks[filePath], currentHunk)
+		return nil
 	}
 
 	for scanner.Scan() {
 		line := scanner.Text()
+		lineNum++
 
-		if matches := hunkRegex.FindStringSubmatch(line); matches != nil {
-			if inHunk {
-				finishHunk()
-			}
-
-			oldStart, err := strconv.Atoi(matches[1])
-			if err != nil {
-				continue
+		if inHunk && oldLeft == 0 && newLeft == 0 {
+			// A diff line right after a complete hunk means its header undercounts. The one
+			// exception is the "-- " signature separator of format-patch emails.
+			if len(line) > 0 && strings.ContainsRune("+- ", rune(line[0])) && line != "-- " {
+				return hunkError(lineNum, "%q has more lines than its header says", header)
 			}
-			oldCount, err := parseHunkCount(matches[2])
-			if err != nil {
-				continue
+			if err := finishHunk(); err != nil {
+				return err
 			}
-			newStart, err := strconv.Atoi(matches[3])
-			if err != nil {
-				continue
+		}
+
+		if matches := hunkRegex.FindStringSubmatch(line); matches != nil {
+			if err := finishHunk(); err != nil {
+				return err
 			}
-			newCount, err := parseHunkCount(matches[4])
-			if err != nil {
-				continue
+
+			var counts [4]int
+			for i := range counts {
+				value, err := parseHunkCount(matches[i+1])
+				if err != nil {
+					return hunkError(lineNum, "invalid header %q", line)
+				}
+				counts[i] = value
 			}
 
-			oldLine = oldStart
-			newLine = newStart
-			currentHunk = newHunk(oldStart, oldCount, newStart, newCount)
+			oldLine, newLine = counts[0], counts[2]
+			oldLeft, newLeft = counts[1], counts[3]
+			header, headerLine = strings.TrimSpace(hunkRegex.FindString(line)), lineNum
+			currentHunk = newHunk(counts[0], counts[1], counts[2], counts[3])
 			inHunk = true
 			continue
 		}
@@ -416,31 +480,47 @@ func (cs *ChangeSet) parsePatch(filePath string, patchContent string) error {
 			continue
 		}
 
-		if len(line) == 0 {
-			continue
+		// Editors may strip the space of empty context lines, which git accepts
+		prefix := byte(' ')
+		if len(line) > 0 {
+			prefix = line[0]
 		}
-
-		prefix := line[0]
 		switch prefix {
 		case '+':
+			if newLeft == 0 {
+				return hunkError(lineNum, "%q has more new lines than its header says", header)
+			}
 			cs.changedLines[filePath][newLine] = true
 			currentHunk.AddedNewLines[newLine] = true
 			newLine++
+			newLeft--
 		case '-':
+			if oldLeft == 0 {
+				return hunkError(lineNum, "%q has more old lines than its header says", header)
+			}
 			currentHunk.DeletedOldLines[oldLine] = true
 			oldLine++
+			oldLeft--
 		case ' ':
+			if oldLeft == 0 || newLeft == 0 {
+				return hunkError(lineNum, "%q has more lines than its header says", header)
+			}
 			currentHunk.ContextNewLines[newLine] = true
 			oldLine++
 			newLine++
+			oldLeft--
+			newLeft--
+		case '\\':
+			// "\ No newline at end of file"
+		default:
+			return hunkError(lineNum, "unexpected line %q in %q", line, header)
 		}
 	}
-
-	if inHunk {
-		finishHunk()
+	if err := scanner.Err(); err != nil {
+		return err
 	}
 
-	return scanner.Err()
+	return finishHunk()
 }
 
 func newHunk(oldStart, oldCount, newStart, newCount int) Hunk {
@@ -481,20 +561,16 @@ func normalizeFilePath(filename string) string {
 func (cs *ChangeSet) parseDiffOutput(diffOutput string) error {
 	diffGitRegex := regexp.MustCompile(`(?m)^diff --git a/(.+) b/(.+)$`)
 	matches := diffGitRegex.FindAllStringSubmatchIndex(diffOutput, -1)
-	isNewFileRegex := regexp.MustCompile(`(?m)^new file mode`)
 
 	if len(matches) == 0 {
 		return nil // No changes
 	}
 
-	for i, match := range matches {
-		// Extract file path from the match (use b/ path which is the new path)
-		fileName := diffOutput[match[4]:match[5]]
-
-		if !isServiceFile(fileName) {
-			continue
-		}
+	// Content of added and deleted files, to detect renames in diffs without rename detection
+	added := make(map[string]string)
+	deleted := make(map[string]string)
 
+	for i, match := range matches {
 		// Get the content of this file's diff (from this match to the next, or to the end)
 		var patchContent string
 		if i < len(matches)-1 {
@@ -503,20 +579,36 @@ func (cs *ChangeSet) parseDiffOutput(diffOutput string) error {
 			patchContent = diffOutput[match[0]:]
 		}
 
-		normalizedPath := normalizeFilePath(fileName)
-
-		isNewFile := isNewFileRegex.MatchString(patchContent)
-
-		if err := cs.parsePatch(normalizedPath, patchContent); err != nil {
-			log.Printf("Warning: failed to parse patch for %s: %v", normalizedPath, err)
+		change := parseFileHeader(patchContent, diffOutput[match[2]:match[3]], diffOutput[match[4]:match[5]])
+		if !isServiceFile(change.Path) {
 			continue
 		}
+		change.Path = normalizeFilePath(change.Path)
+		if change.OldPath != "" {
+			change.OldPath = normalizeFilePath(change.OldPath)
+		}
 
-		cs.changedFiles[normalizedPath] = true
-		if isNewFile {
-			cs.newFiles[normalizedPath] = true
+		switch change.Status {
+		case FileDeleted:
+			deleted[change.Path] = hunkContent(patchContent, '-')
+		case FileAdded:
+			added[change.Path] = hunkContent(patchContent, '+')
 		}
+
+		if change.Status != FileDeleted {
+			if err := cs.parsePatch(change.Path, patchContent); err != nil {
+				// Report the line within the whole diff
+				var hunkErr *HunkError
+				if errors.As(err, &hunkErr) {
+					hunkErr.Line += strings.Count(diffOutput[:match[0]], "\n")
+				}
+				return err
+			}
+		}
+
+		cs.recordFile(change)
 	}
 
+	cs.pairRenames(added, deleted)
 	return nil
 }
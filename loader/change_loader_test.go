@@ -132,3 +132,183 @@ index 1111111..2222222 100644
 		t.Fatalf("ShouldKeepDiagnostic() = true, want false for unrelated evidence line")
 	}
 }
+
+func TestChangeSetIsEditAllowedOnlyCoversAddedLines(t *testing.T) {
+	cs := NewChangeSet()
+
+	diff := `diff --git a/internal/services/cdn/cdn_profile_resource.go b/internal/services/cdn/cdn_profile_resource.go
+index 1111111..2222222 100644
+--- a/internal/services/cdn/cdn_profile_resource.go
++++ b/internal/services/cdn/cdn_profile_resource.go
+@@ -10,1 +10,3 @@
+ 	existing := true
++	added := []string{}
++	alsoAdded := []string{}
+diff --git a/internal/services/cdn/cdn_endpoint_resource.go b/internal/services/cdn/cdn_endpoint_resource.go
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/internal/services/cdn/cdn_endpoint_resource.go
+@@ -0,0 +1,1 @@
++package cdn
+`
+
+	if err := cs.parseDiffOutput(diff); err != nil {
+		t.Fatalf("parseDiffOutput() error = %v", err)
+	}
+
+	file := filepath.Join("repo", "internal", "services", "cdn", "cdn_profile_resource.go")
+	if !cs.IsEditAllowed(file, 11, 12) {
+		t.Fatalf("IsEditAllowed() = false for added lines, want true")
+	}
+	if cs.IsEditAllowed(file, 10, 11) {
+		t.Fatalf("IsEditAllowed() = true for edit spanning an unchanged line, want false")
+	}
+	if !cs.IsEditAllowed(filepath.Join("repo", "internal", "services", "cdn", "cdn_endpoint_resource.go"), 1, 40) {
+		t.Fatalf("IsEditAllowed() = false for new file, want true")
+	}
+	if cs.IsEditAllowed(filepath.Join("repo", "internal", "services", "dns", "registration.go"), 1, 1) {
+		t.Fatalf("IsEditAllowed() = true for unchanged file, want false")
+	}
+}
+
+func TestChangeSetTouchesChangesUsesHunks(t *testing.T) {
+	cs := NewChangeSet()
+
+	diff := `diff --git a/internal/services/cdn/registration.go b/internal/services/cdn/registration.go
+index 1111111..2222222 100644
+--- a/internal/services/cdn/registration.go
++++ b/internal/services/cdn/registration.go
+@@ -20,3 +20,4 @@
+ 		"azurerm_cdn_profile":  nil,
+ 		"azurerm_cdn_endpoint": nil,
++		"azurerm_cdn_custom":   nil,
+ 	}
+`
+
+	if err := cs.parseDiffOutput(diff); err != nil {
+		t.Fatalf("parseDiffOutput() error = %v", err)
+	}
+
+	file := filepath.Join("repo", "internal", "services", "cdn", "registration.go")
+	if !cs.TouchesChanges(file, 18, 20) {
+		t.Fatalf("TouchesChanges() = false for a range overlapping the hunk, want true")
+	}
+	if cs.TouchesChanges(file, 1, 19) {
+		t.Fatalf("TouchesChanges() = true for a range before the hunk, want false")
+	}
+	if cs.IsEditAllowed(file, 20, 22) {
+		t.Fatalf("IsEditAllowed() = true for a range with context lines, want false")
+	}
+}
+
+func TestChangeSetParsesRenamesDeletionsAndBinaryFiles(t *testing.T) {
+	cs := NewChangeSet()
+
+	diff := `diff --git a/internal/services/cdn/old_name.go b/internal/services/cdn/new_name.go
+similarity index 100%
+rename from internal/services/cdn/old_name.go
+rename to internal/services/cdn/new_name.go
+diff --git a/internal/services/cdn/edited.go b/internal/services/cdn/moved.go
+similarity index 87%
+rename from internal/services/cdn/edited.go
+rename to internal/services/cdn/moved.go
+index 1111111..2222222 100644
+--- a/internal/services/cdn/edited.go
++++ b/internal/services/cdn/moved.go
+@@ -3,1 +3,1 @@
+-old
++new
+diff --git a/internal/services/cdn/gone.go b/internal/services/cdn/gone.go
+deleted file mode 100644
+index 3333333..0000000
+--- a/internal/services/cdn/gone.go
++++ /dev/null
+@@ -1,1 +0,0 @@
+-package cdn
+diff --git a/internal/services/cdn/testdata/icon.png b/internal/services/cdn/testdata/icon.png
+new file mode 100644
+index 0000000..4444444
+Binary files /dev/null and b/internal/services/cdn/testdata/icon.png differ
+`
+
+	if err := cs.parseDiffOutput(diff); err != nil {
+		t.Fatalf("parseDiffOutput() error = %v", err)
+	}
+
+	renamed, ok := cs.File("repo/internal/services/cdn/new_name.go")
+	if !ok || !renamed.IsPureRename() || renamed.OldPath != "internal/services/cdn/old_name.go" {
+		t.Fatalf("File(new_name.go) = %+v, %v, want a pure rename of old_name.go", renamed, ok)
+	}
+	if cs.IsNewFile("repo/internal/services/cdn/new_name.go") {
+		t.Fatalf("IsNewFile() = true for a renamed file, want false")
+	}
+
+	moved, _ := cs.File("repo/internal/services/cdn/moved.go")
+	if moved.Status != FileRenamed || moved.Similarity != 87 || moved.IsPureRename() {
+		t.Fatalf("File(moved.go) = %+v, want a rename with similarity 87", moved)
+	}
+	if !cs.changedLines["internal/services/cdn/moved.go"][3] || len(cs.changedLines["internal/services/cdn/moved.go"]) != 1 {
+		t.Fatalf("changed lines of moved.go = %v, want only line 3", cs.changedLines["internal/services/cdn/moved.go"])
+	}
+
+	gone, ok := cs.File("repo/internal/services/cdn/gone.go")
+	if !ok || gone.Status != FileDeleted {
+		t.Fatalf("File(gone.go) = %+v, %v, want a deletion", gone, ok)
+	}
+	if cs.IsFileChanged("repo/internal/services/cdn/gone.go") {
+		t.Fatalf("IsFileChanged() = true for a deleted file, want false")
+	}
+
+	icon, _ := cs.File("repo/internal/services/cdn/testdata/icon.png")
+	if icon.Status != FileAdded || !icon.Binary || !cs.IsNewFile("repo/internal/services/cdn/testdata/icon.png") {
+		t.Fatalf("File(icon.png) = %+v, want a new binary file", icon)
+	}
+}
+
+func TestChangeSetPairsRenamesInDiffsWithoutRenameDetection(t *testing.T) {
+	cs := NewChangeSet()
+
+	diff := `diff --git a/internal/services/cdn/old_name.go b/internal/services/cdn/old_name.go
+deleted file mode 100644
+index 1111111..0000000
+--- a/internal/services/cdn/old_name.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package cdn
+-func f() {}
+diff --git a/internal/services/cdn/new_name.go b/internal/services/cdn/new_name.go
+new file mode 100644
+index 0000000..1111111
+--- /dev/null
++++ b/internal/services/cdn/new_name.go
+@@ -0,0 +1,2 @@
++package cdn
++func f() {}
+diff --git a/internal/services/cdn/added.go b/internal/services/cdn/added.go
+new file mode 100644
+index 0000000..2222222
+--- /dev/null
++++ b/internal/services/cdn/added.go
+@@ -0,0 +1,1 @@
++package cdn
+`
+
+	if err := cs.parseDiffOutput(diff); err != nil {
+		t.Fatalf("parseDiffOutput() error = %v", err)
+	}
+
+	renamed, _ := cs.File("internal/services/cdn/new_name.go")
+	if !renamed.IsPureRename() || renamed.OldPath != "internal/services/cdn/old_name.go" {
+		t.Fatalf("File(new_name.go) = %+v, want a pure rename of old_name.go", renamed)
+	}
+	if cs.IsNewFile("internal/services/cdn/new_name.go") || len(cs.changedLines["internal/services/cdn/new_name.go"]) != 0 {
+		t.Fatalf("renamed file is new or has changed lines, want neither")
+	}
+	if _, ok := cs.File("internal/services/cdn/old_name.go"); ok {
+		t.Fatalf("File(old_name.go) found, want the deletion replaced by the rename")
+	}
+	if !cs.IsNewFile("internal/services/cdn/added.go") {
+		t.Fatalf("IsNewFile(added.go) = false, want true")
+	}
+}
@@ -2,31 +2,60 @@ package loader
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"regexp"
+	"strings"
 )
 
-// DiffFileLoader loads changes from a diff file
+// StdinDiffFile is the diff file name that reads the diff from standard input
+const StdinDiffFile = "-"
+
+// diffHeaderRegex matches the file and hunk headers of a unified diff
+var diffHeaderRegex = regexp.MustCompile(`(?m)^(diff --git |@@ )`)
+
+// DiffFileLoader loads changes from a diff file, or a git format-patch mbox of several commits
 type DiffFileLoader struct {
 	filePath string
+
+	// stdin is read when filePath is StdinDiffFile; nil means os.Stdin
+	stdin io.Reader
 }
 
 // Load loads changes from a diff file and returns a ChangeSet
 func (l *DiffFileLoader) Load() (*ChangeSet, error) {
 	cs := NewChangeSet()
 
-	content, err := os.ReadFile(l.filePath)
+	name := l.filePath
+	var content []byte
+	var err error
+	if l.filePath == StdinDiffFile {
+		name = "stdin"
+		stdin := l.stdin
+		if stdin == nil {
+			stdin = os.Stdin
+		}
+		content, err = io.ReadAll(stdin)
+	} else {
+		content, err = os.ReadFile(l.filePath)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to read diff file: %w", err)
 	}
 
-	// Use the common parseDiffOutput function
-	if err := cs.parseDiffOutput(string(content)); err != nil {
-		return nil, err
+	// An empty diff changes nothing, like --staged with nothing staged; input without a single
+	// header is not a diff at all
+	if strings.TrimSpace(string(content)) == "" {
+		log.Printf("Warning: %s is empty, no files changed", name)
+		return cs, nil
+	}
+	if !diffHeaderRegex.Match(content) {
+		return nil, fmt.Errorf("no diff --git or @@ headers found in %s", name)
 	}
 
-	if len(cs.changedFiles) == 0 {
-		return nil, fmt.Errorf("no valid diff blocks found in file")
+	if err := cs.parsePatchSeries(string(content)); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
 	}
 
 	log.Printf("✓ Found %d changed files with %d changed lines",
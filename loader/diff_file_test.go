@@ -0,0 +1,239 @@
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const stdinDiff = `diff --git a/internal/services/cdn/resource.go b/internal/services/cdn/resource.go
+index 1111111..2222222 100644
+--- a/internal/services/cdn/resource.go
++++ b/internal/services/cdn/resource.go
+@@ -3,1 +3,2 @@
+ context
++added
+`
+
+func TestDiffFileLoaderReadsStdin(t *testing.T) {
+	cs, err := (&DiffFileLoader{filePath: StdinDiffFile, stdin: strings.NewReader(stdinDiff)}).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !cs.changedLines["internal/services/cdn/resource.go"][4] {
+		t.Fatalf("changedLines = %v, want line 4 of resource.go", cs.changedLines)
+	}
+}
+
+func TestDiffFileLoaderAcceptsDiffsWithoutServiceChanges(t *testing.T) {
+	const docsDiff = `diff --git a/website/docs/r/cdn_profile.html.markdown b/website/docs/r/cdn_profile.html.markdown
+index 1111111..2222222 100644
+--- a/website/docs/r/cdn_profile.html.markdown
++++ b/website/docs/r/cdn_profile.html.markdown
+@@ -3,1 +3,2 @@
+ context
++added
+`
+
+	for name, diff := range map[string]string{"docs only": docsDiff, "empty": "", "blank lines": "\n\n"} {
+		t.Run(name, func(t *testing.T) {
+			cs, err := (&DiffFileLoader{filePath: StdinDiffFile, stdin: strings.NewReader(diff)}).Load()
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cs == nil || len(cs.files) != 0 {
+				t.Fatalf("Load() = %+v, want an empty ChangeSet", cs)
+			}
+		})
+	}
+}
+
+func TestDiffFileLoaderRejectsInputWithoutHeaders(t *testing.T) {
+	_, err := (&DiffFileLoader{filePath: StdinDiffFile, stdin: strings.NewReader("not a diff\n")}).Load()
+	if err == nil || !strings.Contains(err.Error(), "no diff --git or @@ headers found in stdin") {
+		t.Fatalf("Load() error = %v, want missing headers error", err)
+	}
+}
+
+func TestDiffFileLoaderReportsMalformedHunks(t *testing.T) {
+	tests := []struct {
+		name string
+		diff string
+		want string
+	}{
+		{
+			name: "missing lines",
+			diff: `diff --git a/internal/services/cdn/resource.go b/internal/services/cdn/resource.go
+--- a/internal/services/cdn/resource.go
++++ b/internal/services/cdn/resource.go
+@@ -1,2 +1,3 @@
+ context
++added
+`,
+			want: `changes.diff: line 4: malformed hunk for internal/services/cdn/resource.go: "@@ -1,2 +1,3 @@" is missing 1 old and 1 new line(s)`,
+		},
+		{
+			name: "extra lines",
+			diff: `diff --git a/internal/services/cdn/other.go b/internal/services/cdn/other.go
+--- a/internal/services/cdn/other.go
++++ b/internal/services/cdn/other.go
+@@ -1,1 +1,1 @@
+-old
++new
+diff --git a/internal/services/cdn/resource.go b/internal/services/cdn/resource.go
+--- a/internal/services/cdn/resource.go
++++ b/internal/services/cdn/resource.go
+@@ -1,1 +1,2 @@
+ context
++added
++extra
+`,
+			want: `changes.diff: line 13: malformed hunk for internal/services/cdn/resource.go: "@@ -1,1 +1,2 @@" has more lines than its header says`,
+		},
+		{
+			name: "unexpected line",
+			diff: `diff --git a/internal/services/cdn/resource.go b/internal/services/cdn/resource.go
+--- a/internal/services/cdn/resource.go
++++ b/internal/services/cdn/resource.go
+@@ -1,2 +1,2 @@
+ context
+*garbage
+`,
+			want: `changes.diff: line 6: malformed hunk for internal/services/cdn/resource.go: unexpected line "*garbage" in "@@ -1,2 +1,2 @@"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chdir(t, t.TempDir())
+			if err := os.WriteFile("changes.diff", []byte(tt.diff), 0o600); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+
+			_, err := (&DiffFileLoader{filePath: "changes.diff"}).Load()
+			if err == nil || err.Error() != tt.want {
+				t.Fatalf("Load() error = %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
+
+// series is git format-patch output of two commits. The second inserts a line at the top of
+// resource.go and removes one of the lines the first added, and extends the file the first added.
+const series = `From 1111111111111111111111111111111111111111 Mon Sep 17 00:00:00 2001
+From: Dev <dev@example.com>
+Subject: [PATCH 1/2] Add things
+
+---
+ internal/services/cdn/client.go   | 1 +
+ internal/services/cdn/resource.go | 2 ++
+ 2 files changed, 3 insertions(+)
+
+diff --git a/internal/services/cdn/client.go b/internal/services/cdn/client.go
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/internal/services/cdn/client.go
+@@ -0,0 +1 @@
++package cdn
+diff --git a/internal/services/cdn/resource.go b/internal/services/cdn/resource.go
+index 1111111..2222222 100644
+--- a/internal/services/cdn/resource.go
++++ b/internal/services/cdn/resource.go
+@@ -4,1 +4,3 @@
+ line4
++a
++b
+-- 
+2.43.0
+
+
+From 2222222222222222222222222222222222222222 Mon Sep 17 00:00:00 2001
+From: Dev <dev@example.com>
+Subject: [PATCH 2/2] Adjust things
+
+---
+ internal/services/cdn/client.go   | 1 +
+ internal/services/cdn/resource.go | 2 +-
+ 2 files changed, 2 insertions(+), 1 deletion(-)
+
+diff --git a/internal/services/cdn/client.go b/internal/services/cdn/client.go
+index 3333333..4444444 100644
+--- a/internal/services/cdn/client.go
++++ b/internal/services/cdn/client.go
+@@ -1 +1,2 @@
+ package cdn
++func f() {}
+diff --git a/internal/services/cdn/resource.go b/internal/services/cdn/resource.go
+index 2222222..5555555 100644
+--- a/internal/services/cdn/resource.go
++++ b/internal/services/cdn/resource.go
+@@ -1,1 +1,2 @@
+ line1
++x
+@@ -5,2 +6,1 @@
+ a
+-b
+-- 
+2.43.0
+
+`
+
+func TestDiffFileLoaderMergesPatchSeries(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "series.mbox")
+	if err := os.WriteFile(path, []byte(series), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	cs, err := (&DiffFileLoader{filePath: path}).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	resource := "internal/services/cdn/resource.go"
+	got := sortedLines(cs.changedLines[resource])
+	if len(got) != 2 || got[0] != 2 || got[1] != 6 {
+		t.Fatalf("changed lines of resource.go = %v, want [2 6]: x, and a moved down by x with b removed", got)
+	}
+	if cs.IsNewFile(resource) {
+		t.Fatalf("IsNewFile(resource.go) = true, want false")
+	}
+	for _, line := range []int{2, 6} {
+		if !cs.matchesSameHunk(resource, []int{line}) {
+			t.Fatalf("line %d is outside the merged hunks %+v", line, cs.hunks[resource])
+		}
+	}
+
+	client := "internal/services/cdn/client.go"
+	if !cs.IsNewFile(client) || len(cs.changedLines[client]) != 2 {
+		t.Fatalf("client.go should be new with 2 added lines, got %v", cs.changedLines[client])
+	}
+}
+
+func TestMergeFollowsRenamesAndDeletions(t *testing.T) {
+	cs := NewChangeSet()
+	cs.recordFile(FileChange{Path: "internal/services/cdn/a.go", Status: FileModified})
+	cs.changedLines["internal/services/cdn/a.go"] = map[int]bool{3: true}
+	cs.recordFile(FileChange{Path: "internal/services/cdn/tmp.go", Status: FileAdded})
+
+	next := NewChangeSet()
+	next.recordFile(FileChange{Path: "internal/services/cdn/b.go", OldPath: "internal/services/cdn/a.go", Status: FileRenamed, Similarity: 100})
+	next.recordFile(FileChange{Path: "internal/services/cdn/tmp.go", Status: FileDeleted})
+	cs.merge(next)
+
+	b, ok := cs.File("internal/services/cdn/b.go")
+	if !ok || b.Status != FileRenamed || b.OldPath != "internal/services/cdn/a.go" || b.IsPureRename() {
+		t.Fatalf("File(b.go) = %+v, %v, want a rename of a.go that is not pure", b, ok)
+	}
+	if !cs.changedLines["internal/services/cdn/b.go"][3] {
+		t.Fatalf("changed lines of a.go did not move to b.go: %v", cs.changedLines)
+	}
+	if _, ok := cs.File("internal/services/cdn/a.go"); ok {
+		t.Fatalf("File(a.go) found after its rename")
+	}
+	if _, ok := cs.File("internal/services/cdn/tmp.go"); ok || cs.IsFileChanged("internal/services/cdn/tmp.go") {
+		t.Fatalf("tmp.go was added and deleted, want no change")
+	}
+}
@@ -0,0 +1,140 @@
+package loader
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+)
+
+// FileStatus is how a diff changed a file
+type FileStatus string
+
+const (
+	FileAdded    FileStatus = "added"
+	FileModified FileStatus = "modified"
+	FileRenamed  FileStatus = "renamed"
+	FileCopied   FileStatus = "copied"
+	FileDeleted  FileStatus = "deleted"
+)
+
+// FileChange describes a file changed by a diff. Paths are normalized like the other ChangeSet paths.
+type FileChange struct {
+	Path       string // path after the change; the removed path for deletions
+	OldPath    string // path before a rename or copy
+	Status     FileStatus
+	Similarity int  // similarity index of a rename or copy in percent, 0 when unknown
+	Binary     bool // binary changes have no changed lines
+}
+
+// IsPureRename reports whether the file was moved without changing its content
+func (f FileChange) IsPureRename() bool {
+	return f.Status == FileRenamed && f.Similarity == 100
+}
+
+// File returns how the diff changed filename
+func (cs *ChangeSet) File(filename string) (FileChange, bool) {
+	if cs == nil {
+		return FileChange{}, false
+	}
+	change, ok := cs.files[normalizeFilePath(filename)]
+	return change, ok
+}
+
+// recordFile records change. Deleted files cannot be analyzed, so they are not marked changed.
+// Added and copied files are new, renamed files are not.
+func (cs *ChangeSet) recordFile(change FileChange) {
+	cs.files[change.Path] = change
+	switch change.Status {
+	case FileDeleted:
+		return
+	case FileAdded, FileCopied:
+		cs.newFiles[change.Path] = true
+	}
+	cs.changedFiles[change.Path] = true
+}
+
+// parseFileHeader parses the extended header git writes between the "diff --git" line of a file
+// and its first hunk. oldName and newName are the a/ and b/ paths of the "diff --git" line.
+func parseFileHeader(patchContent, oldName, newName string) FileChange {
+	change := FileChange{Path: newName, Status: FileModified}
+
+	scanner := bufio.NewScanner(strings.NewReader(patchContent))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "@@") {
+			break
+		}
+
+		switch {
+		case strings.HasPrefix(line, "new file mode"):
+			change.Status = FileAdded
+		case strings.HasPrefix(line, "deleted file mode"):
+			change.Status = FileDeleted
+			change.Path = oldName
+		case strings.HasPrefix(line, "rename from "):
+			change.Status = FileRenamed
+			change.OldPath = strings.TrimPrefix(line, "rename from ")
+		case strings.HasPrefix(line, "rename to "):
+			change.Path = strings.TrimPrefix(line, "rename to ")
+		case strings.HasPrefix(line, "copy from "):
+			change.Status = FileCopied
+			change.OldPath = strings.TrimPrefix(line, "copy from ")
+		case strings.HasPrefix(line, "copy to "):
+			change.Path = strings.TrimPrefix(line, "copy to ")
+		case strings.HasPrefix(line, "similarity index "):
+			change.Similarity, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(line, "similarity index "), "%"))
+		case line == "GIT binary patch" || strings.HasPrefix(line, "Binary files "):
+			change.Binary = true
+		}
+	}
+	return change
+}
+
+// hunkContent returns the lines of the hunks of a patch that start with prefix, '+' for the
+// added lines or '-' for the removed lines, joined by newlines
+func hunkContent(patchContent string, prefix byte) string {
+	var b strings.Builder
+	inHunk := false
+	scanner := bufio.NewScanner(strings.NewReader(patchContent))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "@@") {
+			inHunk = true
+			continue
+		}
+		if inHunk && len(line) > 0 && line[0] == prefix {
+			b.WriteString(line[1:])
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// pairRenames turns an added file whose content equals that of a deleted file into a pure
+// rename of that file, as git reports it when rename detection is on. Diffs made with
+// --no-renames otherwise mark every line of a moved file as added.
+func (cs *ChangeSet) pairRenames(added, deleted map[string]string) {
+	byContent := make(map[string]string, len(deleted))
+	for path, content := range deleted {
+		if content != "" {
+			byContent[content] = path
+		}
+	}
+
+	for path, content := range added {
+		oldPath, ok := byContent[content]
+		if !ok {
+			continue
+		}
+		delete(byContent, content)
+		delete(cs.files, oldPath)
+		delete(cs.newFiles, path)
+		delete(cs.changedLines, path)
+		delete(cs.hunks, path)
+		change := cs.files[path]
+		change.Status = FileRenamed
+		change.OldPath = oldPath
+		change.Similarity = 100
+		cs.files[path] = change
+	}
+}
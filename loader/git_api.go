@@ -1,22 +1,21 @@
 package loader
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
-	"os"
+	"os/exec"
+	"strings"
 )
 
 // PRFile represents a file in a GitHub PR
 type PRFile struct {
-	Filename  string `json:"filename"`
-	Status    string `json:"status"`
-	Additions int    `json:"additions"`
-	Deletions int    `json:"deletions"`
-	Changes   int    `json:"changes"`
-	Patch     string `json:"patch"`
+	Filename         string `json:"filename"`
+	PreviousFilename string `json:"previous_filename"` // set for renamed and copied files
+	Status           string `json:"status"`
+	Additions        int    `json:"additions"`
+	Deletions        int    `json:"deletions"`
+	Changes          int    `json:"changes"`
+	Patch            string `json:"patch"`
 }
 
 // PRInfo represents GitHub PR information
@@ -33,135 +32,170 @@ type PRInfo struct {
 	} `json:"head"`
 }
 
+// PullRequest identifies a GitHub PR and the API serving it
+type PullRequest struct {
+	Number int
+	Owner  string
+	Repo   string
+	APIURL string
+}
+
 // GitHubLoader loads changes from GitHub API
 type GitHubLoader struct {
 	prNumber int
+	owner    string
+	repo     string
+	client   *githubClient
+	dir      string // worktree the PR head is checked out to
+
+	// localDiff returns a git diff in dir of files between the PR base and the fetched PR head.
+	// It fills in files whose patch GitHub omitted because the diff is too large.
+	localDiff func(dir, baseSHA string, files []string) (string, error)
+}
+
+// NewGitHubLoader creates a GitHubLoader for a PR of owner/repo served by the GitHub API at apiURL
+func NewGitHubLoader(prNumber int, owner, repo, apiURL string) *GitHubLoader {
+	return &GitHubLoader{
+		prNumber:  prNumber,
+		owner:     owner,
+		repo:      repo,
+		client:    newGitHubClient(apiURL),
+		localDiff: gitDiffFromMergeBase,
+	}
 }
 
 // Load loads changes from GitHub API and returns a ChangeSet
 func (l *GitHubLoader) Load() (*ChangeSet, error) {
 	cs := NewChangeSet()
 
-	token := os.Getenv("GITHUB_TOKEN")
-	owner, name := getRepoInfo()
+	owner, name := l.owner, l.repo
 
 	prNum := l.prNumber
 
 	log.Printf("Fetching PR #%d changes from GitHub API (%s/%s)...", prNum, owner, name)
 
-	files, err := fetchPRFiles(token, owner, name, prNum)
+	files, err := l.client.fetchPRFiles(owner, name, prNum)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch PR files: %w", err)
 	}
 
+	var truncated []string
 	for _, file := range files {
 		if !isServiceFile(file.Filename) {
 			continue
 		}
 
-		// Normalize the file path for consistent tracking
-		normalizedPath := normalizeFilePath(file.Filename)
-
-		// Check if this is a new file
-		isNewFile := file.Status == "added"
+		change := prFileChange(file)
 
-		if file.Patch != "" {
-			if err := cs.parsePatch(normalizedPath, file.Patch); err != nil {
-				log.Printf("Warning: failed to parse patch for %s: %v", file.Filename, err)
+		if change.Status == FileDeleted {
+			// Deleted files have no lines to analyze
+		} else if file.Patch != "" {
+			if err := cs.parsePatch(change.Path, file.Patch); err != nil {
+				return nil, fmt.Errorf("failed to parse the GitHub patch: %w", err)
 			}
+		} else if file.Changes > 0 {
+			// GitHub omits the patch of diffs that are too large to render
+			truncated = append(truncated, file.Filename)
 		}
 
-		cs.changedFiles[normalizedPath] = true
-		if isNewFile {
-			cs.newFiles[normalizedPath] = true
-		}
+		cs.recordFile(change)
+	}
+
+	if len(truncated) > 0 {
+		l.loadTruncatedPatches(cs, owner, name, truncated)
 	}
 
 	log.Printf("✓ Found %d changed files from GitHub API", len(cs.changedFiles))
 	return cs, nil
 }
 
-// fetchPRFiles fetches the list of changed files from GitHub API
-func fetchPRFiles(token, owner, name string, prNum int) ([]PRFile, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/pulls/%d/files", owner, name, prNum)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
+// prFileChange converts a file of the GitHub PR files API. GitHub detects renames without
+// reporting a similarity index; a renamed file without changes is a pure rename.
+func prFileChange(file PRFile) FileChange {
+	change := FileChange{Path: normalizeFilePath(file.Filename), Status: FileModified}
+	switch file.Status {
+	case "added":
+		change.Status = FileAdded
+	case "removed":
+		change.Status = FileDeleted
+	case "renamed":
+		change.Status = FileRenamed
+		if file.Changes == 0 {
+			change.Similarity = 100
+		}
+	case "copied":
+		change.Status = FileCopied
+	}
+	if file.PreviousFilename != "" && (change.Status == FileRenamed || change.Status == FileCopied) {
+		change.OldPath = normalizeFilePath(file.PreviousFilename)
 	}
+	return change
+}
 
-	if token != "" {
-		req.Header.Set("Authorization", "Bearer "+token)
+// loadTruncatedPatches fills in changed lines for files whose patch GitHub did not return,
+// using a local git diff of the fetched PR worktree
+func (l *GitHubLoader) loadTruncatedPatches(cs *ChangeSet, owner, name string, files []string) {
+	for _, file := range files {
+		log.Printf("Warning: GitHub API returned no patch for %s (diff too large), falling back to local git diff", file)
 	}
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	prInfo, err := l.client.fetchPRInfo(owner, name, l.prNumber)
 	if err != nil {
-		return nil, err
+		log.Printf("Warning: failed to fetch PR base for local git diff, %d file(s) have no changed lines: %v", len(files), err)
+		return
 	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			log.Printf("Warning: failed to close response body: %v", err)
-		}
-	}()
 
-	if resp.StatusCode != http.StatusOK {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("GitHub API returned status %d, failed to read body: %w", resp.StatusCode, err)
-		}
-		return nil, fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, string(body))
+	diff, err := l.localDiff(l.dir, prInfo.Base.SHA, files)
+	if err != nil {
+		log.Printf("Warning: local git diff failed, %d file(s) have no changed lines: %v", len(files), err)
+		return
 	}
 
-	var files []PRFile
-	if err := json.NewDecoder(resp.Body).Decode(&files); err != nil {
-		return nil, err
+	if err := cs.parseDiffOutput(diff); err != nil {
+		log.Printf("Warning: failed to parse local git diff: %v", err)
 	}
-
-	return files, nil
 }
 
-// getRepoInfo gets the repository owner and name
-func getRepoInfo() (owner, name string) {
-	return "hashicorp", "terraform-provider-azurerm"
+// gitDiffFromMergeBase diffs files in dir between the merge base of baseSHA and HEAD, matching the PR diff on GitHub
+func gitDiffFromMergeBase(dir, baseSHA string, files []string) (string, error) {
+	args := append([]string{"diff", "--no-ext-diff", baseSHA + "...HEAD", "--"}, files...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git diff: %w, output: %s", err, strings.TrimSpace(string(output)))
+	}
+	return string(output), nil
 }
 
-// fetchPRInfo fetches PR information from GitHub API
-func fetchPRInfo(token, owner, name string, prNum int) (*PRInfo, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/pulls/%d", owner, name, prNum)
+// fetchPRFiles fetches the list of changed files from GitHub API, following pagination
+func (c *githubClient) fetchPRFiles(owner, name string, prNum int) ([]PRFile, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/files?per_page=%d", c.baseURL, owner, name, prNum, githubPerPage)
 
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
+	var files []PRFile
+	for url != "" {
+		var page []PRFile
+		next, err := c.getJSON(url, &page)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, page...)
+		url = next
 	}
 
-	if token != "" {
-		req.Header.Set("Authorization", "Bearer "+token)
+	if len(files) >= githubMaxPRFiles {
+		log.Printf("Warning: GitHub API lists at most %d files per PR; changes beyond that are not analyzed", githubMaxPRFiles)
 	}
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			log.Printf("Warning: failed to close response body: %v", err)
-		}
-	}()
+	return files, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("GitHub API returned status %d, failed to read body: %w", resp.StatusCode, err)
-		}
-		return nil, fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, string(body))
-	}
+// fetchPRInfo fetches PR information from GitHub API
+func (c *githubClient) fetchPRInfo(owner, name string, prNum int) (*PRInfo, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d", c.baseURL, owner, name, prNum)
 
 	var prInfo PRInfo
-	if err := json.NewDecoder(resp.Body).Decode(&prInfo); err != nil {
+	if _, err := c.getJSON(url, &prInfo); err != nil {
 		return nil, err
 	}
 
@@ -0,0 +1,206 @@
+package loader
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func newTestGitHubClient(t *testing.T, handler http.Handler) (*githubClient, *[]time.Duration) {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	var sleeps []time.Duration
+	return &githubClient{
+		baseURL:    server.URL,
+		httpClient: server.Client(),
+		sleep:      func(d time.Duration) { sleeps = append(sleeps, d) },
+		now:        func() time.Time { return time.Unix(1700000000, 0) },
+	}, &sleeps
+}
+
+func TestFetchPRFilesFollowsPagination(t *testing.T) {
+	const totalFiles = 250
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/hashicorp/terraform-provider-azurerm/pulls/42/files", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("per_page"); got != "100" {
+			t.Errorf("per_page = %q, want 100", got)
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+
+		var files []PRFile
+		for i := (page - 1) * 100; i < page*100 && i < totalFiles; i++ {
+			files = append(files, PRFile{Filename: fmt.Sprintf("internal/services/cdn/file_%d.go", i), Status: "modified"})
+		}
+		if page*100 < totalFiles {
+			pageURL := "http://" + r.Host + r.URL.Path + "?per_page=100&page="
+			w.Header().Set("Link", fmt.Sprintf(`<%s%d>; rel="next", <%s3>; rel="last"`, pageURL, page+1, pageURL))
+		}
+		_ = json.NewEncoder(w).Encode(files)
+	})
+
+	client, _ := newTestGitHubClient(t, mux)
+
+	files, err := client.fetchPRFiles("hashicorp", "terraform-provider-azurerm", 42)
+	if err != nil {
+		t.Fatalf("fetchPRFiles() error = %v", err)
+	}
+	if len(files) != totalFiles {
+		t.Fatalf("len(files) = %d, want %d", len(files), totalFiles)
+	}
+	if files[totalFiles-1].Filename != "internal/services/cdn/file_249.go" {
+		t.Fatalf("last file = %q, want file_249.go", files[totalFiles-1].Filename)
+	}
+}
+
+func TestGetJSONRetriesRateLimitedRequests(t *testing.T) {
+	attempts := 0
+	client, sleeps := newTestGitHubClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		switch attempts {
+		case 1:
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", "1700000010")
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			_ = json.NewEncoder(w).Encode(PRInfo{Number: 42})
+		}
+	}))
+
+	prInfo, err := client.fetchPRInfo("hashicorp", "terraform-provider-azurerm", 42)
+	if err != nil {
+		t.Fatalf("fetchPRInfo() error = %v", err)
+	}
+	if prInfo.Number != 42 {
+		t.Fatalf("Number = %d, want 42", prInfo.Number)
+	}
+	if len(*sleeps) != 2 || (*sleeps)[0] != 3*time.Second || (*sleeps)[1] != 10*time.Second {
+		t.Fatalf("sleeps = %v, want [3s 10s]", *sleeps)
+	}
+}
+
+func TestGetJSONGivesUpAfterBoundedRetries(t *testing.T) {
+	attempts := 0
+	client, sleeps := newTestGitHubClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+
+	if _, err := client.fetchPRInfo("hashicorp", "terraform-provider-azurerm", 42); err == nil {
+		t.Fatalf("fetchPRInfo() error = nil, want rate limit error")
+	}
+	if attempts != githubMaxRetries+1 || len(*sleeps) != githubMaxRetries {
+		t.Fatalf("attempts = %d, sleeps = %d, want %d attempts", attempts, len(*sleeps), githubMaxRetries+1)
+	}
+}
+
+func TestGetJSONDoesNotWaitForDistantReset(t *testing.T) {
+	client, sleeps := newTestGitHubClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", "1700003600")
+		w.WriteHeader(http.StatusForbidden)
+	}))
+
+	if _, err := client.fetchPRInfo("hashicorp", "terraform-provider-azurerm", 42); err == nil {
+		t.Fatalf("fetchPRInfo() error = nil, want rate limit error")
+	}
+	if len(*sleeps) != 0 {
+		t.Fatalf("sleeps = %v, want no waiting for an hour-long reset", *sleeps)
+	}
+}
+
+func TestGitHubLoaderFallsBackToLocalDiffForTruncatedPatches(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/hashicorp/terraform-provider-azurerm/pulls/42/files", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]PRFile{
+			{Filename: "internal/services/cdn/small.go", Status: "modified", Changes: 1, Patch: "@@ -1,1 +1,1 @@\n-old\n+new"},
+			{Filename: "internal/services/cdn/large.go", Status: "modified", Changes: 5000},
+		})
+	})
+	mux.HandleFunc("/repos/hashicorp/terraform-provider-azurerm/pulls/42", func(w http.ResponseWriter, r *http.Request) {
+		info := PRInfo{Number: 42}
+		info.Base.SHA = "abc123"
+		_ = json.NewEncoder(w).Encode(info)
+	})
+
+	client, _ := newTestGitHubClient(t, mux)
+
+	var gotBase string
+	var gotFiles []string
+	loader := &GitHubLoader{
+		prNumber: 42,
+		owner:    "hashicorp",
+		repo:     "terraform-provider-azurerm",
+		client:   client,
+		localDiff: func(dir, baseSHA string, files []string) (string, error) {
+			gotBase, gotFiles = baseSHA, files
+			return `diff --git a/internal/services/cdn/large.go b/internal/services/cdn/large.go
+index 1111111..2222222 100644
+--- a/internal/services/cdn/large.go
++++ b/internal/services/cdn/large.go
+@@ -10,1 +10,2 @@
+ context
++added
+`, nil
+		},
+	}
+
+	cs, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if gotBase != "abc123" || len(gotFiles) != 1 || gotFiles[0] != "internal/services/cdn/large.go" {
+		t.Fatalf("localDiff(%q, %v), want base abc123 and only the truncated file", gotBase, gotFiles)
+	}
+	if !cs.changedLines["internal/services/cdn/large.go"][11] {
+		t.Fatalf("changed line from local diff was not recorded")
+	}
+	if !cs.changedLines["internal/services/cdn/small.go"][1] {
+		t.Fatalf("changed line from GitHub patch was not recorded")
+	}
+}
+
+func TestPRFileChangeModelsRenames(t *testing.T) {
+	tests := []struct {
+		file PRFile
+		want FileChange
+	}{
+		{
+			file: PRFile{Filename: "internal/services/cdn/new.go", PreviousFilename: "internal/services/cdn/old.go", Status: "renamed"},
+			want: FileChange{Path: "internal/services/cdn/new.go", OldPath: "internal/services/cdn/old.go", Status: FileRenamed, Similarity: 100},
+		},
+		{
+			file: PRFile{Filename: "internal/services/cdn/new.go", PreviousFilename: "internal/services/cdn/old.go", Status: "renamed", Changes: 2},
+			want: FileChange{Path: "internal/services/cdn/new.go", OldPath: "internal/services/cdn/old.go", Status: FileRenamed},
+		},
+		{
+			file: PRFile{Filename: "internal/services/cdn/gone.go", Status: "removed", Changes: 10},
+			want: FileChange{Path: "internal/services/cdn/gone.go", Status: FileDeleted},
+		},
+		{
+			file: PRFile{Filename: "internal/services/cdn/copy.go", PreviousFilename: "internal/services/cdn/orig.go", Status: "copied"},
+			want: FileChange{Path: "internal/services/cdn/copy.go", OldPath: "internal/services/cdn/orig.go", Status: FileCopied},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := prFileChange(tt.file); got != tt.want {
+			t.Errorf("prFileChange(%+v) = %+v, want %+v", tt.file, got, tt.want)
+		}
+	}
+}
@@ -31,7 +31,7 @@ func (l *LocalGitLoader) Load() (*ChangeSet, error) {
 		return nil, fmt.Errorf("failed to resolve target: %w", err)
 	}
 
-	if err := processDiffWithWorktree(cs, targetCommit); err != nil {
+	if err := processGitDiff(cs, targetCommit); err != nil {
 		return nil, fmt.Errorf("failed to parse diff: %w", err)
 	}
 	if err := addUntrackedFiles(cs); err != nil {
@@ -44,9 +44,146 @@ func (l *LocalGitLoader) Load() (*ChangeSet, error) {
 	return cs, nil
 }
 
-// processDiffWithWorktree compares a commit with the current worktree using git diff
-func processDiffWithWorktree(cs *ChangeSet, diffRef string) error {
-	cmd := exec.Command("git", "diff", "--no-ext-diff", diffRef)
+// StagedGitLoader loads the changes staged in the index against HEAD, as a pre-commit hook
+// commits them. Untracked files are ignored. Packages are loaded from the working tree, so
+// service files must not have unstaged changes on top of staged ones.
+type StagedGitLoader struct{}
+
+// Load loads the staged changes and returns a ChangeSet
+func (l *StagedGitLoader) Load() (*ChangeSet, error) {
+	if err := checkStagedMatchesWorktree(); err != nil {
+		return nil, err
+	}
+
+	cs := NewChangeSet()
+
+	if err := processGitDiff(cs, "--cached"); err != nil {
+		return nil, fmt.Errorf("failed to parse staged diff: %w", err)
+	}
+
+	log.Printf("✓ Found %d staged files with %d changed lines",
+		len(cs.changedFiles), cs.getTotalChangedLines())
+
+	return cs, nil
+}
+
+// RangeGitLoader loads the changes between two commits given as A..B, or as A...B to compare
+// B with the merge-base of A and B. Packages are loaded from the working tree, so B must be
+// checked out without uncommitted changes.
+type RangeGitLoader struct {
+	commitRange string
+}
+
+// Load loads the changes of the commit range and returns a ChangeSet
+func (l *RangeGitLoader) Load() (*ChangeSet, error) {
+	if err := ValidateCommitRange(l.commitRange); err != nil {
+		return nil, err
+	}
+	if err := checkRangeEnd(l.commitRange); err != nil {
+		return nil, err
+	}
+
+	cs := NewChangeSet()
+	if err := processGitDiff(cs, l.commitRange); err != nil {
+		return nil, fmt.Errorf("failed to parse diff of %s: %w", l.commitRange, err)
+	}
+
+	log.Printf("✓ Found %d changed files with %d changed lines in %s",
+		len(cs.changedFiles), cs.getTotalChangedLines(), l.commitRange)
+
+	return cs, nil
+}
+
+// ValidateCommitRange checks that commitRange has the form A..B or A...B
+func ValidateCommitRange(commitRange string) error {
+	from, to, ok := strings.Cut(commitRange, "..")
+	to = strings.TrimPrefix(to, ".")
+	if !ok || from == "" || to == "" || strings.HasPrefix(to, ".") || strings.Contains(to, "..") || strings.HasPrefix(from, "-") {
+		return fmt.Errorf("%q is not of the form A..B or A...B", commitRange)
+	}
+	return nil
+}
+
+// checkRangeEnd checks that the working tree the packages are loaded from matches the end of
+// commitRange: the end commit is checked out and tracked files have no uncommitted changes
+func checkRangeEnd(commitRange string) error {
+	_, to, _ := strings.Cut(commitRange, "..")
+	to = strings.TrimPrefix(to, ".")
+
+	end, err := resolveCommit(to)
+	if err != nil {
+		return err
+	}
+	head, err := resolveCommit("HEAD")
+	if err != nil {
+		return err
+	}
+	if end != head {
+		return fmt.Errorf("--range %s analyzes the working tree, but %s is not checked out: run 'git checkout %s' first", commitRange, to, to)
+	}
+
+	output, err := exec.Command("git", "status", "--porcelain", "--untracked-files=no").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to run git status: %w, output: %s", err, strings.TrimSpace(string(output)))
+	}
+	if len(strings.TrimSpace(string(output))) > 0 {
+		return fmt.Errorf("--range %s analyzes the working tree, which has uncommitted changes: commit or stash them first", commitRange)
+	}
+	return nil
+}
+
+// checkStagedMatchesWorktree checks that no service file has both staged and unstaged changes,
+// as the staged lines would not match the working tree file the packages are loaded from
+func checkStagedMatchesWorktree() error {
+	staged, err := diffNames("--cached")
+	if err != nil {
+		return err
+	}
+	unstaged, err := diffNames()
+	if err != nil {
+		return err
+	}
+
+	stagedNames := make(map[string]bool, len(staged))
+	for _, name := range staged {
+		stagedNames[name] = true
+	}
+
+	var both []string
+	for _, name := range unstaged {
+		if stagedNames[name] && isServiceFile(normalizeFilePath(name)) {
+			both = append(both, name)
+		}
+	}
+	if len(both) > 0 {
+		return fmt.Errorf("--staged analyzes the working tree, but files with staged changes also have unstaged ones: %s; stage them, or stash them with 'git stash --keep-index', first",
+			strings.Join(both, ", "))
+	}
+	return nil
+}
+
+// diffNames returns the files git diff with args reports as changed
+func diffNames(args ...string) ([]string, error) {
+	output, err := exec.Command("git", append([]string{"diff", "--no-ext-diff", "--name-only"}, args...)...).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run git diff: %w, output: %s", err, strings.TrimSpace(string(output)))
+	}
+	return strings.Fields(string(output)), nil
+}
+
+// resolveCommit returns the hash of the commit rev names
+func resolveCommit(rev string) (string, error) {
+	output, err := exec.Command("git", "rev-parse", "--verify", "--end-of-options", rev+"^{commit}").CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %s", rev, strings.TrimSpace(string(output)))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// processGitDiff parses the output of git diff with args, such as a commit to compare the
+// worktree with
+func processGitDiff(cs *ChangeSet, args ...string) error {
+	cmd := exec.Command("git", append([]string{"diff", "--no-ext-diff", "--find-renames"}, args...)...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("failed to run git diff: %w, output: %s", err, strings.TrimSpace(string(output)))
@@ -3,7 +3,9 @@ package loader
 import (
 	"errors"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/go-git/go-git/v5"
@@ -146,3 +148,204 @@ func setBranchConfig(t *testing.T, repo *git.Repository, currentBranch, remoteNa
 		t.Fatalf("SetConfig() error = %v", err)
 	}
 }
+
+func TestStagedGitLoaderIgnoresUnstagedChanges(t *testing.T) {
+	root := newGitCLITestRepo(t)
+	chdir(t, root)
+
+	writeServiceFile(t, root, "cdn/resource.go", "package cdn\n\nfunc a() {}\n")
+	writeServiceFile(t, root, "cdn/other.go", "package cdn\n")
+	runGit(t, "add", ".")
+	runGit(t, "commit", "-m", "initial")
+
+	writeServiceFile(t, root, "cdn/resource.go", "package cdn\n\nfunc a() {}\n\nfunc b() {}\n")
+	writeServiceFile(t, root, "cdn/client.go", "package cdn\n")
+	runGit(t, "add", ".")
+	writeServiceFile(t, root, "cdn/other.go", "package cdn\n\nfunc c() {}\n")
+
+	cs, err := (&StagedGitLoader{}).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	resource := "internal/services/cdn/resource.go"
+	if !cs.IsFileChanged(resource) || cs.IsNewFile(resource) {
+		t.Fatalf("resource.go should be changed but not new")
+	}
+	if got := len(cs.changedLines[resource]); got != 2 || !cs.changedLines[resource][5] {
+		t.Fatalf("changedLines = %v, want the staged lines 4-5 only", cs.changedLines[resource])
+	}
+	if !cs.IsNewFile("internal/services/cdn/client.go") {
+		t.Fatalf("IsNewFile(client.go) = false, want true for a staged new file")
+	}
+	if cs.IsFileChanged("internal/services/cdn/other.go") {
+		t.Fatalf("IsFileChanged(other.go) = true, want unstaged changes ignored")
+	}
+}
+
+func TestStagedGitLoaderRejectsFilesWithStagedAndUnstagedChanges(t *testing.T) {
+	root := newGitCLITestRepo(t)
+	chdir(t, root)
+
+	writeServiceFile(t, root, "cdn/resource.go", "package cdn\n\nfunc a() {}\n")
+	runGit(t, "add", ".")
+	runGit(t, "commit", "-m", "initial")
+
+	writeServiceFile(t, root, "cdn/resource.go", "package cdn\n\nfunc a() {}\n\nfunc b() {}\n")
+	runGit(t, "add", ".")
+	writeServiceFile(t, root, "cdn/resource.go", "package cdn\n\nfunc c() {}\n\nfunc a() {}\n\nfunc b() {}\n")
+
+	_, err := (&StagedGitLoader{}).Load()
+	if err == nil || !strings.Contains(err.Error(), "internal/services/cdn/resource.go") {
+		t.Fatalf("Load() error = %v, want the file with staged and unstaged changes", err)
+	}
+
+	runGit(t, "add", ".")
+	if _, err := (&StagedGitLoader{}).Load(); err != nil {
+		t.Fatalf("Load() after staging everything error = %v", err)
+	}
+}
+
+func TestRangeGitLoaderComparesCommits(t *testing.T) {
+	root := newGitCLITestRepo(t)
+	chdir(t, root)
+
+	writeServiceFile(t, root, "cdn/resource.go", "package cdn\n")
+	runGit(t, "add", ".")
+	runGit(t, "commit", "-m", "initial")
+	runGit(t, "tag", "v1")
+
+	writeServiceFile(t, root, "dns/zone.go", "package dns\n\nfunc zone() {}\n")
+	runGit(t, "add", ".")
+	runGit(t, "commit", "-m", "add dns")
+
+	cs, err := (&RangeGitLoader{commitRange: "v1..HEAD"}).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !cs.IsNewFile("internal/services/dns/zone.go") || len(cs.changedLines["internal/services/dns/zone.go"]) != 3 {
+		t.Fatalf("zone.go should be new with 3 added lines, got %v", cs.changedLines)
+	}
+	if cs.IsFileChanged("internal/services/cdn/resource.go") {
+		t.Fatalf("IsFileChanged(resource.go) = true, want false for a file outside the range")
+	}
+}
+
+func TestRangeGitLoaderRequiresEndOfRangeCheckedOut(t *testing.T) {
+	root := newGitCLITestRepo(t)
+	chdir(t, root)
+
+	writeServiceFile(t, root, "cdn/resource.go", "package cdn\n")
+	runGit(t, "add", ".")
+	runGit(t, "commit", "-m", "initial")
+	runGit(t, "tag", "v1")
+	writeServiceFile(t, root, "cdn/resource.go", "package cdn\n\nfunc a() {}\n")
+	runGit(t, "commit", "-am", "add a")
+	runGit(t, "tag", "v2")
+
+	// The working tree is not at the end of the range
+	runGit(t, "checkout", "-q", "v1")
+	if _, err := (&RangeGitLoader{commitRange: "v1..v2"}).Load(); err == nil || !strings.Contains(err.Error(), "v2 is not checked out") {
+		t.Fatalf("Load() error = %v, want the end of the range to be checked out", err)
+	}
+
+	// The working tree has uncommitted changes
+	runGit(t, "checkout", "-q", "v2")
+	writeServiceFile(t, root, "cdn/resource.go", "package cdn\n\nfunc b() {}\n")
+	if _, err := (&RangeGitLoader{commitRange: "v1..v2"}).Load(); err == nil || !strings.Contains(err.Error(), "uncommitted changes") {
+		t.Fatalf("Load() error = %v, want uncommitted changes to be rejected", err)
+	}
+
+	runGit(t, "checkout", "--", ".")
+	if _, err := (&RangeGitLoader{commitRange: "v1..v2"}).Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+}
+
+func TestRangeGitLoaderTreatsMovedFileAsRename(t *testing.T) {
+	root := newGitCLITestRepo(t)
+	chdir(t, root)
+
+	content := "package cdn\n\nfunc a() {}\n\nfunc b() {}\n"
+	writeServiceFile(t, root, "cdn/old_resource.go", content)
+	runGit(t, "add", ".")
+	runGit(t, "commit", "-m", "initial")
+	runGit(t, "config", "diff.renames", "false")
+
+	runGit(t, "mv", "internal/services/cdn/old_resource.go", "internal/services/cdn/new_resource.go")
+	runGit(t, "commit", "-m", "rename")
+
+	cs, err := (&RangeGitLoader{commitRange: "HEAD~1..HEAD"}).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	change, ok := cs.File("internal/services/cdn/new_resource.go")
+	if !ok || !change.IsPureRename() || change.OldPath != "internal/services/cdn/old_resource.go" {
+		t.Fatalf("File(new_resource.go) = %+v, %v, want a pure rename", change, ok)
+	}
+	if cs.IsNewFile("internal/services/cdn/new_resource.go") {
+		t.Fatalf("IsNewFile() = true for a renamed file, want false")
+	}
+}
+
+func TestValidateCommitRange(t *testing.T) {
+	for _, valid := range []string{"v1..v2", "main...HEAD", "abc123..def456"} {
+		if err := ValidateCommitRange(valid); err != nil {
+			t.Errorf("ValidateCommitRange(%q) error = %v", valid, err)
+		}
+	}
+	for _, invalid := range []string{"main", "..HEAD", "v1..", "a..b..c", "--output=x..y", "a....b"} {
+		if err := ValidateCommitRange(invalid); err == nil {
+			t.Errorf("ValidateCommitRange(%q) error = nil, want error", invalid)
+		}
+	}
+}
+
+func newGitCLITestRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	root := t.TempDir()
+	cmd := exec.Command("git", "init", "-q", root)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git init error = %v: %s", err, output)
+	}
+	return root
+}
+
+func runGit(t *testing.T, args ...string) {
+	t.Helper()
+	args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false"}, args...)
+	if output, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		t.Fatalf("git %v error = %v: %s", args, err, output)
+	}
+}
+
+func writeServiceFile(t *testing.T, root, name, content string) {
+	t.Helper()
+	path := filepath.Join(root, "internal", "services", filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	oldWD, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd() error = %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Chdir() error = %v", err)
+	}
+	t.Cleanup(func() {
+		if err := os.Chdir(oldWD); err != nil {
+			t.Fatalf("Chdir() cleanup error = %v", err)
+		}
+	})
+}
@@ -0,0 +1,182 @@
+package loader
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultGitHubAPIURL = "https://api.github.com"
+
+	// githubPerPage is the largest page size accepted by the GitHub REST API
+	githubPerPage = 100
+
+	// githubMaxPRFiles is the number of files GitHub returns at most when listing PR files
+	githubMaxPRFiles = 3000
+
+	// githubMaxRetries bounds how often a rate-limited request is retried
+	githubMaxRetries = 3
+
+	// githubMaxRetryWait bounds how long a single retry waits for the rate limit to reset
+	githubMaxRetryWait = 2 * time.Minute
+)
+
+var linkNextRegex = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// githubClient performs GitHub REST API requests with pagination and rate limit handling
+type githubClient struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+
+	// sleep and now are replaced in tests to avoid waiting for real rate limit windows
+	sleep func(time.Duration)
+	now   func() time.Time
+}
+
+// newGitHubClient creates a client for the GitHub API at apiURL (the public API when empty),
+// authenticated with GITHUB_TOKEN, if set
+func newGitHubClient(apiURL string) *githubClient {
+	if apiURL == "" {
+		apiURL = defaultGitHubAPIURL
+	}
+	return &githubClient{
+		baseURL:    apiURL,
+		token:      os.Getenv("GITHUB_TOKEN"),
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+		sleep:      time.Sleep,
+		now:        time.Now,
+	}
+}
+
+// getJSON fetches url and decodes the JSON body into out. It returns the URL of the next
+// page from the Link header, or "" on the last page.
+func (c *githubClient) getJSON(url string, out interface{}) (string, error) {
+	return c.requestJSON(http.MethodGet, url, nil, out)
+}
+
+// requestJSON sends in as the JSON body of a request and decodes the JSON response into out,
+// retrying rate-limited requests. in and out may be nil.
+func (c *githubClient) requestJSON(method, url string, in, out interface{}) (string, error) {
+	var payload []byte
+	if in != nil {
+		var err error
+		if payload, err = json.Marshal(in); err != nil {
+			return "", err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.do(method, url, payload)
+		if err != nil {
+			return "", err
+		}
+
+		if wait, limited := c.rateLimitWait(resp); limited {
+			body, _ := io.ReadAll(resp.Body)
+			closeBody(resp)
+			if attempt >= githubMaxRetries {
+				return "", fmt.Errorf("GitHub API rate limit exceeded after %d retries: %s", githubMaxRetries, string(body))
+			}
+			if wait > githubMaxRetryWait {
+				return "", fmt.Errorf("GitHub API rate limit exceeded, resets in %s; set GITHUB_TOKEN for a higher limit", wait.Round(time.Second))
+			}
+			log.Printf("GitHub API rate limit hit, retrying in %s (%d/%d)...", wait.Round(time.Second), attempt+1, githubMaxRetries)
+			c.sleep(wait)
+			continue
+		}
+
+		defer closeBody(resp)
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				return "", fmt.Errorf("GitHub API returned status %d, failed to read body: %w", resp.StatusCode, err)
+			}
+			return "", fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, string(body))
+		}
+
+		if out != nil {
+			if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+				return "", err
+			}
+		}
+
+		return nextPageURL(resp.Header.Get("Link")), nil
+	}
+}
+
+func (c *githubClient) do(method, url string, payload []byte) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return c.httpClient.Do(req)
+}
+
+// rateLimitWait reports whether the response is a primary or secondary rate limit error
+// and how long to wait before retrying
+func (c *githubClient) rateLimitWait(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			wait := time.Unix(reset, 0).Sub(c.now())
+			if wait < time.Second {
+				wait = time.Second
+			}
+			return wait, true
+		}
+	}
+
+	// A 429 without hints is still a rate limit; GitHub asks to wait at least a minute
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return time.Minute, true
+	}
+
+	return 0, false
+}
+
+// nextPageURL extracts the rel="next" URL from a Link header
+func nextPageURL(link string) string {
+	if match := linkNextRegex.FindStringSubmatch(link); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+func closeBody(resp *http.Response) {
+	if err := resp.Body.Close(); err != nil {
+		log.Printf("Warning: failed to close response body: %v", err)
+	}
+}
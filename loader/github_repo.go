@@ -0,0 +1,83 @@
+package loader
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	defaultRepoOwner = "hashicorp"
+	defaultRepoName  = "terraform-provider-azurerm"
+)
+
+// ParseRepo splits an "owner/name" repository reference
+func ParseRepo(repo string) (owner, name string, err error) {
+	owner, name, ok := strings.Cut(strings.TrimSpace(repo), "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return "", "", fmt.Errorf("invalid repository %q: must be in the form owner/name", repo)
+	}
+	return owner, name, nil
+}
+
+// NormalizeAPIURL validates a GitHub API base URL and strips any trailing slash
+func NormalizeAPIURL(apiURL string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(apiURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid GitHub API URL %q: must be an http(s) URL such as https://github.example.com/api/v3", apiURL)
+	}
+	return strings.TrimSuffix(u.String(), "/"), nil
+}
+
+// parseRemoteURL extracts the host and repository from a git remote URL.
+// Supports https://host/owner/name(.git), ssh://git@host[:port]/owner/name(.git) and git@host:owner/name(.git).
+func parseRemoteURL(remoteURL string) (host, owner, name string, ok bool) {
+	var path string
+	if strings.Contains(remoteURL, "://") {
+		u, err := url.Parse(remoteURL)
+		if err != nil || u.Hostname() == "" {
+			return "", "", "", false
+		}
+		host, path = u.Hostname(), u.Path
+	} else {
+		// scp-like syntax: [user@]host:path
+		hostPart, pathPart, found := strings.Cut(remoteURL, ":")
+		if !found || strings.Contains(hostPart, "/") {
+			return "", "", "", false
+		}
+		if _, after, found := strings.Cut(hostPart, "@"); found {
+			hostPart = after
+		}
+		host, path = hostPart, pathPart
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	segments := strings.Split(path, "/")
+	if host == "" || len(segments) < 2 {
+		return "", "", "", false
+	}
+
+	owner, name = segments[len(segments)-2], segments[len(segments)-1]
+	if owner == "" || name == "" {
+		return "", "", "", false
+	}
+	return host, owner, name, true
+}
+
+// EnterpriseAPIURL returns the REST API base URL of a GitHub Enterprise Server host
+func EnterpriseAPIURL(host string) (string, error) {
+	host = strings.TrimSpace(host)
+	if host == "" || strings.ContainsAny(host, "/ ") {
+		return "", fmt.Errorf("invalid GitHub host %q: must be a host name such as github.example.com", host)
+	}
+	return "https://" + host + "/api/v3", nil
+}
+
+// apiURLForHost returns the REST API base URL of a github.com remote host. Other hosts are only
+// known to be GitHub Enterprise Server when configured, so they are rejected.
+func apiURLForHost(host string) (string, error) {
+	if host == "github.com" || host == "www.github.com" {
+		return defaultGitHubAPIURL, nil
+	}
+	return "", fmt.Errorf("remote host %s is not a GitHub repository; for GitHub Enterprise Server, set --github-host or --github-api-url", host)
+}
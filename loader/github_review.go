@@ -0,0 +1,242 @@
+package loader
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+)
+
+// reviewMarker identifies reviews posted by the linter, so later runs update them instead of posting new ones
+const reviewMarker = "<!-- azurerm-linter-review -->"
+
+// githubActionsLogin is the user GitHub Actions posts as with its GITHUB_TOKEN, which cannot read
+// its own user from the API
+const githubActionsLogin = "github-actions[bot]"
+
+// ReviewComment is a finding attached to a line of a PR file
+type ReviewComment struct {
+	Path string // repository-relative, slash-separated
+	Line int
+	Body string
+}
+
+// Review is the linter's review of a PR
+type Review struct {
+	Summary  string
+	Comments []ReviewComment // findings on lines within the PR diff, posted as inline comments
+	Outside  []ReviewComment // findings on lines outside the PR diff, listed in the review body
+}
+
+type pullReview struct {
+	ID   int64      `json:"id"`
+	Body string     `json:"body"`
+	User githubUser `json:"user"`
+}
+
+type githubUser struct {
+	Login string `json:"login"`
+}
+
+type pullReviewComment struct {
+	ID   int64  `json:"id"`
+	Path string `json:"path"`
+	Line *int   `json:"line"` // nil when the comment is outdated
+	Body string `json:"body"`
+}
+
+type createReviewRequest struct {
+	CommitID string                `json:"commit_id"`
+	Body     string                `json:"body"`
+	Event    string                `json:"event"`
+	Comments []createReviewComment `json:"comments,omitempty"`
+}
+
+type createReviewComment struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Side string `json:"side"`
+	Body string `json:"body"`
+}
+
+type updateReviewRequest struct {
+	Body string `json:"body"`
+}
+
+// PostReview posts the review on pr, or updates the review an earlier run posted
+func PostReview(pr *PullRequest, review Review) error {
+	if pr == nil {
+		return fmt.Errorf("no PR loaded; posting a review requires --pr")
+	}
+
+	return newGitHubClient(pr.APIURL).postReview(pr.Owner, pr.Repo, pr.Number, review)
+}
+
+// postReview creates a review with inline comments, or updates the linter's previous review.
+// A submitted review cannot take new inline comments, so on update comments that still apply
+// are kept, stale ones are deleted and findings without a comment are listed in the body.
+func (c *githubClient) postReview(owner, name string, prNum int, review Review) error {
+	if c.token == "" {
+		return fmt.Errorf("posting a review requires GITHUB_TOKEN")
+	}
+
+	previous, err := c.findOwnReview(owner, name, prNum)
+	if err != nil {
+		return fmt.Errorf("failed to list PR reviews: %w", err)
+	}
+
+	if previous == nil {
+		prInfo, err := c.fetchPRInfo(owner, name, prNum)
+		if err != nil {
+			return fmt.Errorf("failed to fetch PR head: %w", err)
+		}
+
+		req := createReviewRequest{
+			CommitID: prInfo.Head.SHA,
+			Body:     reviewBody(review.Summary, review.Outside),
+			Event:    "COMMENT",
+		}
+		for _, comment := range review.Comments {
+			req.Comments = append(req.Comments, createReviewComment{
+				Path: comment.Path,
+				Line: comment.Line,
+				Side: "RIGHT",
+				Body: comment.Body,
+			})
+		}
+
+		url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/reviews", c.baseURL, owner, name, prNum)
+		if _, err := c.requestJSON(http.MethodPost, url, req, nil); err != nil {
+			return fmt.Errorf("failed to create review: %w", err)
+		}
+
+		log.Printf("✓ Posted review on PR #%d with %d inline comment(s)", prNum, len(req.Comments))
+		return nil
+	}
+
+	existing, err := c.fetchReviewComments(owner, name, prNum, previous.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list review comments: %w", err)
+	}
+
+	wanted := make(map[ReviewComment]bool, len(review.Comments))
+	for _, comment := range review.Comments {
+		wanted[comment] = true
+	}
+
+	kept := make(map[ReviewComment]bool)
+	for _, comment := range existing {
+		if comment.Line != nil {
+			key := ReviewComment{Path: comment.Path, Line: *comment.Line, Body: comment.Body}
+			if wanted[key] && !kept[key] {
+				kept[key] = true
+				continue
+			}
+		}
+
+		url := fmt.Sprintf("%s/repos/%s/%s/pulls/comments/%d", c.baseURL, owner, name, comment.ID)
+		if _, err := c.requestJSON(http.MethodDelete, url, nil, nil); err != nil {
+			return fmt.Errorf("failed to delete stale review comment: %w", err)
+		}
+	}
+
+	outside := append([]ReviewComment(nil), review.Outside...)
+	for _, comment := range review.Comments {
+		if !kept[comment] {
+			outside = append(outside, comment)
+		}
+	}
+
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/reviews/%d", c.baseURL, owner, name, prNum, previous.ID)
+	if _, err := c.requestJSON(http.MethodPut, url, updateReviewRequest{Body: reviewBody(review.Summary, outside)}, nil); err != nil {
+		return fmt.Errorf("failed to update review: %w", err)
+	}
+
+	log.Printf("✓ Updated review on PR #%d: kept %d inline comment(s), removed %d", prNum, len(kept), len(existing)-len(kept))
+	return nil
+}
+
+// findOwnReview returns the most recent review carrying reviewMarker posted by the user of the
+// token, or nil. Reviews of other users are never touched, even if they quote the marker.
+func (c *githubClient) findOwnReview(owner, name string, prNum int) (*pullReview, error) {
+	login, err := c.fetchLogin()
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/reviews?per_page=%d", c.baseURL, owner, name, prNum, githubPerPage)
+
+	var own *pullReview
+	for url != "" {
+		var page []pullReview
+		next, err := c.getJSON(url, &page)
+		if err != nil {
+			return nil, err
+		}
+		for i := range page {
+			if strings.EqualFold(page[i].User.Login, login) && strings.Contains(page[i].Body, reviewMarker) {
+				own = &page[i]
+			}
+		}
+		url = next
+	}
+
+	return own, nil
+}
+
+// fetchLogin returns the login of the user the token belongs to. The GITHUB_TOKEN of a GitHub
+// Actions workflow may not read it, so in a workflow a failure falls back to githubActionsLogin.
+func (c *githubClient) fetchLogin() (string, error) {
+	var user githubUser
+	if _, err := c.getJSON(c.baseURL+"/user", &user); err != nil {
+		if os.Getenv("GITHUB_ACTIONS") == "true" {
+			return githubActionsLogin, nil
+		}
+		return "", fmt.Errorf("failed to fetch the user of GITHUB_TOKEN: %w", err)
+	}
+	return user.Login, nil
+}
+
+// fetchReviewComments lists the inline comments of a review
+func (c *githubClient) fetchReviewComments(owner, name string, prNum int, reviewID int64) ([]pullReviewComment, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/reviews/%d/comments?per_page=%d", c.baseURL, owner, name, prNum, reviewID, githubPerPage)
+
+	var comments []pullReviewComment
+	for url != "" {
+		var page []pullReviewComment
+		next, err := c.getJSON(url, &page)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, page...)
+		url = next
+	}
+
+	return comments, nil
+}
+
+// reviewBody renders the review summary followed by the findings that have no inline comment
+func reviewBody(summary string, outside []ReviewComment) string {
+	var b strings.Builder
+	b.WriteString(reviewMarker + "\n")
+	b.WriteString(summary)
+
+	if len(outside) > 0 {
+		sorted := append([]ReviewComment(nil), outside...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			if sorted[i].Path != sorted[j].Path {
+				return sorted[i].Path < sorted[j].Path
+			}
+			return sorted[i].Line < sorted[j].Line
+		})
+
+		b.WriteString("\n\nFindings on lines without an inline comment:\n")
+		for _, comment := range sorted {
+			fmt.Fprintf(&b, "\n- `%s:%d`: %s", comment.Path, comment.Line, comment.Body)
+		}
+	}
+
+	return b.String()
+}
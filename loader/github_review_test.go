@@ -0,0 +1,181 @@
+package loader
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const reviewsPath = "/repos/hashicorp/terraform-provider-azurerm/pulls/42/reviews"
+
+// handleUser serves the user of the token as login
+func handleUser(mux *http.ServeMux, login string) {
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(githubUser{Login: login})
+	})
+}
+
+func testReview() Review {
+	return Review{
+		Summary: "azurerm-linter found 3 issue(s) in this PR.",
+		Comments: []ReviewComment{
+			{Path: "internal/services/cdn/a.go", Line: 10, Body: "**AZBP001**: first"},
+			{Path: "internal/services/cdn/a.go", Line: 20, Body: "**AZBP002**: second"},
+		},
+		Outside: []ReviewComment{
+			{Path: "internal/services/cdn/b.go", Line: 5, Body: "**AZNR001**: outside"},
+		},
+	}
+}
+
+func TestPostReviewCreatesReviewWithInlineComments(t *testing.T) {
+	var created createReviewRequest
+	mux := http.NewServeMux()
+	handleUser(mux, "linter-bot")
+	mux.HandleFunc("/repos/hashicorp/terraform-provider-azurerm/pulls/42", func(w http.ResponseWriter, r *http.Request) {
+		info := PRInfo{Number: 42}
+		info.Head.SHA = "def456"
+		_ = json.NewEncoder(w).Encode(info)
+	})
+	mux.HandleFunc(reviewsPath, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode([]pullReview{
+				{ID: 1, Body: "LGTM", User: githubUser{Login: "linter-bot"}},
+				{ID: 3, Body: "Quoting the linter: " + reviewMarker, User: githubUser{Login: "maintainer"}},
+			})
+		case http.MethodPost:
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				t.Errorf("Authorization = %q, want the GITHUB_TOKEN", r.Header.Get("Authorization"))
+			}
+			if err := json.NewDecoder(r.Body).Decode(&created); err != nil {
+				t.Errorf("decoding review: %v", err)
+			}
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{"id": 2}`))
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	client, _ := newTestGitHubClient(t, mux)
+	client.token = "secret"
+
+	if err := client.postReview("hashicorp", "terraform-provider-azurerm", 42, testReview()); err != nil {
+		t.Fatalf("postReview() error = %v", err)
+	}
+
+	if created.CommitID != "def456" || created.Event != "COMMENT" {
+		t.Fatalf("review = %+v, want a COMMENT review on the PR head", created)
+	}
+	if len(created.Comments) != 2 || created.Comments[1].Line != 20 || created.Comments[1].Side != "RIGHT" {
+		t.Fatalf("comments = %+v, want both inline findings", created.Comments)
+	}
+	if !strings.Contains(created.Body, reviewMarker) || !strings.Contains(created.Body, "`internal/services/cdn/b.go:5`: **AZNR001**: outside") {
+		t.Fatalf("body = %q, want the marker and the finding outside the diff", created.Body)
+	}
+}
+
+func TestPostReviewUpdatesPreviousReview(t *testing.T) {
+	line10, line30 := 10, 30
+	var updated updateReviewRequest
+	var deleted []string
+
+	mux := http.NewServeMux()
+	handleUser(mux, "linter-bot")
+	mux.HandleFunc(reviewsPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("unexpected %s %s, want the previous review to be updated", r.Method, r.URL.Path)
+		}
+		_ = json.NewEncoder(w).Encode([]pullReview{
+			{ID: 7, Body: reviewMarker + "\nold summary", User: githubUser{Login: "Linter-Bot"}},
+			{ID: 8, Body: "> " + reviewMarker + "\nquoted by a maintainer", User: githubUser{Login: "maintainer"}},
+		})
+	})
+	mux.HandleFunc(reviewsPath+"/7/comments", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]pullReviewComment{
+			{ID: 100, Path: "internal/services/cdn/a.go", Line: &line10, Body: "**AZBP001**: first"},
+			{ID: 101, Path: "internal/services/cdn/a.go", Line: &line30, Body: "**AZBP009**: fixed since"},
+			{ID: 102, Path: "internal/services/cdn/a.go", Line: nil, Body: "**AZBP002**: second"},
+		})
+	})
+	mux.HandleFunc(reviewsPath+"/7", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&updated); err != nil {
+			t.Errorf("decoding review: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"id": 7}`))
+	})
+	mux.HandleFunc("/repos/hashicorp/terraform-provider-azurerm/pulls/comments/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+		deleted = append(deleted, strings.TrimPrefix(r.URL.Path, "/repos/hashicorp/terraform-provider-azurerm/pulls/comments/"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	client, _ := newTestGitHubClient(t, mux)
+	client.token = "secret"
+
+	if err := client.postReview("hashicorp", "terraform-provider-azurerm", 42, testReview()); err != nil {
+		t.Fatalf("postReview() error = %v", err)
+	}
+
+	if fmt.Sprint(deleted) != "[101 102]" {
+		t.Fatalf("deleted comments = %v, want the stale and the outdated comment", deleted)
+	}
+	for _, want := range []string{
+		reviewMarker,
+		"`internal/services/cdn/a.go:20`: **AZBP002**: second",
+		"`internal/services/cdn/b.go:5`: **AZNR001**: outside",
+	} {
+		if !strings.Contains(updated.Body, want) {
+			t.Fatalf("updated body = %q, want it to contain %q", updated.Body, want)
+		}
+	}
+	if strings.Contains(updated.Body, "AZBP001") {
+		t.Fatalf("updated body = %q, finding with a kept inline comment should not be repeated", updated.Body)
+	}
+}
+
+func TestFindOwnReviewFallsBackToGitHubActionsUser(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Resource not accessible by integration"}`, http.StatusForbidden)
+	})
+	mux.HandleFunc(reviewsPath, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]pullReview{
+			{ID: 7, Body: reviewMarker, User: githubUser{Login: githubActionsLogin}},
+			{ID: 8, Body: reviewMarker, User: githubUser{Login: "maintainer"}},
+		})
+	})
+
+	client, _ := newTestGitHubClient(t, mux)
+	client.token = "secret"
+
+	t.Setenv("GITHUB_ACTIONS", "")
+	if _, err := client.findOwnReview("hashicorp", "terraform-provider-azurerm", 42); err == nil {
+		t.Fatalf("findOwnReview() error = nil outside GitHub Actions, want the /user error")
+	}
+
+	t.Setenv("GITHUB_ACTIONS", "true")
+	review, err := client.findOwnReview("hashicorp", "terraform-provider-azurerm", 42)
+	if err != nil {
+		t.Fatalf("findOwnReview() error = %v", err)
+	}
+	if review == nil || review.ID != 7 {
+		t.Fatalf("findOwnReview() = %+v, want the review of %s", review, githubActionsLogin)
+	}
+}
+
+func TestPostReviewRequiresToken(t *testing.T) {
+	client, _ := newTestGitHubClient(t, http.NotFoundHandler())
+
+	if err := client.postReview("hashicorp", "terraform-provider-azurerm", 42, testReview()); err == nil {
+		t.Fatalf("postReview() error = nil, want missing GITHUB_TOKEN error")
+	}
+}
@@ -0,0 +1,248 @@
+package loader
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// patchStartRegex matches the line starting each patch of git format-patch output
+var patchStartRegex = regexp.MustCompile(`(?m)^From [0-9a-f]{40} `)
+
+// parsePatchSeries parses a diff, or the patches of a git format-patch mbox one after another.
+// The changes of each patch are merged into those of the patches before it, so the ChangeSet
+// describes the combined change with line numbers of the final files.
+func (cs *ChangeSet) parsePatchSeries(content string) error {
+	starts := patchStartRegex.FindAllStringIndex(content, -1)
+	if len(starts) < 2 {
+		return cs.parseDiffOutput(content)
+	}
+
+	for i, start := range starts {
+		end := len(content)
+		if i < len(starts)-1 {
+			end = starts[i+1][0]
+		}
+
+		patch := NewChangeSet()
+		if err := patch.parseDiffOutput(content[start[0]:end]); err != nil {
+			var hunkErr *HunkError
+			if errors.As(err, &hunkErr) {
+				hunkErr.Line += strings.Count(content[:start[0]], "\n")
+			}
+			return err
+		}
+		cs.merge(patch)
+	}
+	return nil
+}
+
+// merge applies the changes of next, a patch made on top of the changes of cs
+func (cs *ChangeSet) merge(next *ChangeSet) {
+	paths := make([]string, 0, len(next.files))
+	for path := range next.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	// Take the earlier changes of every file next touches, from its old path for renames,
+	// before storing any of them, so files swapping names are not mixed up
+	type earlier struct {
+		change FileChange
+		lines  map[int]bool
+		hunks  []Hunk
+	}
+	previous := make(map[string]earlier)
+	for _, path := range paths {
+		from := path
+		if change := next.files[path]; change.Status == FileRenamed {
+			from = change.OldPath
+		}
+		if prev, ok := cs.files[from]; ok {
+			previous[path] = earlier{change: prev, lines: cs.changedLines[from], hunks: cs.hunks[from]}
+		}
+	}
+	for _, path := range paths {
+		if prev, ok := previous[path]; ok {
+			cs.forget(prev.change.Path)
+		}
+	}
+
+	for _, path := range paths {
+		change := next.files[path]
+		prev, had := previous[path]
+
+		// Line numbers of earlier changes move with the lines next adds and removes
+		if had && change.Status != FileDeleted && change.Status != FileAdded {
+			mapLine := newLineMapper(next.hunks[path])
+			merged := make(map[int]bool, len(prev.lines)+len(next.changedLines[path]))
+			for line := range prev.lines {
+				if newLine, ok := mapLine(line); ok {
+					merged[newLine] = true
+				}
+			}
+			for line := range next.changedLines[path] {
+				merged[line] = true
+			}
+			cs.changedLines[path] = merged
+			cs.hunks[path] = mergeHunks(prev.hunks, next.hunks[path], mapLine)
+		} else if lines, ok := next.changedLines[path]; ok {
+			cs.changedLines[path] = lines
+			cs.hunks[path] = next.hunks[path]
+		}
+
+		merged := change
+		if had {
+			merged = mergeFileChange(prev.change, change)
+		}
+		if merged.Status == "" {
+			// Added by an earlier patch and deleted by this one
+			delete(cs.changedLines, path)
+			delete(cs.hunks, path)
+			continue
+		}
+		cs.recordFile(merged)
+	}
+}
+
+// forget removes the changes of path
+func (cs *ChangeSet) forget(path string) {
+	delete(cs.files, path)
+	delete(cs.changedFiles, path)
+	delete(cs.newFiles, path)
+	delete(cs.changedLines, path)
+	delete(cs.hunks, path)
+}
+
+// mergeFileChange combines the change of a file by an earlier patch with that of a later one.
+// It returns a change without status for a file the earlier patch added and the later one deleted.
+func mergeFileChange(prev, next FileChange) FileChange {
+	merged := next
+	merged.Binary = prev.Binary || next.Binary
+
+	switch {
+	case next.Status == FileDeleted:
+		if prev.Status == FileAdded || prev.Status == FileCopied {
+			return FileChange{}
+		}
+		if prev.Status == FileRenamed {
+			merged.Path = prev.OldPath
+		}
+		merged.OldPath = ""
+	case prev.Status == FileAdded || prev.Status == FileCopied:
+		merged.Status = prev.Status
+		merged.OldPath = prev.OldPath
+		merged.Similarity = prev.Similarity
+	case prev.Status == FileRenamed:
+		merged.Status = FileRenamed
+		merged.OldPath = prev.OldPath
+		merged.Similarity = prev.Similarity
+		if next.Status == FileRenamed && next.Similarity < merged.Similarity {
+			merged.Similarity = next.Similarity
+		} else if next.Status != FileRenamed {
+			// The rename is no longer pure, and its new similarity is unknown
+			merged.Similarity = 0
+		}
+	case prev.Status == FileModified && next.Status == FileRenamed:
+		// Renamed after being edited, with an unknown similarity to the original
+		merged.Similarity = 0
+	case prev.Status == FileDeleted:
+		// Deleted and added back
+		merged.Status = FileModified
+	}
+	return merged
+}
+
+// newLineMapper returns a function that maps a line of a file before hunks were applied to its
+// line after. It reports false for lines the hunks remove.
+func newLineMapper(hunks []Hunk) func(line int) (int, bool) {
+	return func(line int) (int, bool) {
+		delta := 0
+		for _, hunk := range hunks {
+			if hunk.OldCount == 0 {
+				// Lines inserted after OldStart
+				if line <= hunk.OldStart {
+					break
+				}
+				delta += hunk.NewCount
+				continue
+			}
+			if line < hunk.OldStart {
+				break
+			}
+			if line >= hunk.OldStart+hunk.OldCount {
+				delta += hunk.NewCount - hunk.OldCount
+				continue
+			}
+			if hunk.DeletedOldLines[line] {
+				return 0, false
+			}
+
+			// The context lines of a hunk appear in the same order before and after it
+			kept := 0
+			for old := hunk.OldStart; old < line; old++ {
+				if !hunk.DeletedOldLines[old] {
+					kept++
+				}
+			}
+			context := sortedLines(hunk.ContextNewLines)
+			if kept >= len(context) {
+				return 0, false
+			}
+			return context[kept], true
+		}
+		return line + delta, true
+	}
+}
+
+// mergeHunks moves the new lines of hunks through mapLine and adds the hunks of next. Old lines
+// keep referring to the file before the first patch, and hunks of next to the file before next.
+func mergeHunks(hunks, next []Hunk, mapLine func(int) (int, bool)) []Hunk {
+	var result []Hunk
+	for _, hunk := range hunks {
+		moved := newHunk(hunk.OldStart, hunk.OldCount, 0, 0)
+		moved.DeletedOldLines = hunk.DeletedOldLines
+		first, last := 0, -1
+		for line := hunk.NewStart; line < hunk.NewStart+hunk.NewCount; line++ {
+			newLine, ok := mapLine(line)
+			if !ok {
+				continue
+			}
+			if hunk.AddedNewLines[line] {
+				moved.AddedNewLines[newLine] = true
+			}
+			if hunk.ContextNewLines[line] {
+				moved.ContextNewLines[newLine] = true
+			}
+			if last < first {
+				first = newLine
+			}
+			last = newLine
+		}
+		if last < first {
+			// Every line of the hunk was removed
+			if len(hunk.DeletedOldLines) == 0 {
+				continue
+			}
+			first, last = 0, -1
+		}
+		moved.NewStart, moved.NewCount = first, last-first+1
+		result = append(result, moved)
+	}
+
+	result = append(result, next...)
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].NewStart < result[j].NewStart
+	})
+	return result
+}
+
+func sortedLines(lines map[int]bool) []int {
+	result := make([]int, 0, len(lines))
+	for line := range lines {
+		result = append(result, line)
+	}
+	sort.Ints(result)
+	return result
+}
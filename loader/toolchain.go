@@ -0,0 +1,99 @@
+package loader
+
+import (
+	"fmt"
+	"go/version"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"golang.org/x/mod/modfile"
+)
+
+// GoVersionError reports a module whose go directive requires a newer Go language version than
+// the linter was built with. The go/types package compiled into the linter cannot type check
+// code written for a newer language version.
+type GoVersionError struct {
+	GoMod    string // path of the go.mod file
+	Required string // Go version required by the go directive, e.g. go1.26.0
+	Built    string // Go version the linter was built with
+}
+
+func (e *GoVersionError) Error() string {
+	return fmt.Sprintf("%s requires %s (go directive), but azurerm-linter was built with %s and cannot type check it. Rebuild the linter with:\n\n  GOTOOLCHAIN=%s go install github.com/qixialu/azurerm-linter@latest",
+		e.GoMod, e.Required, e.Built, installToolchain(e.Required))
+}
+
+// CheckGoVersion checks that the linter can analyze the module containing dir, whose go.mod
+// may require a newer Go than the linter was built with. Without a go.mod, or for development
+// builds of Go, there is nothing to check.
+func CheckGoVersion(dir string) error {
+	return checkGoVersion(dir, runtime.Version())
+}
+
+func checkGoVersion(dir, built string) error {
+	if !version.IsValid(built) {
+		return nil
+	}
+
+	path := findGoMod(dir)
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	// Lax parsing skips the toolchain directive; it is only the fallback for directives this
+	// build of x/mod does not know yet
+	f, err := modfile.Parse(path, data, nil)
+	if err != nil {
+		f, err = modfile.ParseLax(path, data, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	newer := func(required string) bool {
+		return version.IsValid(required) && version.Compare(version.Lang(required), version.Lang(built)) > 0
+	}
+	if f.Go != nil && newer("go"+f.Go.Version) {
+		return &GoVersionError{GoMod: path, Required: "go" + f.Go.Version, Built: built}
+	}
+	// The toolchain directive only recommends a toolchain; the language version of the code
+	// is set by the go directive
+	if f.Toolchain != nil && newer(f.Toolchain.Name) {
+		log.Printf("Warning: %s recommends %s (toolchain directive), but azurerm-linter was built with %s",
+			path, f.Toolchain.Name, built)
+	}
+	return nil
+}
+
+// findGoMod returns the go.mod file of the module containing dir, or "" when there is none
+func findGoMod(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, "go.mod")
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// installToolchain returns the toolchain to install a Go version with. Since Go 1.21 a go
+// directive such as "go 1.26" names a language version; its first release is go1.26.0.
+func installToolchain(v string) string {
+	if version.Lang(v) == v && version.Compare(v, "go1.21") >= 0 {
+		return v + ".0"
+	}
+	return v
+}
@@ -0,0 +1,66 @@
+package loader
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckGoVersion(t *testing.T) {
+	tests := []struct {
+		name         string
+		goMod        string
+		built        string
+		wantRequired string
+	}{
+		{name: "same language version", goMod: "module m\n\ngo 1.25.9\n", built: "go1.25.3"},
+		{name: "older language version", goMod: "module m\n\ngo 1.24.0\n", built: "go1.25.3"},
+		{name: "newer go directive", goMod: "module m\n\ngo 1.26.0\n", built: "go1.25.3", wantRequired: "go1.26.0"},
+		{name: "newer toolchain directive", goMod: "module m\n\ngo 1.25.0\n\ntoolchain go1.26.1\n", built: "go1.25.3"},
+		{name: "newer go and toolchain directives", goMod: "module m\n\ngo 1.26.0\n\ntoolchain go1.26.1\n", built: "go1.25.3", wantRequired: "go1.26.0"},
+		{name: "development build", goMod: "module m\n\ngo 1.26.0\n", built: "devel go1.27-abcdef"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(tt.goMod), 0o600); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+			sub := filepath.Join(dir, "internal", "services")
+			if err := os.MkdirAll(sub, 0o755); err != nil {
+				t.Fatalf("MkdirAll() error = %v", err)
+			}
+
+			err := checkGoVersion(sub, tt.built)
+			if tt.wantRequired == "" {
+				if err != nil {
+					t.Fatalf("checkGoVersion() error = %v, want nil", err)
+				}
+				return
+			}
+
+			var versionErr *GoVersionError
+			if !errors.As(err, &versionErr) {
+				t.Fatalf("checkGoVersion() error = %v, want GoVersionError", err)
+			}
+			if versionErr.Required != tt.wantRequired || versionErr.GoMod != filepath.Join(dir, "go.mod") {
+				t.Fatalf("checkGoVersion() error = %+v, want go directive requiring %s", versionErr, tt.wantRequired)
+			}
+			if !strings.Contains(err.Error(), "GOTOOLCHAIN="+tt.wantRequired+" go install github.com/qixialu/azurerm-linter@latest") {
+				t.Fatalf("Error() = %q, want the go install command", err.Error())
+			}
+		})
+	}
+}
+
+func TestInstallToolchainNamesFirstRelease(t *testing.T) {
+	if got := installToolchain("go1.26"); got != "go1.26.0" {
+		t.Fatalf("installToolchain(go1.26) = %q, want go1.26.0", got)
+	}
+	if got := installToolchain("go1.26.2"); got != "go1.26.2" {
+		t.Fatalf("installToolchain(go1.26.2) = %q, want go1.26.2", got)
+	}
+}
@@ -15,38 +15,55 @@ type WorktreeLoader struct {
 	prNumber     int
 	owner        string
 	repo         string
+	apiURL       string
 	remoteName   string
 	worktreePath string
 	baseBranch   string
 }
 
-// NewWorktreeLoader creates a new WorktreeLoader
-func NewWorktreeLoader(prNumber int, remoteName, baseBranch string) *WorktreeLoader {
-	owner, repo := getRepoInfo()
+// NewWorktreeLoader creates a new WorktreeLoader. An empty owner/repo or apiURL is
+// auto-detected from the URL of the selected git remote.
+func NewWorktreeLoader(prNumber int, remoteName, baseBranch, owner, repo, apiURL string) *WorktreeLoader {
 	return &WorktreeLoader{
 		prNumber:   prNumber,
 		remoteName: remoteName,
 		baseBranch: baseBranch,
 		owner:      owner,
 		repo:       repo,
+		apiURL:     apiURL,
 	}
 }
 
-// detectRemoteForPR detects the appropriate remote for fetching PR
+// detectRemoteForPR detects the appropriate remote for fetching PR and fills in the
+// repository and API URL that were not given from the remote URL
 // Priority: user-specified remote flag > upstream > origin
 func (l *WorktreeLoader) detectRemoteForPR() (string, error) {
-	// Check if user specified remote
-	if l.remoteName != "" {
-		log.Printf("Using user-specified remote: %s", l.remoteName)
-		return l.remoteName, nil
-	}
-
-	// Auto-detect: prefer upstream, fallback to origin
 	repo, err := git.PlainOpen(".")
 	if err != nil {
 		return "", fmt.Errorf("failed to open repository: %w", err)
 	}
 
+	remoteName, err := selectRemote(repo, l.remoteName)
+	if err != nil {
+		return "", err
+	}
+
+	if err := l.detectRepository(repo, remoteName); err != nil {
+		return "", err
+	}
+
+	return remoteName, nil
+}
+
+// selectRemote returns the user-specified remote, or auto-detects one preferring upstream over origin
+func selectRemote(repo *git.Repository, remoteName string) (string, error) {
+	// Check if user specified remote
+	if remoteName != "" {
+		log.Printf("Using user-specified remote: %s", remoteName)
+		return remoteName, nil
+	}
+
+	// Auto-detect: prefer upstream, fallback to origin
 	remotes, err := repo.Remotes()
 	if err != nil {
 		return "", fmt.Errorf("failed to list remotes: %w", err)
@@ -77,6 +94,43 @@ func (l *WorktreeLoader) detectRemoteForPR() (string, error) {
 	return "", fmt.Errorf("no suitable remote found (origin or upstream)")
 }
 
+// detectRepository fills in the owner/repo and API URL that were not configured from the URL of
+// the remote, falling back to the public hashicorp/terraform-provider-azurerm repository.
+// A remote on a host other than github.com requires a configured API URL.
+func (l *WorktreeLoader) detectRepository(repo *git.Repository, remoteName string) error {
+	if l.owner != "" && l.apiURL != "" {
+		return nil
+	}
+
+	var host, owner, name string
+	var ok bool
+	if remote, err := repo.Remote(remoteName); err == nil && len(remote.Config().URLs) > 0 {
+		host, owner, name, ok = parseRemoteURL(remote.Config().URLs[0])
+	}
+
+	if l.apiURL == "" {
+		l.apiURL = defaultGitHubAPIURL
+		if ok {
+			apiURL, err := apiURLForHost(host)
+			if err != nil {
+				return fmt.Errorf("remote '%s': %w", remoteName, err)
+			}
+			l.apiURL = apiURL
+		}
+	}
+
+	if l.owner == "" {
+		if ok {
+			l.owner, l.repo = owner, name
+			log.Printf("Auto-detected repository from remote '%s': %s/%s", remoteName, owner, name)
+		} else {
+			l.owner, l.repo = defaultRepoOwner, defaultRepoName
+			log.Printf("Could not detect repository from remote '%s', using %s/%s", remoteName, l.owner, l.repo)
+		}
+	}
+	return nil
+}
+
 func (l *WorktreeLoader) fetchPRRefArgs() []string {
 	return []string{"fetch", l.remoteName, fmt.Sprintf("refs/pull/%d/head", l.prNumber)}
 }
@@ -88,14 +142,12 @@ func (l *WorktreeLoader) Setup() (string, error) {
 		return "", fmt.Errorf("not in a git repository. Please run this tool from the terraform-provider-azurerm directory")
 	}
 
-	// 1. Detect which remote to use (if not specified)
-	if l.remoteName == "" {
-		remote, err := l.detectRemoteForPR()
-		if err != nil {
-			return "", fmt.Errorf("failed to detect remote: %w. Please ensure you're in the terraform-provider-azurerm repository with correct remote configured", err)
-		}
-		l.remoteName = remote
+	// 1. Detect which remote and repository to use (if not specified)
+	remote, err := l.detectRemoteForPR()
+	if err != nil {
+		return "", fmt.Errorf("failed to detect remote: %w. Please ensure you're in the terraform-provider-azurerm repository with correct remote configured", err)
 	}
+	l.remoteName = remote
 
 	// 2. Get PR details to find the base branch (if not specified)
 	if l.baseBranch == "" {
@@ -132,12 +184,21 @@ func (l *WorktreeLoader) Setup() (string, error) {
 	}
 
 	log.Printf("✓ Worktree created at %s", l.worktreePath)
+
+	// 6. Fail before loading packages when the PR needs a newer Go than the linter was built with
+	if err := CheckGoVersion(l.worktreePath); err != nil {
+		if cleanupErr := l.Cleanup(); cleanupErr != nil {
+			log.Printf("Warning: failed to cleanup worktree: %v", cleanupErr)
+		}
+		return "", err
+	}
+
 	return l.worktreePath, nil
 }
 
 // fetchPRDetails fetches PR information from GitHub API to get base branch
 func (l *WorktreeLoader) fetchPRDetails() error {
-	prInfo, err := fetchPRInfo(os.Getenv("GITHUB_TOKEN"), l.owner, l.repo, l.prNumber)
+	prInfo, err := newGitHubClient(l.apiURL).fetchPRInfo(l.owner, l.repo, l.prNumber)
 	if err != nil {
 		return err
 	}
@@ -147,6 +208,11 @@ func (l *WorktreeLoader) fetchPRDetails() error {
 	return nil
 }
 
+// GetRepository returns the owner, name and GitHub API URL of the repository the PR belongs to
+func (l *WorktreeLoader) GetRepository() (owner, repo, apiURL string) {
+	return l.owner, l.repo, l.apiURL
+}
+
 // GetWorktreePath returns the path to the worktree
 func (l *WorktreeLoader) GetWorktreePath() string {
 	return l.worktreePath
@@ -3,6 +3,9 @@ package loader
 import (
 	"strings"
 	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
 )
 
 func TestFetchPRRefArgsAvoidsShallowFetch(t *testing.T) {
@@ -19,3 +22,53 @@ func TestFetchPRRefArgsAvoidsShallowFetch(t *testing.T) {
 		}
 	}
 }
+
+func TestDetectRepositoryFromRemote(t *testing.T) {
+	repo, err := git.PlainInit(t.TempDir(), false)
+	if err != nil {
+		t.Fatalf("PlainInit() error = %v", err)
+	}
+	if _, err := repo.CreateRemote(&config.RemoteConfig{Name: "upstream", URLs: []string{"git@github.example.com:platform/terraform-provider-azurerm.git"}}); err != nil {
+		t.Fatalf("CreateRemote() error = %v", err)
+	}
+
+	if _, err := repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{"https://github.com/myorg/terraform-provider-azurerm.git"}}); err != nil {
+		t.Fatalf("CreateRemote() error = %v", err)
+	}
+
+	loader := &WorktreeLoader{prNumber: 123}
+	if err := loader.detectRepository(repo, "origin"); err != nil {
+		t.Fatalf("detectRepository() error = %v", err)
+	}
+
+	owner, name, apiURL := loader.GetRepository()
+	if owner != "myorg" || name != "terraform-provider-azurerm" || apiURL != "https://api.github.com" {
+		t.Fatalf("GetRepository() = %q, %q, %q, want the github.com repository from the remote", owner, name, apiURL)
+	}
+
+	enterprise := &WorktreeLoader{prNumber: 123, apiURL: "https://github.example.com/api/v3"}
+	if err := enterprise.detectRepository(repo, "upstream"); err != nil {
+		t.Fatalf("detectRepository() error = %v", err)
+	}
+
+	owner, name, apiURL = enterprise.GetRepository()
+	if owner != "platform" || name != "terraform-provider-azurerm" || apiURL != "https://github.example.com/api/v3" {
+		t.Fatalf("GetRepository() = %q, %q, %q, want the repository from the remote and the configured API URL", owner, name, apiURL)
+	}
+}
+
+func TestDetectRepositoryRejectsRemotesOutsideGitHub(t *testing.T) {
+	repo, err := git.PlainInit(t.TempDir(), false)
+	if err != nil {
+		t.Fatalf("PlainInit() error = %v", err)
+	}
+	if _, err := repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{"git@gitlab.com:myorg/terraform-provider-azurerm.git"}}); err != nil {
+		t.Fatalf("CreateRemote() error = %v", err)
+	}
+
+	loader := &WorktreeLoader{prNumber: 123, owner: "myorg", repo: "mirror"}
+	err = loader.detectRepository(repo, "origin")
+	if err == nil || !strings.Contains(err.Error(), "not a GitHub repository") {
+		t.Fatalf("detectRepository() error = %v, want a not a GitHub repository error", err)
+	}
+}
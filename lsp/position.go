@@ -0,0 +1,99 @@
+package lsp
+
+import (
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// mapper converts byte positions in a file to LSP positions, which count UTF-16 code units
+type mapper struct {
+	content    []byte
+	lineStarts []int
+}
+
+func newMapper(content []byte) *mapper {
+	m := &mapper{content: content, lineStarts: []int{0}}
+	for i, b := range content {
+		if b == '\n' {
+			m.lineStarts = append(m.lineStarts, i+1)
+		}
+	}
+	return m
+}
+
+// offsetPosition converts a byte offset
+func (m *mapper) offsetPosition(offset int) position {
+	offset = max(0, min(offset, len(m.content)))
+
+	line := 0
+	for line+1 < len(m.lineStarts) && m.lineStarts[line+1] <= offset {
+		line++
+	}
+	return position{Line: line, Character: utf16Len(m.content[m.lineStarts[line]:offset])}
+}
+
+// linePosition converts a 1-based line and 1-based byte column, as reported by go/token
+func (m *mapper) linePosition(line, column int) position {
+	if line < 1 || line > len(m.lineStarts) {
+		return position{Line: max(line-1, 0)}
+	}
+	return m.offsetPosition(m.lineStarts[line-1] + max(column-1, 0))
+}
+
+// lineEnd returns the position at the end of a 1-based line, before its line break
+func (m *mapper) lineEnd(line int) position {
+	if line < 1 || line > len(m.lineStarts) {
+		return position{Line: max(line-1, 0)}
+	}
+
+	end := len(m.content)
+	if line < len(m.lineStarts) {
+		end = m.lineStarts[line] - 1
+	}
+	if end > m.lineStarts[line-1] && m.content[end-1] == '\r' {
+		end--
+	}
+	return m.offsetPosition(end)
+}
+
+func utf16Len(b []byte) int {
+	n := 0
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		if utf16.RuneLen(r) == 2 {
+			n += 2
+		} else {
+			n++
+		}
+		b = b[size:]
+	}
+	return n
+}
+
+// uriToPath converts a file URI to a file path
+func uriToPath(uri string) (string, bool) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return "", false
+	}
+
+	path := u.Path
+	// file:///C:/dir/file.go has the path /C:/dir/file.go
+	if runtime.GOOS == "windows" && len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	return filepath.Clean(filepath.FromSlash(path)), true
+}
+
+// pathToURI converts an absolute file path to a file URI
+func pathToURI(path string) string {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
@@ -0,0 +1,616 @@
+// Package lsp serves the AzureRM provider checks to editors over the Language Server Protocol.
+//
+// The server analyzes the package of each opened or saved file and publishes the findings as
+// diagnostics, with the suggested fixes offered as quick fix code actions. Loaded packages and
+// their findings are cached until a file of the package, or of a package it imports, is saved.
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/qixialu/azurerm-linter/lint"
+	"github.com/qixialu/azurerm-linter/passes"
+	"github.com/qixialu/azurerm-linter/rules"
+	"github.com/qixialu/azurerm-linter/session"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/packages"
+)
+
+const serverName = "azurerm-linter"
+
+// Options configures the language server
+type Options struct {
+	// Analyzers are the checks to run; nil runs passes.AllChecks
+	Analyzers []*analysis.Analyzer
+
+	// CheckConfig holds the settings of the checks and the packages resource analysis skips
+	CheckConfig session.Config
+
+	// RuleURL returns the documentation link of a check; nil publishes no links
+	RuleURL func(checkID string) string
+
+	// Severity returns the severity of the findings of a check; nil publishes warnings
+	Severity func(checkID string) rules.Severity
+
+	// Exclude reports whether findings in filename are dropped; nil keeps all findings.
+	// root is the workspace root.
+	Exclude func(root, filename string) bool
+
+	// Version is reported to the client
+	Version string
+}
+
+// Server is a language server for one client connection
+type Server struct {
+	opts Options
+	conn *conn
+	ctx  context.Context
+
+	// loading allows one package load at a time, as loading with syntax is memory hungry
+	loading chan struct{}
+	wg      sync.WaitGroup
+
+	// loadPackages is replaced in tests to count package loads
+	loadPackages func(ctx context.Context, dir string, patterns ...string) ([]*packages.Package, error)
+
+	mu          sync.Mutex
+	root        string
+	initialized bool
+	shutdown    bool
+	documents   map[string]*document      // open documents by path
+	packages    map[string]*packageResult // analysis results by package directory
+}
+
+type document struct {
+	// dirty is set when the document changed since it was last saved; its findings and
+	// fixes refer to the saved content
+	dirty bool
+}
+
+// packageResult holds the findings of the package in a directory. Its other fields are
+// set once ready is closed.
+type packageResult struct {
+	ready chan struct{}
+
+	files    []string        // source files of the package, which diagnostics are published for
+	deps     map[string]bool // directories of the package and of all packages it imports
+	findings []lint.Finding
+	err      error
+}
+
+// NewServer creates a language server
+func NewServer(opts Options) *Server {
+	if opts.Analyzers == nil {
+		opts.Analyzers = passes.AllChecks
+	}
+	return &Server{
+		opts:         opts,
+		loading:      make(chan struct{}, 1),
+		loadPackages: lint.Load,
+		documents:    make(map[string]*document),
+		packages:     make(map[string]*packageResult),
+	}
+}
+
+// Serve handles LSP messages from r and writes responses and notifications to w until the
+// client sends exit or closes r. It returns an error when the client exits without shutdown.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer func() {
+		cancel()
+		s.wg.Wait()
+	}()
+
+	s.ctx = ctx
+	s.conn = newConn(r, w)
+
+	for {
+		req, err := s.conn.read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		if req.Method == methodExit {
+			s.mu.Lock()
+			shutdown := s.shutdown
+			s.mu.Unlock()
+			if !shutdown {
+				return errors.New("client exited without shutdown")
+			}
+			return nil
+		}
+
+		if err := s.handle(req); err != nil {
+			return err
+		}
+	}
+}
+
+// handle dispatches a request or notification. Only errors writing to the client are returned.
+func (s *Server) handle(req *request) error {
+	s.mu.Lock()
+	initialized, shutdown := s.initialized, s.shutdown
+	s.mu.Unlock()
+
+	isRequest := req.ID != nil
+	switch {
+	case req.Method != methodInitialize && !initialized:
+		if isRequest {
+			return s.conn.replyError(req.ID, codeServerNotInitialized, "server not initialized")
+		}
+		return nil
+	case shutdown:
+		if isRequest {
+			return s.conn.replyError(req.ID, codeInvalidRequest, "server is shutting down")
+		}
+		return nil
+	}
+
+	var result interface{}
+	var err error
+	switch req.Method {
+	case methodInitialize:
+		result, err = s.initialize(req.Params)
+	case methodInitialized:
+	case methodShutdown:
+		s.mu.Lock()
+		s.shutdown = true
+		s.mu.Unlock()
+	case methodDidOpen:
+		err = s.didOpen(req.Params)
+	case methodDidChange:
+		err = s.didChange(req.Params)
+	case methodDidSave:
+		err = s.didSave(req.Params)
+	case methodDidClose:
+		err = s.didClose(req.Params)
+	case methodCodeAction:
+		result, err = s.codeAction(req.Params)
+	default:
+		if isRequest {
+			return s.conn.replyError(req.ID, codeMethodNotFound, "method not supported: "+req.Method)
+		}
+		return nil
+	}
+
+	if !isRequest {
+		if err != nil {
+			log.Printf("Warning: %s: %v", req.Method, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return s.conn.replyError(req.ID, codeInvalidParams, err.Error())
+	}
+	return s.conn.reply(req.ID, result)
+}
+
+func (s *Server) initialize(raw json.RawMessage) (interface{}, error) {
+	var params initializeParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, err
+	}
+
+	root, ok := uriToPath(params.RootURI)
+	if !ok {
+		var err error
+		if root, err = os.Getwd(); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	s.root = root
+	s.initialized = true
+	s.mu.Unlock()
+
+	return initializeResult{
+		Capabilities: serverCapabilities{
+			TextDocumentSync: textDocumentSyncOptions{
+				OpenClose: true,
+				Change:    textDocumentSyncFull,
+				Save:      saveOptions{IncludeText: false},
+			},
+			CodeActionProvider: codeActionOptions{CodeActionKinds: []string{codeActionQuickFix}},
+		},
+		ServerInfo: serverInfo{Name: serverName, Version: s.opts.Version},
+	}, nil
+}
+
+func (s *Server) didOpen(raw json.RawMessage) error {
+	var params didOpenParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return err
+	}
+	path, ok := goFilePath(params.TextDocument.URI)
+	if !ok {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.documents[path] = &document{}
+
+	dir := filepath.Dir(path)
+	result, cached := s.packages[dir]
+	if !cached {
+		s.analyzeLocked(dir)
+		return nil
+	}
+
+	// Publish cached findings right away; a pending analysis publishes when it finishes
+	select {
+	case <-result.ready:
+		if result.err != nil {
+			s.analyzeLocked(dir)
+			return nil
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.publish(result)
+		}()
+	default:
+	}
+	return nil
+}
+
+func (s *Server) didChange(raw json.RawMessage) error {
+	var params didChangeParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return err
+	}
+	path, ok := goFilePath(params.TextDocument.URI)
+	if !ok {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if doc, ok := s.documents[path]; ok {
+		doc.dirty = true
+	}
+	return nil
+}
+
+func (s *Server) didSave(raw json.RawMessage) error {
+	var params didSaveParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return err
+	}
+	path, ok := goFilePath(params.TextDocument.URI)
+	if !ok {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if doc, ok := s.documents[path]; ok {
+		doc.dirty = false
+	}
+
+	// The saved package and every cached package importing it are stale. Reanalyze those
+	// with open documents; the others are analyzed when a file of theirs is opened.
+	dir := filepath.Dir(path)
+	stale := []string{dir}
+	for cachedDir, result := range s.packages {
+		if cachedDir == dir {
+			continue
+		}
+		select {
+		case <-result.ready:
+			if !result.deps[dir] {
+				continue
+			}
+		default:
+			// The imports of a pending analysis are unknown, and it may have loaded dir before the save
+		}
+		stale = append(stale, cachedDir)
+	}
+
+	for _, staleDir := range stale {
+		delete(s.packages, staleDir)
+		if staleDir == dir || s.hasOpenDocumentLocked(staleDir) {
+			s.analyzeLocked(staleDir)
+		}
+	}
+	return nil
+}
+
+func (s *Server) didClose(raw json.RawMessage) error {
+	var params didCloseParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return err
+	}
+	path, ok := goFilePath(params.TextDocument.URI)
+	if !ok {
+		return nil
+	}
+
+	s.mu.Lock()
+	delete(s.documents, path)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Server) codeAction(raw json.RawMessage) (interface{}, error) {
+	var params codeActionParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, err
+	}
+
+	actions := []codeAction{}
+	path, ok := goFilePath(params.TextDocument.URI)
+	if !ok {
+		return actions, nil
+	}
+
+	s.mu.Lock()
+	doc := s.documents[path]
+	result := s.packages[filepath.Dir(path)]
+	s.mu.Unlock()
+
+	// Fixes are byte edits of the saved content, which no longer match a modified document
+	if doc == nil || doc.dirty || result == nil {
+		return actions, nil
+	}
+	select {
+	case <-result.ready:
+	default:
+		return actions, nil
+	}
+
+	mappers := make(map[string]*mapper)
+	for _, f := range result.findings {
+		if f.Path != path || len(f.Fixes) == 0 {
+			continue
+		}
+
+		diag, err := s.diagnostic(f, mappers)
+		if err != nil {
+			return nil, err
+		}
+		if diag.Range.End.Line < params.Range.Start.Line || diag.Range.Start.Line > params.Range.End.Line {
+			continue
+		}
+
+		for _, fix := range f.Fixes {
+			edit, err := workspaceEditFor(fix, mappers)
+			if err != nil {
+				return nil, err
+			}
+
+			title := fix.Message
+			if title == "" {
+				title = "Fix " + f.CheckID
+			}
+			actions = append(actions, codeAction{
+				Title:       title,
+				Kind:        codeActionQuickFix,
+				Diagnostics: []diagnostic{diag},
+				Edit:        edit,
+			})
+		}
+	}
+
+	return actions, nil
+}
+
+// hasOpenDocumentLocked reports whether a document in dir is open. s.mu must be held.
+func (s *Server) hasOpenDocumentLocked(dir string) bool {
+	for path := range s.documents {
+		if filepath.Dir(path) == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// analyzeLocked starts analyzing the package in dir and caches its result. s.mu must be held.
+func (s *Server) analyzeLocked(dir string) {
+	result := &packageResult{ready: make(chan struct{})}
+	s.packages[dir] = result
+	root := s.root
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		s.load(root, dir, result)
+		close(result.ready)
+
+		s.mu.Lock()
+		current := s.packages[dir] == result
+		s.mu.Unlock()
+
+		if result.err != nil {
+			log.Printf("Error: failed to analyze %s: %v", dir, result.err)
+		}
+		// A save superseded this analysis while it ran
+		if !current {
+			return
+		}
+		if result.err != nil {
+			// The findings published before no longer match the files, which do not compile
+			s.clear(dir)
+			return
+		}
+		s.publish(result)
+	}()
+}
+
+// load loads and analyzes the package in dir into result
+func (s *Server) load(root, dir string, result *packageResult) {
+	select {
+	case s.loading <- struct{}{}:
+		defer func() { <-s.loading }()
+	case <-s.ctx.Done():
+		result.err = s.ctx.Err()
+		return
+	}
+
+	pkgs, err := s.loadPackages(s.ctx, dir, ".")
+	if err != nil {
+		result.err = err
+		return
+	}
+
+	findings, err := lint.Analyze(pkgs, s.opts.Analyzers, nil, s.opts.CheckConfig)
+	if err != nil {
+		result.err = err
+		return
+	}
+
+	result.deps = make(map[string]bool)
+	files := make(map[string]bool)
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, file := range pkg.GoFiles {
+			fileDir := filepath.Dir(file)
+			result.deps[fileDir] = true
+			if fileDir == dir && !files[file] {
+				files[file] = true
+				result.files = append(result.files, file)
+			}
+		}
+	})
+
+	for _, f := range findings {
+		if s.opts.Exclude != nil && s.opts.Exclude(root, f.Path) {
+			continue
+		}
+		result.findings = append(result.findings, f)
+	}
+}
+
+// publish sends the diagnostics of every file of the package, clearing those of fixed files
+func (s *Server) publish(result *packageResult) {
+	byFile := make(map[string][]diagnostic, len(result.files))
+	for _, file := range result.files {
+		byFile[file] = []diagnostic{}
+	}
+
+	mappers := make(map[string]*mapper)
+	for _, f := range result.findings {
+		diag, err := s.diagnostic(f, mappers)
+		if err != nil {
+			log.Printf("Warning: %v", err)
+			continue
+		}
+		byFile[f.Path] = append(byFile[f.Path], diag)
+	}
+
+	for file, diags := range byFile {
+		if err := s.conn.notify(methodPublishDiagnostics, publishDiagnosticsParams{URI: pathToURI(file), Diagnostics: diags}); err != nil {
+			log.Printf("Warning: failed to publish diagnostics: %v", err)
+			return
+		}
+	}
+}
+
+// clear publishes empty diagnostics for the Go files in dir
+func (s *Server) clear(dir string) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		log.Printf("Warning: failed to list files of %s: %v", dir, err)
+		return
+	}
+	for _, file := range files {
+		if err := s.conn.notify(methodPublishDiagnostics, publishDiagnosticsParams{URI: pathToURI(file), Diagnostics: []diagnostic{}}); err != nil {
+			log.Printf("Warning: failed to publish diagnostics: %v", err)
+			return
+		}
+	}
+}
+
+// diagnostic converts a finding, reading its file through mappers
+func (s *Server) diagnostic(f lint.Finding, mappers map[string]*mapper) (diagnostic, error) {
+	m, err := mapperFor(f.Path, mappers)
+	if err != nil {
+		return diagnostic{}, err
+	}
+
+	diag := diagnostic{
+		Range:    lspRange{Start: m.linePosition(f.Line, f.Column), End: m.lineEnd(f.Line)},
+		Severity: s.severity(f.CheckID),
+		Code:     f.CheckID,
+		Source:   serverName,
+		Message:  strings.TrimSpace(strings.TrimPrefix(f.Message, f.CheckID+": ")),
+	}
+	if s.opts.RuleURL != nil {
+		diag.CodeDescription = &codeDescription{Href: s.opts.RuleURL(f.CheckID)}
+	}
+	return diag, nil
+}
+
+// severity returns the LSP diagnostic severity of the findings of a check
+func (s *Server) severity(checkID string) int {
+	if s.opts.Severity == nil {
+		return severityWarning
+	}
+	switch s.opts.Severity(checkID) {
+	case rules.SeverityError:
+		return severityError
+	case rules.SeverityInfo:
+		return severityInformation
+	default:
+		return severityWarning
+	}
+}
+
+// workspaceEditFor converts the byte edits of a fix to LSP text edits
+func workspaceEditFor(fix lint.Fix, mappers map[string]*mapper) (workspaceEdit, error) {
+	edit := workspaceEdit{Changes: make(map[string][]textEdit)}
+	for _, e := range fix.Edits {
+		m, err := mapperFor(e.Path, mappers)
+		if err != nil {
+			return workspaceEdit{}, err
+		}
+
+		uri := pathToURI(e.Path)
+		edit.Changes[uri] = append(edit.Changes[uri], textEdit{
+			Range:   lspRange{Start: m.offsetPosition(e.Offset), End: m.offsetPosition(e.End)},
+			NewText: e.NewText,
+		})
+	}
+	return edit, nil
+}
+
+// mapperFor returns the mapper of a file, reading it on first use. The files are unchanged
+// since they were analyzed, as saving a file of the package invalidates its findings.
+func mapperFor(path string, mappers map[string]*mapper) (*mapper, error) {
+	if m, ok := mappers[path]; ok {
+		return m, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	m := newMapper(content)
+	mappers[path] = m
+	return m, nil
+}
+
+// goFilePath returns the path of a Go file URI
+func goFilePath(uri string) (string, bool) {
+	path, ok := uriToPath(uri)
+	if !ok || filepath.Ext(path) != ".go" {
+		return "", false
+	}
+	return path, true
+}
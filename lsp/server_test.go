@@ -0,0 +1,331 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/qixialu/azurerm-linter/lint"
+	"github.com/qixialu/azurerm-linter/passes"
+	"github.com/qixialu/azurerm-linter/rules"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/packages"
+)
+
+// testClient drives a Server over in-memory pipes
+type testClient struct {
+	t        *testing.T
+	w        io.Writer
+	messages chan map[string]json.RawMessage
+	nextID   int
+}
+
+func startServer(t *testing.T, server *Server) (*testClient, <-chan error) {
+	t.Helper()
+
+	clientToServer, serverIn := io.Pipe()
+	serverOut, serverToClient := io.Pipe()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Serve(context.Background(), clientToServer, serverToClient)
+		_ = serverToClient.Close()
+	}()
+
+	client := &testClient{t: t, w: serverIn, messages: make(chan map[string]json.RawMessage, 100)}
+	go func() {
+		defer close(client.messages)
+		r := bufio.NewReader(serverOut)
+		for {
+			header, err := textproto.NewReader(r).ReadMIMEHeader()
+			if err != nil {
+				return
+			}
+			length, _ := strconv.Atoi(header.Get("Content-Length"))
+			body := make([]byte, length)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return
+			}
+			var msg map[string]json.RawMessage
+			if err := json.Unmarshal(body, &msg); err != nil {
+				t.Errorf("invalid message %s: %v", body, err)
+				return
+			}
+			client.messages <- msg
+		}
+	}()
+
+	t.Cleanup(func() { _ = serverIn.Close() })
+	return client, done
+}
+
+func (c *testClient) send(msg map[string]interface{}) {
+	c.t.Helper()
+	msg["jsonrpc"] = "2.0"
+	body, err := json.Marshal(msg)
+	if err != nil {
+		c.t.Fatalf("Marshal() error = %v", err)
+	}
+	if _, err := fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n%s", len(body), body); err != nil {
+		c.t.Fatalf("write error = %v", err)
+	}
+}
+
+func (c *testClient) notify(method string, params interface{}) {
+	c.t.Helper()
+	c.send(map[string]interface{}{"method": method, "params": params})
+}
+
+// call sends a request and returns its result, skipping notifications sent meanwhile
+func (c *testClient) call(method string, params interface{}, result interface{}) {
+	c.t.Helper()
+	c.nextID++
+	id := c.nextID
+	c.send(map[string]interface{}{"id": id, "method": method, "params": params})
+
+	msg := c.waitFor(func(msg map[string]json.RawMessage) bool {
+		return string(msg["id"]) == strconv.Itoa(id)
+	})
+	if msg["error"] != nil {
+		c.t.Fatalf("%s error = %s", method, msg["error"])
+	}
+	if result != nil {
+		if err := json.Unmarshal(msg["result"], result); err != nil {
+			c.t.Fatalf("invalid %s result %s: %v", method, msg["result"], err)
+		}
+	}
+}
+
+// diagnostics waits for the diagnostics published for uri
+func (c *testClient) diagnostics(uri string) []diagnostic {
+	c.t.Helper()
+	var params publishDiagnosticsParams
+	c.waitFor(func(msg map[string]json.RawMessage) bool {
+		if string(msg["method"]) != `"`+methodPublishDiagnostics+`"` {
+			return false
+		}
+		params = publishDiagnosticsParams{}
+		return json.Unmarshal(msg["params"], &params) == nil && params.URI == uri
+	})
+	return params.Diagnostics
+}
+
+func (c *testClient) waitFor(match func(map[string]json.RawMessage) bool) map[string]json.RawMessage {
+	c.t.Helper()
+	timeout := time.After(time.Minute)
+	for {
+		select {
+		case msg, ok := <-c.messages:
+			if !ok {
+				c.t.Fatalf("server closed the connection")
+			}
+			if match(msg) {
+				return msg
+			}
+		case <-timeout:
+			c.t.Fatalf("timed out waiting for a message")
+		}
+	}
+}
+
+func writeModule(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/provider\n\ngo 1.21\n",
+		"internal/services/cdn/errors.go": `package cdn
+
+import "fmt"
+
+func first() error {
+	return fmt.Errorf("first failure")
+}
+`,
+		"internal/services/cdn/other.go": `package cdn
+
+func other() error {
+	return first()
+}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+	return dir
+}
+
+func TestServerPublishesDiagnosticsAndFixes(t *testing.T) {
+	dir := writeModule(t)
+	pkgDir := filepath.Join(dir, "internal", "services", "cdn")
+	errorsURI := pathToURI(filepath.Join(pkgDir, "errors.go"))
+	otherURI := pathToURI(filepath.Join(pkgDir, "other.go"))
+
+	server := NewServer(Options{
+		Analyzers: []*analysis.Analyzer{passes.AZRE001Analyzer},
+		RuleURL:   func(checkID string) string { return "https://example.com/rules/" + checkID },
+		Severity:  func(checkID string) rules.Severity { return rules.SeverityError },
+	})
+	var loads atomic.Int32
+	server.loadPackages = func(ctx context.Context, dir string, patterns ...string) ([]*packages.Package, error) {
+		loads.Add(1)
+		return lint.Load(ctx, dir, patterns...)
+	}
+
+	client, done := startServer(t, server)
+
+	var initResult initializeResult
+	client.call(methodInitialize, map[string]interface{}{"rootUri": pathToURI(dir)}, &initResult)
+	if initResult.Capabilities.CodeActionProvider.CodeActionKinds[0] != codeActionQuickFix {
+		t.Fatalf("capabilities = %+v, want quick fix code actions", initResult.Capabilities)
+	}
+	client.notify(methodInitialized, map[string]interface{}{})
+
+	client.notify(methodDidOpen, map[string]interface{}{"textDocument": map[string]interface{}{"uri": errorsURI}})
+	diags := client.diagnostics(errorsURI)
+	if len(diags) != 1 {
+		t.Fatalf("diagnostics = %+v, want one", diags)
+	}
+	diag := diags[0]
+	if diag.Code != "AZRE001" || diag.Severity != severityError || diag.CodeDescription == nil || diag.CodeDescription.Href != "https://example.com/rules/AZRE001" {
+		t.Fatalf("diagnostic = %+v, want an AZRE001 error with a rule link", diag)
+	}
+	if diag.Range.Start != (position{Line: 5, Character: 8}) || strings.HasPrefix(diag.Message, "AZRE001") {
+		t.Fatalf("diagnostic = %+v, want start 5:8 and the message without the check ID", diag)
+	}
+
+	var actions []codeAction
+	client.call(methodCodeAction, map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": errorsURI},
+		"range":        diag.Range,
+		"context":      map[string]interface{}{"diagnostics": []diagnostic{diag}},
+	}, &actions)
+	if len(actions) != 1 || actions[0].Kind != codeActionQuickFix {
+		t.Fatalf("code actions = %+v, want one quick fix", actions)
+	}
+	var newText []string
+	for _, edit := range actions[0].Edit.Changes[errorsURI] {
+		newText = append(newText, edit.NewText)
+	}
+	if !strings.Contains(strings.Join(newText, ""), "errors.New") {
+		t.Fatalf("fix edits = %+v, want errors.New", actions[0].Edit.Changes)
+	}
+
+	// Another file of the package reuses the cached analysis
+	client.notify(methodDidOpen, map[string]interface{}{"textDocument": map[string]interface{}{"uri": otherURI}})
+	if diags := client.diagnostics(otherURI); len(diags) != 0 {
+		t.Fatalf("diagnostics of other.go = %+v, want none", diags)
+	}
+	if got := loads.Load(); got != 1 {
+		t.Fatalf("loads = %d after opening a second file, want 1", got)
+	}
+
+	// A modified document offers no fixes until it is saved
+	client.notify(methodDidChange, map[string]interface{}{"textDocument": map[string]interface{}{"uri": errorsURI}})
+	client.call(methodCodeAction, map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": errorsURI},
+		"range":        diag.Range,
+	}, &actions)
+	if len(actions) != 0 {
+		t.Fatalf("code actions of a modified document = %+v, want none", actions)
+	}
+
+	// Saving the fixed file reloads the package and clears its diagnostics
+	if err := os.WriteFile(filepath.Join(pkgDir, "errors.go"), []byte("package cdn\n\nimport \"errors\"\n\nfunc first() error {\n\treturn errors.New(\"first failure\")\n}\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	client.notify(methodDidSave, map[string]interface{}{"textDocument": map[string]interface{}{"uri": errorsURI}})
+	if diags := client.diagnostics(errorsURI); len(diags) != 0 {
+		t.Fatalf("diagnostics after the fix = %+v, want none", diags)
+	}
+	if got := loads.Load(); got != 2 {
+		t.Fatalf("loads = %d after saving, want 2", got)
+	}
+
+	client.call(methodShutdown, nil, nil)
+	client.notify(methodExit, nil)
+	if err := <-done; err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+}
+
+func TestServerClearsDiagnosticsWhenSavedFileDoesNotCompile(t *testing.T) {
+	dir := writeModule(t)
+	pkgDir := filepath.Join(dir, "internal", "services", "cdn")
+	errorsURI := pathToURI(filepath.Join(pkgDir, "errors.go"))
+	otherURI := pathToURI(filepath.Join(pkgDir, "other.go"))
+
+	server := NewServer(Options{Analyzers: []*analysis.Analyzer{passes.AZRE001Analyzer}})
+	client, done := startServer(t, server)
+	client.call(methodInitialize, map[string]interface{}{"rootUri": pathToURI(dir)}, nil)
+	client.notify(methodInitialized, map[string]interface{}{})
+
+	client.notify(methodDidOpen, map[string]interface{}{"textDocument": map[string]interface{}{"uri": errorsURI}})
+	if diags := client.diagnostics(errorsURI); len(diags) != 1 {
+		t.Fatalf("diagnostics = %+v, want one", diags)
+	}
+
+	// The diagnostic of the clean file would point at a line the broken file moved
+	if err := os.WriteFile(filepath.Join(pkgDir, "other.go"), []byte("package cdn\n\nfunc other() error {\n\treturn first(\n}\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(pkgDir, "errors.go"), []byte("package cdn\n\nimport \"fmt\"\n\n// first fails\nfunc first() error {\n\treturn fmt.Errorf(\"first failure\")\n}\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	client.notify(methodDidSave, map[string]interface{}{"textDocument": map[string]interface{}{"uri": otherURI}})
+	if diags := client.diagnostics(errorsURI); len(diags) != 0 {
+		t.Fatalf("diagnostics after saving a broken file = %+v, want them cleared", diags)
+	}
+
+	client.call(methodShutdown, nil, nil)
+	client.notify(methodExit, nil)
+	if err := <-done; err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+}
+
+func TestServerRejectsRequestsBeforeInitialize(t *testing.T) {
+	client, done := startServer(t, NewServer(Options{}))
+
+	client.send(map[string]interface{}{"id": 1, "method": methodCodeAction, "params": map[string]interface{}{}})
+	msg := client.waitFor(func(msg map[string]json.RawMessage) bool { return string(msg["id"]) == "1" })
+
+	var rpcErr responseError
+	if err := json.Unmarshal(msg["error"], &rpcErr); err != nil || rpcErr.Code != codeServerNotInitialized {
+		t.Fatalf("response = %s, want server not initialized error", msg["error"])
+	}
+
+	client.notify(methodExit, nil)
+	if err := <-done; err == nil {
+		t.Fatalf("Serve() error = nil, want error for exit without shutdown")
+	}
+}
+
+func TestMapperCountsUTF16CodeUnits(t *testing.T) {
+	m := newMapper([]byte("a\r\n\tx := \"é😀\" // y\nlast"))
+
+	if got := m.linePosition(2, 14); got != (position{Line: 1, Character: 10}) {
+		t.Fatalf("linePosition(2, 14) = %+v, want 1:10", got)
+	}
+	if got := m.lineEnd(1); got != (position{Line: 0, Character: 1}) {
+		t.Fatalf("lineEnd(1) = %+v, want 0:1 before the CRLF", got)
+	}
+	if got := m.lineEnd(3); got != (position{Line: 2, Character: 4}) {
+		t.Fatalf("lineEnd(3) = %+v, want 2:4", got)
+	}
+}
@@ -33,10 +33,35 @@ func run() int {
 		return 0
 	}
 
+	// Remove the result cache
+	if cfg.CleanCache {
+		return int(cmd.CleanCache())
+	}
+
+	// Document checks
+	if cfg.Explain {
+		return int(cmd.Explain(cfg))
+	}
+
+	// Install the pre-commit hook
+	if cfg.InstallHook {
+		return int(cmd.InstallHook())
+	}
+
+	// Load project configuration file
+	if err := cfg.LoadProjectConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 3
+	}
+
 	// Handle list checks flag
 	if cfg.ListChecks {
-		cmd.PrintChecks()
-		return 0
+		return int(cmd.PrintChecks(cfg))
+	}
+
+	// Serve diagnostics to editors
+	if cfg.LSP {
+		return int(cmd.RunLSP(context.Background(), cfg))
 	}
 
 	// Create and run the linter
@@ -4,9 +4,9 @@ import (
 	"github.com/bflad/tfproviderlint/helper/terraformtype/helper/schema"
 	"github.com/bflad/tfproviderlint/passes/commentignore"
 	"github.com/qixialu/azurerm-linter/helper"
-	"github.com/qixialu/azurerm-linter/loader"
 	localschema "github.com/qixialu/azurerm-linter/passes/schema"
 	"github.com/qixialu/azurerm-linter/reporting"
+	"github.com/qixialu/azurerm-linter/session"
 	"golang.org/x/tools/go/analysis"
 )
 
@@ -40,7 +40,7 @@ var AZBP001Analyzer = &analysis.Analyzer{
 	Name:     azbp001Name,
 	Doc:      AZBP001Doc,
 	Run:      runAZBP001,
-	Requires: []*analysis.Analyzer{localschema.LocalAnalyzer, commentignore.Analyzer},
+	Requires: []*analysis.Analyzer{localschema.LocalAnalyzer, commentignore.Analyzer, session.Analyzer},
 }
 
 func runAZBP001(pass *analysis.Pass) (interface{}, error) {
@@ -77,7 +77,7 @@ func runAZBP001(pass *analysis.Pass) (interface{}, error) {
 
 		if !hasValidation {
 			pos := pass.Fset.Position(schemaLit.Pos())
-			if !loader.IsFileChanged(pos.Filename) {
+			if !session.From(pass).IsFileChanged(pos.Filename) {
 				continue
 			}
 			if propertyName := cached.PropertyName; propertyName != "" {
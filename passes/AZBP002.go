@@ -7,9 +7,9 @@ import (
 	"github.com/bflad/tfproviderlint/helper/terraformtype/helper/schema"
 	"github.com/bflad/tfproviderlint/passes/commentignore"
 	"github.com/qixialu/azurerm-linter/helper"
-	"github.com/qixialu/azurerm-linter/loader"
 	localschema "github.com/qixialu/azurerm-linter/passes/schema"
 	"github.com/qixialu/azurerm-linter/reporting"
+	"github.com/qixialu/azurerm-linter/session"
 	"golang.org/x/tools/go/analysis"
 )
 
@@ -40,7 +40,7 @@ var AZBP002Analyzer = &analysis.Analyzer{
 	Name:     azbp002Name,
 	Doc:      AZBP002Doc,
 	Run:      runAZBP002,
-	Requires: []*analysis.Analyzer{localschema.LocalAnalyzer, commentignore.Analyzer},
+	Requires: []*analysis.Analyzer{localschema.LocalAnalyzer, commentignore.Analyzer, session.Analyzer},
 }
 
 func runAZBP002(pass *analysis.Pass) (interface{}, error) {
@@ -88,7 +88,7 @@ func runAZBP002(pass *analysis.Pass) (interface{}, error) {
 		// Check order: Optional should come before Computed
 		if optionalPos > computedPos {
 			pos := pass.Fset.Position(schemaLit.Pos())
-			if loader.IsFileChanged(pos.Filename) {
+			if session.From(pass).IsFileChanged(pos.Filename) {
 				reporting.Reportf(pass, reporting.ReportOptions{
 					Rule:          azbp002Name,
 					ReportPos:     schemaLit.Pos(),
@@ -127,7 +127,7 @@ func runAZBP002(pass *analysis.Pass) (interface{}, error) {
 
 		if !hasOCComment {
 			pos := pass.Fset.Position(schemaLit.Pos())
-			if !loader.IsFileChanged(pos.Filename) {
+			if !session.From(pass).IsFileChanged(pos.Filename) {
 				continue
 			}
 			if propertyName := cached.PropertyName; propertyName != "" {
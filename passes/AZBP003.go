@@ -1,13 +1,14 @@
 package passes
 
 import (
+	"fmt"
 	"go/ast"
 	"go/types"
 
 	"github.com/bflad/tfproviderlint/passes/commentignore"
 	"github.com/qixialu/azurerm-linter/helper"
-	"github.com/qixialu/azurerm-linter/loader"
 	"github.com/qixialu/azurerm-linter/reporting"
+	"github.com/qixialu/azurerm-linter/session"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 	"golang.org/x/tools/go/ast/inspector"
@@ -36,7 +37,7 @@ var AZBP003Analyzer = &analysis.Analyzer{
 	Name:     azbp003Name,
 	Doc:      AZBP003Doc,
 	Run:      runAZBP003,
-	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer},
+	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer, session.Analyzer},
 }
 
 func runAZBP003(pass *analysis.Pass) (interface{}, error) {
@@ -45,7 +46,7 @@ func runAZBP003(pass *analysis.Pass) (interface{}, error) {
 		return nil, nil
 	}
 
-	if helper.ShouldSkipPackageForResourceAnalysis(pass.Pkg.Path()) {
+	if helper.ShouldSkipPackageForResourceAnalysis(pass.Pkg.Path(), session.From(pass).SkipPackages()) {
 		return nil, nil
 	}
 
@@ -130,13 +131,21 @@ func runAZBP003(pass *analysis.Pass) (interface{}, error) {
 			return
 		}
 
-		if loader.IsFileChanged(pos.Filename) && !ignorer.ShouldIgnore(azbp003Name, call) {
+		if session.From(pass).IsFileChanged(pos.Filename) && !ignorer.ShouldIgnore(azbp003Name, call) {
+			var fixes []analysis.SuggestedFix
+			if len(argCall.Args) == 1 {
+				// pointer.To(sdk.Enum(x)) => pointer.ToEnum[sdk.Enum](x)
+				fixes = append(fixes, helper.ReplaceNodeFix("Use pointer.ToEnum", call, fmt.Sprintf("%s.ToEnum[%s](%s)",
+					ident.Name, helper.NodeText(pass.Fset, argCall.Fun), helper.NodeText(pass.Fset, argCall.Args[0]))))
+			}
+
 			reporting.Reportf(pass, reporting.ReportOptions{
-				Rule:          azbp003Name,
-				ReportPos:     call.Pos(),
-				EvidenceFile:  pos.Filename,
-				EvidenceLines: []int{pos.Line},
-				MatchMode:     reporting.MatchModeExactAdded,
+				Rule:           azbp003Name,
+				ReportPos:      call.Pos(),
+				EvidenceFile:   pos.Filename,
+				EvidenceLines:  []int{pos.Line},
+				MatchMode:      reporting.MatchModeExactAdded,
+				SuggestedFixes: fixes,
 			}, "%s: use `%s` to convert Enum type instead of explicitly type conversion.\n",
 				azbp003Name, helper.FixedCode("pointer.ToEnum"))
 		}
@@ -9,5 +9,5 @@ import (
 
 func TestAZBP003(t *testing.T) {
 	testdata := analysistest.TestData()
-	analysistest.Run(t, testdata, passes.AZBP003Analyzer, "testdata/src/azbp003")
+	analysistest.RunWithSuggestedFixes(t, testdata, passes.AZBP003Analyzer, "testdata/src/azbp003")
 }
@@ -8,8 +8,8 @@ import (
 
 	"github.com/bflad/tfproviderlint/passes/commentignore"
 	"github.com/qixialu/azurerm-linter/helper"
-	"github.com/qixialu/azurerm-linter/loader"
 	"github.com/qixialu/azurerm-linter/reporting"
+	"github.com/qixialu/azurerm-linter/session"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 	"golang.org/x/tools/go/ast/inspector"
@@ -39,7 +39,7 @@ var AZBP004Analyzer = &analysis.Analyzer{
 	Name:     azbp004Name,
 	Doc:      AZBP004Doc,
 	Run:      runAZBP004,
-	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer},
+	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer, session.Analyzer},
 }
 
 func runAZBP004(pass *analysis.Pass) (interface{}, error) {
@@ -48,7 +48,7 @@ func runAZBP004(pass *analysis.Pass) (interface{}, error) {
 		return nil, nil
 	}
 
-	if helper.ShouldSkipPackageForResourceAnalysis(pass.Pkg.Path()) {
+	if helper.ShouldSkipPackageForResourceAnalysis(pass.Pkg.Path(), session.From(pass).SkipPackages()) {
 		return nil, nil
 	}
 
@@ -98,7 +98,7 @@ func checkZeroInitPattern(pass *analysis.Pass, inspector *inspector.Inspector, i
 			}
 
 			pos := pass.Fset.Position(assignStmt.Pos())
-			if loader.IsFileChanged(pos.Filename) && !ignorer.ShouldIgnore(azbp004Name, assignStmt) {
+			if session.From(pass).IsFileChanged(pos.Filename) && !ignorer.ShouldIgnore(azbp004Name, assignStmt) {
 				reporting.Reportf(pass, reporting.ReportOptions{
 					Rule:          azbp004Name,
 					ReportPos:     assignStmt.Pos(),
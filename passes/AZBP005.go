@@ -7,8 +7,8 @@ import (
 
 	"github.com/bflad/tfproviderlint/passes/commentignore"
 	"github.com/qixialu/azurerm-linter/helper"
-	"github.com/qixialu/azurerm-linter/loader"
 	"github.com/qixialu/azurerm-linter/reporting"
+	"github.com/qixialu/azurerm-linter/session"
 	"golang.org/x/tools/go/analysis"
 )
 
@@ -36,16 +36,32 @@ Valid usage:
 
 const azbp005Name = "AZBP005"
 
-var expectedLicenseLines = []string{
-	"// Copyright IBM Corp. 2014, 2025",
-	"// SPDX-License-Identifier: MPL-2.0",
-}
+const defaultLicenseHeader = "// Copyright IBM Corp. 2014, 2025\n// SPDX-License-Identifier: MPL-2.0"
+
+// azbp005LicenseHeader is the default of the license-header setting, set by the flag of the same name
+var azbp005LicenseHeader string
 
 var AZBP005Analyzer = &analysis.Analyzer{
 	Name:     azbp005Name,
 	Doc:      AZBP005Doc,
 	Run:      runAZBP005,
-	Requires: []*analysis.Analyzer{commentignore.Analyzer},
+	Requires: []*analysis.Analyzer{commentignore.Analyzer, session.Analyzer},
+}
+
+func init() {
+	AZBP005Analyzer.Flags.StringVar(&azbp005LicenseHeader, "license-header", defaultLicenseHeader,
+		"expected license header comment lines, separated by newlines")
+}
+
+// expectedLicenseLines returns the license header configured for the run split into trimmed comment lines
+func expectedLicenseLines(pass *analysis.Pass) []string {
+	var lines []string
+	for _, line := range strings.Split(setting(pass, "license-header"), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
 }
 
 func runAZBP005(pass *analysis.Pass) (interface{}, error) {
@@ -62,7 +78,7 @@ func runAZBP005(pass *analysis.Pass) (interface{}, error) {
 
 func checkLicenseHeader(pass *analysis.Pass, file *ast.File, ignorer *commentignore.Ignorer) {
 	filename := pass.Fset.Position(file.Pos()).Filename
-	if !strings.HasSuffix(filename, ".go") || !loader.IsFileChanged(filename) {
+	if !strings.HasSuffix(filename, ".go") || !session.From(pass).IsFileChanged(filename) {
 		return
 	}
 
@@ -70,7 +86,11 @@ func checkLicenseHeader(pass *analysis.Pass, file *ast.File, ignorer *commentign
 		return
 	}
 
-	expectedHeader := strings.Join(expectedLicenseLines, "\n")
+	expectedLines := expectedLicenseLines(pass)
+	if len(expectedLines) == 0 {
+		return
+	}
+	expectedHeader := strings.Join(expectedLines, "\n")
 
 	// Check: must have comments, first comment before package, starts at line 1
 	if len(file.Comments) == 0 || file.Comments[0].Pos() > file.Package {
@@ -100,7 +120,7 @@ func checkLicenseHeader(pass *analysis.Pass, file *ast.File, ignorer *commentign
 
 	// Check content matches
 	comments := firstComment.List
-	if len(comments) < len(expectedLicenseLines) {
+	if len(comments) < len(expectedLines) {
 		reporting.Report(pass, reporting.ReportOptions{
 			Rule:          azbp005Name,
 			ReportPos:     firstComment.Pos(),
@@ -112,7 +132,7 @@ func checkLicenseHeader(pass *analysis.Pass, file *ast.File, ignorer *commentign
 		return
 	}
 
-	for i, expected := range expectedLicenseLines {
+	for i, expected := range expectedLines {
 		if strings.TrimSpace(comments[i].Text) != expected {
 			reporting.Report(pass, reporting.ReportOptions{
 				Rule:          azbp005Name,
@@ -7,8 +7,8 @@ import (
 
 	"github.com/bflad/tfproviderlint/passes/commentignore"
 	"github.com/qixialu/azurerm-linter/helper"
-	"github.com/qixialu/azurerm-linter/loader"
 	"github.com/qixialu/azurerm-linter/reporting"
+	"github.com/qixialu/azurerm-linter/session"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 	"golang.org/x/tools/go/ast/inspector"
@@ -39,11 +39,11 @@ var AZBP006Analyzer = &analysis.Analyzer{
 	Name:     azbp006Name,
 	Doc:      AZBP006Doc,
 	Run:      runAZBP006,
-	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer},
+	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer, session.Analyzer},
 }
 
 func runAZBP006(pass *analysis.Pass) (interface{}, error) {
-	if helper.ShouldSkipPackageForResourceAnalysis(pass.Pkg.Path()) {
+	if helper.ShouldSkipPackageForResourceAnalysis(pass.Pkg.Path(), session.From(pass).SkipPackages()) {
 		return nil, nil
 	}
 
@@ -111,7 +111,7 @@ func runAZBP006(pass *analysis.Pass) (interface{}, error) {
 			}
 
 			pos := pass.Fset.Position(kv.Pos())
-			if !loader.IsFileChanged(pos.Filename) {
+			if !session.From(pass).IsFileChanged(pos.Filename) {
 				continue
 			}
 			if ignorer.ShouldIgnore(azbp006Name, kv) {
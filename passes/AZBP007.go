@@ -6,8 +6,8 @@ import (
 
 	"github.com/bflad/tfproviderlint/passes/commentignore"
 	"github.com/qixialu/azurerm-linter/helper"
-	"github.com/qixialu/azurerm-linter/loader"
 	"github.com/qixialu/azurerm-linter/reporting"
+	"github.com/qixialu/azurerm-linter/session"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 	"golang.org/x/tools/go/ast/inspector"
@@ -33,11 +33,11 @@ var AZBP007Analyzer = &analysis.Analyzer{
 	Name:     azbp007Name,
 	Doc:      AZBP007Doc,
 	Run:      runAZBP007,
-	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer},
+	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer, session.Analyzer},
 }
 
 func runAZBP007(pass *analysis.Pass) (interface{}, error) {
-	if helper.ShouldSkipPackageForResourceAnalysis(pass.Pkg.Path()) {
+	if helper.ShouldSkipPackageForResourceAnalysis(pass.Pkg.Path(), session.From(pass).SkipPackages()) {
 		return nil, nil
 	}
 
@@ -99,7 +99,7 @@ func runAZBP007(pass *analysis.Pass) (interface{}, error) {
 			}
 
 			pos := pass.Fset.Position(compositeLit.Pos())
-			if !loader.IsFileChanged(pos.Filename) {
+			if !session.From(pass).IsFileChanged(pos.Filename) {
 				continue
 			}
 
@@ -113,6 +113,9 @@ func runAZBP007(pass *analysis.Pass) (interface{}, error) {
 				EvidenceFile:  pos.Filename,
 				EvidenceLines: []int{pos.Line},
 				MatchMode:     reporting.MatchModeExactAdded,
+				SuggestedFixes: []analysis.SuggestedFix{
+					helper.ReplaceNodeFix("Use make([]string, 0)", compositeLit, "make([]string, 0)"),
+				},
 			}, "%s: use %s instead of %s\n",
 				azbp007Name,
 				helper.FixedCode("make([]string, 0)"),
@@ -9,5 +9,5 @@ import (
 
 func TestAZBP007(t *testing.T) {
 	testdata := analysistest.TestData()
-	analysistest.Run(t, testdata, passes.AZBP007Analyzer, "testdata/src/azbp007")
+	analysistest.RunWithSuggestedFixes(t, testdata, passes.AZBP007Analyzer, "testdata/src/azbp007")
 }
@@ -7,9 +7,9 @@ import (
 	"github.com/bflad/tfproviderlint/helper/terraformtype/helper/schema"
 	"github.com/bflad/tfproviderlint/passes/commentignore"
 	"github.com/qixialu/azurerm-linter/helper"
-	"github.com/qixialu/azurerm-linter/loader"
 	localschema "github.com/qixialu/azurerm-linter/passes/schema"
 	"github.com/qixialu/azurerm-linter/reporting"
+	"github.com/qixialu/azurerm-linter/session"
 	"golang.org/x/tools/go/analysis"
 )
 
@@ -34,7 +34,7 @@ var AZBP008Analyzer = &analysis.Analyzer{
 	Name:     azbp008Name,
 	Doc:      AZBP008Doc,
 	Run:      runAZBP008,
-	Requires: []*analysis.Analyzer{localschema.LocalAnalyzer, commentignore.Analyzer},
+	Requires: []*analysis.Analyzer{localschema.LocalAnalyzer, commentignore.Analyzer, session.Analyzer},
 }
 
 func runAZBP008(pass *analysis.Pass) (interface{}, error) {
@@ -79,7 +79,7 @@ func runAZBP008(pass *analysis.Pass) (interface{}, error) {
 		}
 
 		evidenceFile, _ := compositeLiteralEvidence(compLit, pass.Fset)
-		if !loader.IsFileChanged(evidenceFile) {
+		if !session.From(pass).IsFileChanged(evidenceFile) {
 			continue
 		}
 
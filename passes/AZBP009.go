@@ -7,8 +7,8 @@ import (
 
 	"github.com/bflad/tfproviderlint/passes/commentignore"
 	"github.com/qixialu/azurerm-linter/helper"
-	"github.com/qixialu/azurerm-linter/loader"
 	"github.com/qixialu/azurerm-linter/reporting"
+	"github.com/qixialu/azurerm-linter/session"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 	"golang.org/x/tools/go/ast/inspector"
@@ -51,7 +51,7 @@ var AZBP009Analyzer = &analysis.Analyzer{
 	Name:     azbp009Name,
 	Doc:      AZBP009Doc,
 	Run:      runAZBP009,
-	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer},
+	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer, session.Analyzer},
 }
 
 func runAZBP009(pass *analysis.Pass) (interface{}, error) {
@@ -112,7 +112,7 @@ func runAZBP009(pass *analysis.Pass) (interface{}, error) {
 
 	inspector.Preorder(nodeFilter, func(n ast.Node) {
 		pos := pass.Fset.Position(n.Pos())
-		if !loader.IsFileChanged(pos.Filename) || ignorer.ShouldIgnore(azbp009Name, n) {
+		if !session.From(pass).IsFileChanged(pos.Filename) || ignorer.ShouldIgnore(azbp009Name, n) {
 			return
 		}
 
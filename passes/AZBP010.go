@@ -5,8 +5,8 @@ import (
 	"go/token"
 
 	"github.com/bflad/tfproviderlint/passes/commentignore"
-	"github.com/qixialu/azurerm-linter/loader"
 	"github.com/qixialu/azurerm-linter/reporting"
+	"github.com/qixialu/azurerm-linter/session"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 	"golang.org/x/tools/go/ast/inspector"
@@ -49,7 +49,7 @@ var AZBP010Analyzer = &analysis.Analyzer{
 	Name:     azbp010Name,
 	Doc:      AZBP010Doc,
 	Run:      runAZBP010,
-	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer},
+	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer, session.Analyzer},
 }
 
 func runAZBP010(pass *analysis.Pass) (interface{}, error) {
@@ -93,7 +93,7 @@ func runAZBP010(pass *analysis.Pass) (interface{}, error) {
 
 			if returnsOnlyDeclaredVars(returnStmt, declaredVars) {
 				pos := pass.Fset.Position(declStmt.Pos())
-				if !loader.IsFileChanged(pos.Filename) || ignorer.ShouldIgnore(azbp010Name, declStmt) {
+				if !session.From(pass).IsFileChanged(pos.Filename) || ignorer.ShouldIgnore(azbp010Name, declStmt) {
 					continue
 				}
 
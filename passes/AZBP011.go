@@ -6,8 +6,8 @@ import (
 
 	"github.com/bflad/tfproviderlint/passes/commentignore"
 	"github.com/qixialu/azurerm-linter/helper"
-	"github.com/qixialu/azurerm-linter/loader"
 	"github.com/qixialu/azurerm-linter/reporting"
+	"github.com/qixialu/azurerm-linter/session"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 	"golang.org/x/tools/go/ast/inspector"
@@ -57,7 +57,7 @@ var AZBP011Analyzer = &analysis.Analyzer{
 	Name:     azbp011Name,
 	Doc:      AZBP011Doc,
 	Run:      runAZBP011,
-	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer},
+	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer, session.Analyzer},
 }
 
 func runAZBP011(pass *analysis.Pass) (interface{}, error) {
@@ -101,7 +101,7 @@ func runAZBP011(pass *analysis.Pass) (interface{}, error) {
 		}
 
 		pos := pass.Fset.Position(callExpr.Pos())
-		if !loader.IsFileChanged(pos.Filename) || ignorer.ShouldIgnore(azbp011Name, callExpr) {
+		if !session.From(pass).IsFileChanged(pos.Filename) || ignorer.ShouldIgnore(azbp011Name, callExpr) {
 			return
 		}
 
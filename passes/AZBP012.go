@@ -7,8 +7,8 @@ import (
 
 	"github.com/bflad/tfproviderlint/passes/commentignore"
 	"github.com/qixialu/azurerm-linter/helper"
-	"github.com/qixialu/azurerm-linter/loader"
 	"github.com/qixialu/azurerm-linter/reporting"
+	"github.com/qixialu/azurerm-linter/session"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 	"golang.org/x/tools/go/ast/inspector"
@@ -40,11 +40,11 @@ var AZBP012Analyzer = &analysis.Analyzer{
 	Name:     azbp012Name,
 	Doc:      AZBP012Doc,
 	Run:      runAZBP012,
-	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer},
+	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer, session.Analyzer},
 }
 
 func runAZBP012(pass *analysis.Pass) (interface{}, error) {
-	if helper.ShouldSkipPackageForResourceAnalysis(pass.Pkg.Path()) {
+	if helper.ShouldSkipPackageForResourceAnalysis(pass.Pkg.Path(), session.From(pass).SkipPackages()) {
 		return nil, nil
 	}
 
@@ -83,7 +83,7 @@ func runAZBP012(pass *analysis.Pass) (interface{}, error) {
 		}
 
 		pos := pass.Fset.Position(ifStmt.Pos())
-		if !loader.IsFileChanged(pos.Filename) {
+		if !session.From(pass).IsFileChanged(pos.Filename) {
 			return
 		}
 		if ignorer.ShouldIgnore(azbp012Name, ifStmt) {
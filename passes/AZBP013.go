@@ -7,8 +7,8 @@ import (
 
 	"github.com/bflad/tfproviderlint/passes/commentignore"
 	"github.com/qixialu/azurerm-linter/helper"
-	"github.com/qixialu/azurerm-linter/loader"
 	"github.com/qixialu/azurerm-linter/reporting"
+	"github.com/qixialu/azurerm-linter/session"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 	"golang.org/x/tools/go/ast/inspector"
@@ -43,11 +43,11 @@ var AZBP013Analyzer = &analysis.Analyzer{
 	Name:     azbp013Name,
 	Doc:      AZBP013Doc,
 	Run:      runAZBP013,
-	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer},
+	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer, session.Analyzer},
 }
 
 func runAZBP013(pass *analysis.Pass) (interface{}, error) {
-	if helper.ShouldSkipPackageForResourceAnalysis(pass.Pkg.Path()) {
+	if helper.ShouldSkipPackageForResourceAnalysis(pass.Pkg.Path(), session.From(pass).SkipPackages()) {
 		return nil, nil
 	}
 
@@ -69,7 +69,7 @@ func runAZBP013(pass *analysis.Pass) (interface{}, error) {
 		}
 
 		pos := pass.Fset.Position(ifStmt.Pos())
-		if !loader.IsFileChanged(pos.Filename) {
+		if !session.From(pass).IsFileChanged(pos.Filename) {
 			return
 		}
 		if ignorer.ShouldIgnore(azbp013Name, ifStmt) {
@@ -2,12 +2,13 @@ package passes
 
 import (
 	"go/ast"
+	"go/token"
 	"go/types"
 
 	"github.com/bflad/tfproviderlint/passes/commentignore"
 	"github.com/qixialu/azurerm-linter/helper"
-	"github.com/qixialu/azurerm-linter/loader"
 	"github.com/qixialu/azurerm-linter/reporting"
+	"github.com/qixialu/azurerm-linter/session"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 	"golang.org/x/tools/go/ast/inspector"
@@ -34,11 +35,11 @@ var AZBP014Analyzer = &analysis.Analyzer{
 	Name:     azbp014Name,
 	Doc:      AZBP014Doc,
 	Run:      runAZBP014,
-	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer},
+	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer, session.Analyzer},
 }
 
 func runAZBP014(pass *analysis.Pass) (interface{}, error) {
-	if helper.ShouldSkipPackageForResourceAnalysis(pass.Pkg.Path()) {
+	if helper.ShouldSkipPackageForResourceAnalysis(pass.Pkg.Path(), session.From(pass).SkipPackages()) {
 		return nil, nil
 	}
 
@@ -53,63 +54,102 @@ func runAZBP014(pass *analysis.Pass) (interface{}, error) {
 	}
 
 	nodeFilter := []ast.Node{(*ast.CompositeLit)(nil)}
-	insp.Preorder(nodeFilter, func(n ast.Node) {
+	insp.WithStack(nodeFilter, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
 		lit, ok := n.(*ast.CompositeLit)
 		if !ok {
-			return
+			return true
 		}
 
 		// Only empty literals (no field initializers)
 		if len(lit.Elts) != 0 {
-			return
+			return true
 		}
 
 		pos := pass.Fset.Position(lit.Pos())
-		if !loader.IsFileChanged(pos.Filename) {
-			return
+		if !session.From(pass).IsFileChanged(pos.Filename) {
+			return true
 		}
 		if ignorer.ShouldIgnore(azbp014Name, lit) {
-			return
+			return true
 		}
 
 		// Resolve the type of the composite literal
 		litType := pass.TypesInfo.TypeOf(lit)
 		if litType == nil {
-			return
+			return true
 		}
 
 		named, ok := litType.(*types.Named)
 		if !ok {
-			return
+			return true
 		}
 
 		typeName := named.Obj().Name()
 		pkg := named.Obj().Pkg()
 		if pkg == nil {
-			return
+			return true
 		}
 
 		// Check if Default<TypeName>() exists in the type's package
 		defaultFuncName := "Default" + typeName
 		obj := pkg.Scope().Lookup(defaultFuncName)
 		if obj == nil {
-			return
+			return true
 		}
 
 		// Verify it's a function
 		if _, ok := obj.(*types.Func); !ok {
-			return
+			return true
+		}
+
+		var fixes []analysis.SuggestedFix
+		if !isAddressOperand(stack, lit) {
+			// Default*() returns a value, so &Options{} cannot be rewritten in place
+			if fixText, ok := defaultConstructorCall(lit, defaultFuncName); ok {
+				fixes = append(fixes, helper.ReplaceNodeFix("Use "+defaultFuncName+"()", lit, fixText))
+			}
 		}
 
 		reporting.Reportf(pass, reporting.ReportOptions{
-			Rule:          azbp014Name,
-			ReportPos:     lit.Pos(),
-			EvidenceFile:  pos.Filename,
-			EvidenceLines: []int{pos.Line},
-			MatchMode:     reporting.MatchModeExactAdded,
+			Rule:           azbp014Name,
+			ReportPos:      lit.Pos(),
+			EvidenceFile:   pos.Filename,
+			EvidenceLines:  []int{pos.Line},
+			MatchMode:      reporting.MatchModeExactAdded,
+			SuggestedFixes: fixes,
 		}, "%s: use %s.%s() instead of empty %s literal\n",
 			azbp014Name, pkg.Name(), defaultFuncName, typeName)
+
+		return true
 	})
 
 	return nil, nil
 }
+
+// isAddressOperand reports whether lit is the operand of a & expression
+func isAddressOperand(stack []ast.Node, lit *ast.CompositeLit) bool {
+	if len(stack) < 2 {
+		return false
+	}
+	unary, ok := stack[len(stack)-2].(*ast.UnaryExpr)
+	return ok && unary.Op == token.AND && unary.X == lit
+}
+
+// defaultConstructorCall builds the Default*() call replacing lit, qualified like the literal's type
+func defaultConstructorCall(lit *ast.CompositeLit, defaultFuncName string) (string, bool) {
+	switch t := lit.Type.(type) {
+	case *ast.SelectorExpr:
+		pkgIdent, ok := t.X.(*ast.Ident)
+		if !ok {
+			return "", false
+		}
+		return pkgIdent.Name + "." + defaultFuncName + "()", true
+	case *ast.Ident:
+		return defaultFuncName + "()", true
+	default:
+		return "", false
+	}
+}
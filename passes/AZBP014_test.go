@@ -9,5 +9,5 @@ import (
 
 func TestAZBP014(t *testing.T) {
 	testdata := analysistest.TestData()
-	analysistest.Run(t, testdata, passes.AZBP014Analyzer, "testdata/src/azbp014")
+	analysistest.RunWithSuggestedFixes(t, testdata, passes.AZBP014Analyzer, "testdata/src/azbp014")
 }
@@ -5,8 +5,8 @@ import (
 	"strings"
 
 	"github.com/bflad/tfproviderlint/passes/commentignore"
-	"github.com/qixialu/azurerm-linter/loader"
 	"github.com/qixialu/azurerm-linter/reporting"
+	"github.com/qixialu/azurerm-linter/session"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 )
@@ -46,7 +46,7 @@ var AZBP015Analyzer = &analysis.Analyzer{
 	Name:     azbp015Name,
 	Doc:      AZBP015Doc,
 	Run:      runAZBP015,
-	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer},
+	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer, session.Analyzer},
 }
 
 func runAZBP015(pass *analysis.Pass) (interface{}, error) {
@@ -81,7 +81,7 @@ func runAZBP015(pass *analysis.Pass) (interface{}, error) {
 				}
 
 				pos := pass.Fset.Position(callExpr.Pos())
-				if !loader.IsFileChanged(pos.Filename) {
+				if !session.From(pass).IsFileChanged(pos.Filename) {
 					return true
 				}
 				if ignorer.ShouldIgnore(azbp015Name, callExpr) {
@@ -7,9 +7,9 @@ import (
 
 	"github.com/bflad/tfproviderlint/passes/commentignore"
 	"github.com/qixialu/azurerm-linter/helper"
-	"github.com/qixialu/azurerm-linter/loader"
 	"github.com/qixialu/azurerm-linter/passes/schema"
 	"github.com/qixialu/azurerm-linter/reporting"
+	"github.com/qixialu/azurerm-linter/session"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 	"golang.org/x/tools/go/ast/inspector"
@@ -34,7 +34,33 @@ Special cases:
 - If optional fields appear before required fields, their original order is preserved
   (This happens when some resources have optional fields as part of the resource ID components)
 - The expected order assumes ID fields are in the correct order; ID field ordering is not validated
-- Nested schemas are not validated by this rule`
+- Nested schemas are not validated by this rule
+
+Example violation:
+  "name": {
+      Type:     pluginsdk.TypeString,
+      Required: true,
+  },
+  "location":            commonschema.Location(),
+  "resource_group_name": commonschema.ResourceGroupName(), // must come before location
+  "tags":                commonschema.Tags(),
+  "sku_name": { // optional fields come before tags
+      Type:     pluginsdk.TypeString,
+      Optional: true,
+  },
+
+Valid usage:
+  "name": {
+      Type:     pluginsdk.TypeString,
+      Required: true,
+  },
+  "resource_group_name": commonschema.ResourceGroupName(),
+  "location":            commonschema.Location(),
+  "sku_name": {
+      Type:     pluginsdk.TypeString,
+      Optional: true,
+  },
+  "tags": commonschema.Tags(),`
 
 const aznr001Name = "AZNR001"
 
@@ -53,6 +79,7 @@ var AZNR001Analyzer = &analysis.Analyzer{
 		inspect.Analyzer,
 		schema.CompleteSchemaAnalyzer,
 		commentignore.Analyzer,
+		session.Analyzer,
 	},
 }
 
@@ -83,7 +110,7 @@ func runAZNR001(pass *analysis.Pass) (interface{}, error) {
 
 		// Apply filename filtering
 		filename := pass.Fset.Position(comp.Pos()).Filename
-		if !loader.IsNewFile(filename) || !helper.IsResourceOrDataSourceFile(filename) {
+		if !session.From(pass).IsNewFile(filename) || !helper.IsResourceOrDataSourceFile(filename) {
 			return
 		}
 
@@ -121,12 +148,20 @@ func runAZNR001(pass *analysis.Pass) (interface{}, error) {
 			for i, f := range fields {
 				actualOrder[i] = f.Name
 			}
+			var fixes []analysis.SuggestedFix
+			if order, ok := aznr001ElementOrder(comp, fields, expectedOrder); ok {
+				if fix, ok := helper.ReorderElementsFix(pass, comp, order, "Reorder schema fields"); ok {
+					fixes = append(fixes, fix)
+				}
+			}
+
 			reporting.Reportf(pass, reporting.ReportOptions{
-				Rule:          aznr001Name,
-				ReportPos:     comp.Pos(),
-				EvidenceFile:  pos.Filename,
-				EvidenceLines: []int{pos.Line},
-				MatchMode:     reporting.MatchModeNewFile,
+				Rule:           aznr001Name,
+				ReportPos:      comp.Pos(),
+				EvidenceFile:   pos.Filename,
+				EvidenceLines:  []int{pos.Line},
+				MatchMode:      reporting.MatchModeNewFile,
+				SuggestedFixes: fixes,
 			}, "%s: %s\nExpected order (assuming ID fields are correct):\n  %s\nActual order:\n  %s\n",
 				aznr001Name, issue,
 				helper.FixedCode(strings.Join(expectedOrder, ", ")),
@@ -137,6 +172,29 @@ func runAZNR001(pass *analysis.Pass) (interface{}, error) {
 	return nil, nil
 }
 
+// aznr001ElementOrder maps the expected field order onto indexes of the schema map elements.
+// It fails when some elements were not resolved as schema fields, since those cannot be placed.
+func aznr001ElementOrder(comp *ast.CompositeLit, fields []helper.SchemaFieldInfo, expectedOrder []string) ([]int, bool) {
+	if len(comp.Elts) != len(fields) || len(expectedOrder) != len(fields) {
+		return nil, false
+	}
+
+	indexByName := make(map[string]int, len(fields))
+	for i, field := range fields {
+		indexByName[field.Name] = i
+	}
+
+	order := make([]int, 0, len(expectedOrder))
+	for _, name := range expectedOrder {
+		idx, ok := indexByName[name]
+		if !ok {
+			return nil, false
+		}
+		order = append(order, idx)
+	}
+	return order, true
+}
+
 func checkAZNR001OrderingIssues(fields []helper.SchemaFieldInfo) ([]string, string) {
 	if len(fields) == 0 {
 		return nil, ""
@@ -10,9 +10,9 @@ import (
 	"github.com/bflad/tfproviderlint/helper/astutils"
 	"github.com/bflad/tfproviderlint/passes/commentignore"
 	"github.com/qixialu/azurerm-linter/helper"
-	"github.com/qixialu/azurerm-linter/loader"
 	"github.com/qixialu/azurerm-linter/passes/schema"
 	"github.com/qixialu/azurerm-linter/reporting"
+	"github.com/qixialu/azurerm-linter/session"
 
 	"golang.org/x/tools/go/analysis"
 )
@@ -69,6 +69,7 @@ var AZNR002Analyzer = &analysis.Analyzer{
 	Requires: []*analysis.Analyzer{
 		schema.TypedResourceInfoAnalyzer,
 		commentignore.Analyzer,
+		session.Analyzer,
 	},
 }
 
@@ -340,7 +341,7 @@ func reportMissingProperties(pass *analysis.Pass, ignorer *commentignore.Ignorer
 			position := pass.Fset.Position(fieldInfo.Pos)
 			// Check if position is valid (Pos is in current pass's FileSet)
 			if position.IsValid() {
-				if !loader.IsFileChanged(position.Filename) {
+				if !session.From(pass).IsFileChanged(position.Filename) {
 					continue
 				}
 				reporting.Report(pass, reporting.ReportOptions{
@@ -362,7 +363,7 @@ func reportMissingProperties(pass *analysis.Pass, ignorer *commentignore.Ignorer
 
 		// Fallback to Update function position (for cross-package schemas)
 		if fieldInfo.Position.IsValid() {
-			if !loader.IsFileChanged(fieldInfo.Position.Filename) {
+			if !session.From(pass).IsFileChanged(fieldInfo.Position.Filename) {
 				continue
 			}
 		}
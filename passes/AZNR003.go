@@ -6,9 +6,9 @@ import (
 
 	"github.com/bflad/tfproviderlint/passes/commentignore"
 	"github.com/qixialu/azurerm-linter/helper"
-	"github.com/qixialu/azurerm-linter/loader"
 	"github.com/qixialu/azurerm-linter/passes/schema"
 	"github.com/qixialu/azurerm-linter/reporting"
+	"github.com/qixialu/azurerm-linter/session"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 	"golang.org/x/tools/go/ast/inspector"
@@ -49,11 +49,11 @@ var AZNR003Analyzer = &analysis.Analyzer{
 	Name:     aznr003Name,
 	Doc:      AZNR003Doc,
 	Run:      runAZNR003,
-	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer, schema.TypedResourceInfoAnalyzer},
+	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer, schema.TypedResourceInfoAnalyzer, session.Analyzer},
 }
 
 func runAZNR003(pass *analysis.Pass) (interface{}, error) {
-	if helper.ShouldSkipPackageForResourceAnalysis(pass.Pkg.Path()) {
+	if helper.ShouldSkipPackageForResourceAnalysis(pass.Pkg.Path(), session.From(pass).SkipPackages()) {
 		return nil, nil
 	}
 
@@ -97,7 +97,7 @@ func runAZNR003(pass *analysis.Pass) (interface{}, error) {
 
 		// Check git filter
 		pos := pass.Fset.Position(funcDecl.Pos())
-		if !loader.IsFileChanged(pos.Filename) {
+		if !session.From(pass).IsFileChanged(pos.Filename) {
 			return
 		}
 
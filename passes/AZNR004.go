@@ -7,8 +7,8 @@ import (
 
 	"github.com/bflad/tfproviderlint/passes/commentignore"
 	"github.com/qixialu/azurerm-linter/helper"
-	"github.com/qixialu/azurerm-linter/loader"
 	"github.com/qixialu/azurerm-linter/reporting"
+	"github.com/qixialu/azurerm-linter/session"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 	"golang.org/x/tools/go/ast/inspector"
@@ -52,11 +52,11 @@ var AZNR004Analyzer = &analysis.Analyzer{
 	Name:     aznr004Name,
 	Doc:      AZNR004Doc,
 	Run:      runAZNR004,
-	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer},
+	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer, session.Analyzer},
 }
 
 func runAZNR004(pass *analysis.Pass) (interface{}, error) {
-	if helper.ShouldSkipPackageForResourceAnalysis(pass.Pkg.Path()) {
+	if helper.ShouldSkipPackageForResourceAnalysis(pass.Pkg.Path(), session.From(pass).SkipPackages()) {
 		return nil, nil
 	}
 
@@ -140,7 +140,7 @@ func runAZNR004(pass *analysis.Pass) (interface{}, error) {
 			}
 
 			pos := pass.Fset.Position(retStmt.Pos())
-			if !loader.IsFileChanged(pos.Filename) {
+			if !session.From(pass).IsFileChanged(pos.Filename) {
 				return true
 			}
 
@@ -11,8 +11,8 @@ import (
 
 	"github.com/bflad/tfproviderlint/passes/commentignore"
 	"github.com/qixialu/azurerm-linter/helper"
-	"github.com/qixialu/azurerm-linter/loader"
 	"github.com/qixialu/azurerm-linter/reporting"
+	"github.com/qixialu/azurerm-linter/session"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 	"golang.org/x/tools/go/ast/inspector"
@@ -59,11 +59,11 @@ var AZNR005Analyzer = &analysis.Analyzer{
 	Name:     aznr005Name,
 	Doc:      AZNR005Doc,
 	Run:      runAZNR005,
-	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer},
+	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer, session.Analyzer},
 }
 
 func runAZNR005(pass *analysis.Pass) (interface{}, error) {
-	if helper.ShouldSkipPackageForResourceAnalysis(pass.Pkg.Path()) {
+	if helper.ShouldSkipPackageForResourceAnalysis(pass.Pkg.Path(), session.From(pass).SkipPackages()) {
 		return nil, nil
 	}
 
@@ -82,7 +82,7 @@ func runAZNR005(pass *analysis.Pass) (interface{}, error) {
 		if !strings.HasSuffix(filepath.Base(pos.Filename), "registration.go") {
 			continue
 		}
-		if !loader.IsFileChanged(pos.Filename) {
+		if !session.From(pass).IsFileChanged(pos.Filename) {
 			continue
 		}
 
@@ -272,13 +272,28 @@ func validateSorting(pass *analysis.Pass, compositeLit *ast.CompositeLit) bool {
 		evidenceLines = append(evidenceLines, pos.Line)
 	}
 
+	var fixes []analysis.SuggestedFix
+	if len(registrations) == len(compositeLit.Elts) {
+		order := make([]int, len(registrations))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(i, j int) bool {
+			return registrations[order[i]] < registrations[order[j]]
+		})
+		if fix, ok := helper.ReorderElementsFix(pass, compositeLit, order, "Sort registrations alphabetically"); ok {
+			fixes = append(fixes, fix)
+		}
+	}
+
 	reporting.Report(pass, reporting.ReportOptions{
-		Rule:          aznr005Name,
-		ReportPos:     compositeLit.Pos(),
-		Message:       aznr005Name + ": " + helper.FixedCode("registrations should be sorted alphabetically") + "\n",
-		EvidenceFile:  pass.Fset.Position(compositeLit.Pos()).Filename,
-		EvidenceLines: evidenceLines,
-		MatchMode:     reporting.MatchModeSameHunk,
+		Rule:           aznr005Name,
+		ReportPos:      compositeLit.Pos(),
+		Message:        aznr005Name + ": " + helper.FixedCode("registrations should be sorted alphabetically") + "\n",
+		EvidenceFile:   pass.Fset.Position(compositeLit.Pos()).Filename,
+		EvidenceLines:  evidenceLines,
+		MatchMode:      reporting.MatchModeSameHunk,
+		SuggestedFixes: fixes,
 	})
 
 	return true
@@ -7,19 +7,19 @@ import (
 
 	"github.com/qixialu/azurerm-linter/loader"
 	"github.com/qixialu/azurerm-linter/passes"
+	"github.com/qixialu/azurerm-linter/session"
+	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/analysistest"
 )
 
-func resetChangesForTest(t *testing.T) {
-	t.Helper()
-	if _, err := loader.LoadChanges(loader.LoaderOptions{NoFilter: true}); err != nil {
-		t.Fatalf("LoadChanges() cleanup error = %v", err)
-	}
+// analyzerWithChanges binds analyzer to a session filtering by cs
+func analyzerWithChanges(analyzer *analysis.Analyzer, cs *loader.ChangeSet) *analysis.Analyzer {
+	return session.Bind([]*analysis.Analyzer{analyzer}, session.New(cs))[0]
 }
 
 func TestAZNR005(t *testing.T) {
 	testdata := analysistest.TestData()
-	analysistest.Run(t, testdata, passes.AZNR005Analyzer, "testdata/src/aznr005")
+	analysistest.RunWithSuggestedFixes(t, testdata, passes.AZNR005Analyzer, "testdata/src/aznr005")
 }
 
 func TestAZNR005DeletionOnlyDiffStillReportsInFilteredMode(t *testing.T) {
@@ -40,14 +40,12 @@ index 1111111..2222222 100644
 		t.Fatalf("WriteFile() error = %v", err)
 	}
 
-	if _, err := loader.LoadChanges(loader.LoaderOptions{DiffFile: diffPath}); err != nil {
+	cs, err := loader.LoadChanges(loader.LoaderOptions{DiffFile: diffPath})
+	if err != nil {
 		t.Fatalf("LoadChanges() error = %v", err)
 	}
-	t.Cleanup(func() {
-		resetChangesForTest(t)
-	})
 
-	analysistest.Run(t, testdata, passes.AZNR005Analyzer, "testdata/src/internal/services/cdn")
+	analysistest.Run(t, testdata, analyzerWithChanges(passes.AZNR005Analyzer, cs), "testdata/src/internal/services/cdn")
 }
 
 func TestAZNR005DeletionOnlyHunkStillReportsWhenOtherLinesChanged(t *testing.T) {
@@ -71,14 +69,12 @@ index 1111111..2222222 100644
 		t.Fatalf("WriteFile() error = %v", err)
 	}
 
-	if _, err := loader.LoadChanges(loader.LoaderOptions{DiffFile: diffPath}); err != nil {
+	cs, err := loader.LoadChanges(loader.LoaderOptions{DiffFile: diffPath})
+	if err != nil {
 		t.Fatalf("LoadChanges() error = %v", err)
 	}
-	t.Cleanup(func() {
-		resetChangesForTest(t)
-	})
 
-	analysistest.Run(t, testdata, passes.AZNR005Analyzer, "testdata/src/internal/services/cdn")
+	analysistest.Run(t, testdata, analyzerWithChanges(passes.AZNR005Analyzer, cs), "testdata/src/internal/services/cdn")
 }
 
 func TestAZNR005FilteredModeKeepsLaterChangedUnsortedSection(t *testing.T) {
@@ -99,14 +95,12 @@ index 1111111..2222222 100644
 		t.Fatalf("WriteFile() error = %v", err)
 	}
 
-	if _, err := loader.LoadChanges(loader.LoaderOptions{DiffFile: diffPath}); err != nil {
+	cs, err := loader.LoadChanges(loader.LoaderOptions{DiffFile: diffPath})
+	if err != nil {
 		t.Fatalf("LoadChanges() error = %v", err)
 	}
-	t.Cleanup(func() {
-		resetChangesForTest(t)
-	})
 
-	analysistest.Run(t, testdata, passes.AZNR005Analyzer, "testdata/src/internal/services/cdnsections")
+	analysistest.Run(t, testdata, analyzerWithChanges(passes.AZNR005Analyzer, cs), "testdata/src/internal/services/cdnsections")
 }
 
 func TestAZNR005FilteredModeKeepsChangedSortedSectionWhenLaterSectionIsUnsorted(t *testing.T) {
@@ -127,14 +121,12 @@ index 1111111..2222222 100644
 		t.Fatalf("WriteFile() error = %v", err)
 	}
 
-	if _, err := loader.LoadChanges(loader.LoaderOptions{DiffFile: diffPath}); err != nil {
+	cs, err := loader.LoadChanges(loader.LoaderOptions{DiffFile: diffPath})
+	if err != nil {
 		t.Fatalf("LoadChanges() error = %v", err)
 	}
-	t.Cleanup(func() {
-		resetChangesForTest(t)
-	})
 
-	analysistest.Run(t, testdata, passes.AZNR005Analyzer, "testdata/src/internal/services/cdnsections")
+	analysistest.Run(t, testdata, analyzerWithChanges(passes.AZNR005Analyzer, cs), "testdata/src/internal/services/cdnsections")
 }
 
 func TestAZNR005FilteredModeKeepsGloballyUnsortedSectionedLiteral(t *testing.T) {
@@ -155,12 +147,10 @@ index 1111111..2222222 100644
 		t.Fatalf("WriteFile() error = %v", err)
 	}
 
-	if _, err := loader.LoadChanges(loader.LoaderOptions{DiffFile: diffPath}); err != nil {
+	cs, err := loader.LoadChanges(loader.LoaderOptions{DiffFile: diffPath})
+	if err != nil {
 		t.Fatalf("LoadChanges() error = %v", err)
 	}
-	t.Cleanup(func() {
-		resetChangesForTest(t)
-	})
 
-	analysistest.Run(t, testdata, passes.AZNR005Analyzer, "testdata/src/internal/services/cdnsections")
+	analysistest.Run(t, testdata, analyzerWithChanges(passes.AZNR005Analyzer, cs), "testdata/src/internal/services/cdnsections")
 }
@@ -7,8 +7,8 @@ import (
 	"strings"
 
 	"github.com/bflad/tfproviderlint/passes/commentignore"
-	"github.com/qixialu/azurerm-linter/loader"
 	"github.com/qixialu/azurerm-linter/reporting"
+	"github.com/qixialu/azurerm-linter/session"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 	"golang.org/x/tools/go/ast/inspector"
@@ -52,7 +52,7 @@ var AZNR006Analyzer = &analysis.Analyzer{
 	Name:     aznr006Name,
 	Doc:      AZNR006Doc,
 	Run:      runAZNR006,
-	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer},
+	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer, session.Analyzer},
 }
 
 func runAZNR006(pass *analysis.Pass) (interface{}, error) {
@@ -85,7 +85,7 @@ func runAZNR006(pass *analysis.Pass) (interface{}, error) {
 		}
 
 		pos := pass.Fset.Position(ifStmt.Pos())
-		if !loader.IsFileChanged(pos.Filename) || ignorer.ShouldIgnore(aznr006Name, ifStmt) {
+		if !session.From(pass).IsFileChanged(pos.Filename) || ignorer.ShouldIgnore(aznr006Name, ifStmt) {
 			return
 		}
 
@@ -9,8 +9,8 @@ import (
 
 	"github.com/bflad/tfproviderlint/passes/commentignore"
 	"github.com/qixialu/azurerm-linter/helper"
-	"github.com/qixialu/azurerm-linter/loader"
 	"github.com/qixialu/azurerm-linter/reporting"
+	"github.com/qixialu/azurerm-linter/session"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 	"golang.org/x/tools/go/ast/inspector"
@@ -46,7 +46,7 @@ var AZNR007Analyzer = &analysis.Analyzer{
 	Name:     aznr007Name,
 	Doc:      AZNR007Doc,
 	Run:      runAZNR007,
-	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer},
+	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer, session.Analyzer},
 }
 
 // aznr007NameValueRegex matches top-level HCL name attributes (2-space indent) with a quoted string value.
@@ -123,7 +123,7 @@ func runAZNR007(pass *analysis.Pass) (interface{}, error) {
 				matchLine += strings.Count(value[:loc[0]], "\n")
 			}
 
-			if !loader.IsFileChanged(pos.Filename) {
+			if !session.From(pass).IsFileChanged(pos.Filename) {
 				continue
 			}
 
@@ -8,8 +8,8 @@ import (
 
 	"github.com/bflad/tfproviderlint/passes/commentignore"
 	"github.com/qixialu/azurerm-linter/helper"
-	"github.com/qixialu/azurerm-linter/loader"
 	"github.com/qixialu/azurerm-linter/reporting"
+	"github.com/qixialu/azurerm-linter/session"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 	"golang.org/x/tools/go/ast/inspector"
@@ -43,7 +43,7 @@ var AZNR008Analyzer = &analysis.Analyzer{
 	Name:     aznr008Name,
 	Doc:      AZNR008Doc,
 	Run:      runAZNR008,
-	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer},
+	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer, session.Analyzer},
 }
 
 var aznr008HardcodedIdRegex = regexp.MustCompile(`/subscriptions/[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}/`)
@@ -95,7 +95,7 @@ func runAZNR008(pass *analysis.Pass) (interface{}, error) {
 			matchLine += strings.Count(value[:loc[0]], "\n")
 		}
 
-		if !loader.IsFileChanged(pos.Filename) {
+		if !session.From(pass).IsFileChanged(pos.Filename) {
 			return
 		}
 
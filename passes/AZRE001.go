@@ -3,12 +3,13 @@ package passes
 import (
 	"go/ast"
 	"go/token"
+	"go/types"
 	"strings"
 
 	"github.com/bflad/tfproviderlint/passes/commentignore"
 	"github.com/qixialu/azurerm-linter/helper"
-	"github.com/qixialu/azurerm-linter/loader"
 	"github.com/qixialu/azurerm-linter/reporting"
+	"github.com/qixialu/azurerm-linter/session"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 	"golang.org/x/tools/go/ast/inspector"
@@ -32,7 +33,7 @@ var AZRE001Analyzer = &analysis.Analyzer{
 	Name:     azre001Name,
 	Doc:      AZRE001Doc,
 	Run:      runAZRE001,
-	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer},
+	Requires: []*analysis.Analyzer{inspect.Analyzer, commentignore.Analyzer, session.Analyzer},
 }
 
 func runAZRE001(pass *analysis.Pass) (interface{}, error) {
@@ -42,12 +43,12 @@ func runAZRE001(pass *analysis.Pass) (interface{}, error) {
 	}
 
 	// Pre-filter: Build set of changed files that import "fmt"
-	relevantFiles := make(map[string]bool)
+	relevantFiles := make(map[string]*ast.File)
 	for _, f := range pass.Files {
 		filename := pass.Fset.Position(f.Pos()).Filename
 
 		// Skip if not changed
-		if !loader.IsFileChanged(filename) {
+		if !session.From(pass).IsFileChanged(filename) {
 			continue
 		}
 
@@ -61,7 +62,7 @@ func runAZRE001(pass *analysis.Pass) (interface{}, error) {
 		}
 
 		if importsFmt {
-			relevantFiles[filename] = true
+			relevantFiles[filename] = f
 		}
 	}
 
@@ -76,6 +77,9 @@ func runAZRE001(pass *analysis.Pass) (interface{}, error) {
 		return nil, nil
 	}
 
+	// Number of references to fmt in each relevant file, counted on first use
+	fmtUses := make(map[*ast.File]int)
+
 	// Pre-filter: only look at CallExpr nodes
 	nodeFilter := []ast.Node{
 		(*ast.CallExpr)(nil),
@@ -90,7 +94,8 @@ func runAZRE001(pass *analysis.Pass) (interface{}, error) {
 		// Use pre-computed Position for both filename and line number
 		pos := pass.Fset.Position(call.Pos())
 		filename := pos.Filename
-		if !relevantFiles[filename] {
+		file, ok := relevantFiles[filename]
+		if !ok {
 			return
 		}
 
@@ -132,12 +137,18 @@ func runAZRE001(pass *analysis.Pass) (interface{}, error) {
 				return
 			}
 
+			var fixes []analysis.SuggestedFix
+			if fix, ok := azre001Fix(pass, file, call, lit, fmtUses); ok {
+				fixes = append(fixes, fix)
+			}
+
 			reporting.Reportf(pass, reporting.ReportOptions{
-				Rule:          azre001Name,
-				ReportPos:     call.Pos(),
-				EvidenceFile:  filename,
-				EvidenceLines: []int{pos.Line},
-				MatchMode:     reporting.MatchModeExactAdded,
+				Rule:           azre001Name,
+				ReportPos:      call.Pos(),
+				EvidenceFile:   filename,
+				EvidenceLines:  []int{pos.Line},
+				MatchMode:      reporting.MatchModeExactAdded,
+				SuggestedFixes: fixes,
 			}, "%s: fixed error strings should use %s instead of %s\n",
 				azre001Name,
 				helper.FixedCode("errors.New()"),
@@ -147,3 +158,52 @@ func runAZRE001(pass *analysis.Pass) (interface{}, error) {
 
 	return nil, nil
 }
+
+// azre001Fix returns the fix replacing call with errors.New. The fix imports errors unless the
+// file already does, and drops the fmt import when call is its last use. There is no fix when
+// the name errors refers to something else at call, such as github.com/pkg/errors.
+func azre001Fix(pass *analysis.Pass, file *ast.File, call *ast.CallExpr, lit *ast.BasicLit, fmtUses map[*ast.File]int) (analysis.SuggestedFix, bool) {
+	importsErrors := false
+	if _, obj := pass.Pkg.Scope().Innermost(call.Pos()).LookupParent("errors", call.Pos()); obj != nil {
+		pkgName, ok := obj.(*types.PkgName)
+		if !ok || pkgName.Imported().Path() != "errors" {
+			return analysis.SuggestedFix{}, false
+		}
+		importsErrors = true
+	}
+
+	if _, ok := fmtUses[file]; !ok {
+		fmtUses[file] = countPackageUses(pass, file, "fmt")
+	}
+	lastFmtUse := fmtUses[file] == 1
+
+	fix := helper.ReplaceNodeFix("Use errors.New", call, "errors.New("+lit.Value+")")
+	var importEdit *analysis.TextEdit
+	switch {
+	case importsErrors && lastFmtUse:
+		importEdit = helper.DeleteImportEdit(file, "fmt")
+	case importsErrors:
+	case lastFmtUse:
+		importEdit = helper.ReplaceImportEdit(file, "fmt", "errors")
+	default:
+		importEdit = helper.AddImportEdit(file, "errors")
+	}
+	if importEdit != nil {
+		fix.TextEdits = append(fix.TextEdits, *importEdit)
+	}
+	return fix, true
+}
+
+// countPackageUses returns the number of references in file to the package imported from path
+func countPackageUses(pass *analysis.Pass, file *ast.File, path string) int {
+	uses := 0
+	ast.Inspect(file, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok {
+			if pkgName, ok := pass.TypesInfo.Uses[ident].(*types.PkgName); ok && pkgName.Imported().Path() == path {
+				uses++
+			}
+		}
+		return true
+	})
+	return uses
+}
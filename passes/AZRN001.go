@@ -5,9 +5,9 @@ import (
 
 	"github.com/bflad/tfproviderlint/passes/commentignore"
 	"github.com/qixialu/azurerm-linter/helper"
-	"github.com/qixialu/azurerm-linter/loader"
 	localschema "github.com/qixialu/azurerm-linter/passes/schema"
 	"github.com/qixialu/azurerm-linter/reporting"
+	"github.com/qixialu/azurerm-linter/session"
 	"golang.org/x/tools/go/analysis"
 )
 
@@ -33,6 +33,7 @@ var AZRN001Analyzer = &analysis.Analyzer{
 	Requires: []*analysis.Analyzer{
 		localschema.LocalAnalyzer,
 		commentignore.Analyzer,
+		session.Analyzer,
 	},
 }
 
@@ -58,15 +59,24 @@ func runAZRN001(pass *analysis.Pass) (interface{}, error) {
 		if strings.Contains(fieldName, "_in_percent") {
 			suggestedName := strings.ReplaceAll(fieldName, "_in_percent", "_percentage")
 			pos := pass.Fset.Position(schemaLit.Pos())
-			if !loader.IsFileChanged(pos.Filename) {
+			if !session.From(pass).IsFileChanged(pos.Filename) {
 				continue
 			}
+
+			var fixes []analysis.SuggestedFix
+			if cached.Key != nil {
+				// Only the schema map key is renamed; references elsewhere (models, tfschema tags) are left to the author
+				fixes = append(fixes, helper.ReplaceNodeFix("Rename to "+suggestedName, cached.Key,
+					strings.ReplaceAll(cached.Key.Value, "_in_percent", "_percentage")))
+			}
+
 			reporting.Reportf(pass, reporting.ReportOptions{
-				Rule:          azrn001Name,
-				ReportPos:     schemaLit.Pos(),
-				EvidenceFile:  pos.Filename,
-				EvidenceLines: []int{pos.Line},
-				MatchMode:     reporting.MatchModeExactAdded,
+				Rule:           azrn001Name,
+				ReportPos:      schemaLit.Pos(),
+				EvidenceFile:   pos.Filename,
+				EvidenceLines:  []int{pos.Line},
+				MatchMode:      reporting.MatchModeExactAdded,
+				SuggestedFixes: fixes,
 			}, "%s: field %q should use %s suffix instead of %s (suggested: %q)\n",
 				azrn001Name, fieldName,
 				helper.FixedCode("'_percentage'"),
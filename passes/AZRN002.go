@@ -6,9 +6,9 @@ import (
 	"github.com/bflad/tfproviderlint/helper/terraformtype/helper/schema"
 	"github.com/bflad/tfproviderlint/passes/commentignore"
 	"github.com/qixialu/azurerm-linter/helper"
-	"github.com/qixialu/azurerm-linter/loader"
 	localschema "github.com/qixialu/azurerm-linter/passes/schema"
 	"github.com/qixialu/azurerm-linter/reporting"
+	"github.com/qixialu/azurerm-linter/session"
 	"golang.org/x/tools/go/analysis"
 )
 
@@ -34,6 +34,7 @@ var AZRN002Analyzer = &analysis.Analyzer{
 	Requires: []*analysis.Analyzer{
 		localschema.LocalAnalyzer,
 		commentignore.Analyzer,
+		session.Analyzer,
 	},
 }
 
@@ -64,7 +65,7 @@ func runAZRN002(pass *analysis.Pass) (interface{}, error) {
 			suggestedName := strings.ReplaceAll(fieldName, "is_", "")
 			pos := pass.Fset.Position(schemaLit.Pos())
 
-			if !loader.IsFileChanged(pos.Filename) {
+			if !session.From(pass).IsFileChanged(pos.Filename) {
 				continue
 			}
 
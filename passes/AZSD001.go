@@ -6,9 +6,9 @@ import (
 	"github.com/bflad/tfproviderlint/helper/terraformtype/helper/schema"
 	"github.com/bflad/tfproviderlint/passes/commentignore"
 	"github.com/qixialu/azurerm-linter/helper"
-	"github.com/qixialu/azurerm-linter/loader"
 	localschema "github.com/qixialu/azurerm-linter/passes/schema"
 	"github.com/qixialu/azurerm-linter/reporting"
+	"github.com/qixialu/azurerm-linter/session"
 	"golang.org/x/tools/go/analysis"
 )
 
@@ -49,7 +49,7 @@ var AZSD001Analyzer = &analysis.Analyzer{
 	Name:     azsd001Name,
 	Doc:      AZSD001Doc,
 	Run:      runAZSD001,
-	Requires: []*analysis.Analyzer{localschema.LocalAnalyzer, commentignore.Analyzer},
+	Requires: []*analysis.Analyzer{localschema.LocalAnalyzer, commentignore.Analyzer, session.Analyzer},
 }
 
 func runAZSD001(pass *analysis.Pass) (interface{}, error) {
@@ -155,7 +155,7 @@ func runAZSD001(pass *analysis.Pass) (interface{}, error) {
 
 			if !hasComment {
 				pos := pass.Fset.Position(schemaLit.Pos())
-				if !loader.IsFileChanged(pos.Filename) {
+				if !session.From(pass).IsFileChanged(pos.Filename) {
 					continue
 				}
 				if propertyName := cached.PropertyName; propertyName != "" {
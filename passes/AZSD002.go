@@ -6,9 +6,9 @@ import (
 	"github.com/bflad/tfproviderlint/helper/terraformtype/helper/schema"
 	"github.com/bflad/tfproviderlint/passes/commentignore"
 	"github.com/qixialu/azurerm-linter/helper"
-	"github.com/qixialu/azurerm-linter/loader"
 	localschema "github.com/qixialu/azurerm-linter/passes/schema"
 	"github.com/qixialu/azurerm-linter/reporting"
+	"github.com/qixialu/azurerm-linter/session"
 	"golang.org/x/tools/go/analysis"
 )
 
@@ -68,6 +68,7 @@ var AZSD002Analyzer = &analysis.Analyzer{
 	Requires: []*analysis.Analyzer{
 		localschema.LocalAnalyzer,
 		commentignore.Analyzer,
+		session.Analyzer,
 	},
 }
 
@@ -172,7 +173,7 @@ func runAZSD002(pass *analysis.Pass) (interface{}, error) {
 		// and none of them have AtLeastOneOf set
 		if !hasRequiredField && !hasDefaultValue && !hasAtLeastOneOfOrExactlyOneOf && optionalFieldsCount >= 2 {
 			pos := pass.Fset.Position(schemaLit.Pos())
-			if !loader.IsFileChanged(pos.Filename) {
+			if !session.From(pass).IsFileChanged(pos.Filename) {
 				continue
 			}
 			if propertyName := cached.PropertyName; propertyName != "" {
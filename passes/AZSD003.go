@@ -10,9 +10,9 @@ import (
 	"github.com/bflad/tfproviderlint/helper/terraformtype/helper/schema"
 	"github.com/bflad/tfproviderlint/passes/commentignore"
 	"github.com/qixialu/azurerm-linter/helper"
-	"github.com/qixialu/azurerm-linter/loader"
 	localschema "github.com/qixialu/azurerm-linter/passes/schema"
 	"github.com/qixialu/azurerm-linter/reporting"
+	"github.com/qixialu/azurerm-linter/session"
 	"golang.org/x/tools/go/analysis"
 )
 
@@ -55,6 +55,7 @@ var AZSD003Analyzer = &analysis.Analyzer{
 	Requires: []*analysis.Analyzer{
 		localschema.LocalAnalyzer,
 		commentignore.Analyzer,
+		session.Analyzer,
 	},
 }
 
@@ -111,7 +112,7 @@ func runAZSD003(pass *analysis.Pass) (interface{}, error) {
 
 		if len(redundantFields) > 0 {
 			evidenceFile, evidenceLines := schemaLitEvidence(pass, schemaLit, exactlyOneOfFile, exactlyOneOfLines, conflictsWithFile, conflictsWithLines)
-			if !loader.IsFileChanged(evidenceFile) {
+			if !session.From(pass).IsFileChanged(evidenceFile) {
 				continue
 			}
 			reporting.Reportf(pass, reporting.ReportOptions{
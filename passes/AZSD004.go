@@ -7,9 +7,9 @@ import (
 	"github.com/bflad/tfproviderlint/helper/terraformtype/helper/schema"
 	"github.com/bflad/tfproviderlint/passes/commentignore"
 	"github.com/qixialu/azurerm-linter/helper"
-	"github.com/qixialu/azurerm-linter/loader"
 	localschema "github.com/qixialu/azurerm-linter/passes/schema"
 	"github.com/qixialu/azurerm-linter/reporting"
+	"github.com/qixialu/azurerm-linter/session"
 	"golang.org/x/tools/go/analysis"
 )
 
@@ -64,7 +64,7 @@ var AZSD004Analyzer = &analysis.Analyzer{
 	Name:     azsd004Name,
 	Doc:      AZSD004Doc,
 	Run:      runAZSD004,
-	Requires: []*analysis.Analyzer{localschema.LocalAnalyzer, commentignore.Analyzer},
+	Requires: []*analysis.Analyzer{localschema.LocalAnalyzer, commentignore.Analyzer, session.Analyzer},
 }
 
 func runAZSD004(pass *analysis.Pass) (interface{}, error) {
@@ -122,7 +122,7 @@ func checkSchemaForViolations(pass *analysis.Pass, schemaInfo *schema.SchemaInfo
 		return
 	}
 
-	if !loader.IsFileChanged(pos.Filename) {
+	if !session.From(pass).IsFileChanged(pos.Filename) {
 		return
 	}
 
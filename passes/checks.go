@@ -38,3 +38,11 @@ var AllChecks = []*analysis.Analyzer{
 	AZNR006Analyzer,
 	AZNR008Analyzer,
 }
+
+// DeprecatedChecks contains the Analyzers of deprecated checks. They no longer run, but are
+// kept so their documentation stays available.
+var DeprecatedChecks = []*analysis.Analyzer{
+	AZBP015Analyzer,
+	AZNR003Analyzer,
+	AZNR007Analyzer,
+}
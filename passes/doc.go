@@ -1,340 +1,15 @@
 // Package passes provides analysis passes for the azurerm-linter.
 //
 // This package contains multiple analyzers that enforce coding standards and best practices
-// for the Azure Resource Manager Terraform Provider (azurerm).
+// for the Azure Resource Manager Terraform Provider (azurerm). Each analyzer documents its
+// check, with flagged and correct examples, in its Doc; the category, severity, contributing
+// guide reference and lifecycle of each check are declared in package rules.
 //
-// # Available Analyzers
+// The rule pages in docs/rules are generated from both:
 //
-// # AZBP001 - String Validation Check
+//	azurerm-linter explain --markdown=docs/rules
 //
-// Reports when String type schema fields (Required or Optional) do not have a ValidateFunc.
+// and the documentation of a single check is printed with:
 //
-// Reference: https://github.com/hashicorp/terraform-provider-azurerm/blob/main/contributing/topics/guide-new-fields-to-resource.md#schema
-//
-// Flagged:
-//
-//	"name": {
-//	    Type:     pluginsdk.TypeString,
-//	    Required: true,
-//	    // Missing ValidateFunc!
-//	}
-//
-// Correct:
-//
-//	"name": {
-//	    Type:         pluginsdk.TypeString,
-//	    Required:     true,
-//	    ValidateFunc: validation.StringIsNotEmpty,
-//	}
-//
-// AZBP002 - Optional+Computed Documentation Check
-//
-// Reports when schema properties are marked as both Optional and Computed without
-// proper documentation explaining why this pattern is necessary.
-//
-// Reference: https://github.com/hashicorp/terraform-provider-azurerm/blob/main/contributing/topics/best-practices.md#setting-properties-to-optional--computed
-//
-// Flagged:
-//
-//	"etag": {
-//	    Type:     pluginsdk.TypeString,
-//	    Optional: true,
-//	    Computed: true,  // Missing NOTE: O+C comment
-//	}
-//
-// Correct:
-//
-//	"etag": {
-//	    Type:     pluginsdk.TypeString,
-//	    Optional: true,
-//	    // NOTE: O+C Azure generates a new value every time this resource is updated
-//	    Computed: true,
-//	}
-//
-// AZSD001 - MaxItems:1 Flattening Check
-//
-// Reports when blocks with MaxItems: 1 contain only a single nested property without
-// proper justification. These should typically be flattened for better user experience.
-//
-// Flagged:
-//
-//	"config": {
-//	    Type:     pluginsdk.TypeList,
-//	    MaxItems: 1,
-//	    Elem: &pluginsdk.Resource{
-//	        Schema: map[string]*pluginsdk.Schema{
-//	            "value": {...},  // Only one property - should be flattened
-//	        },
-//	    },
-//	}
-//
-// Correct (flattened):
-//
-//	"config_value": {...}
-//
-// Correct (with explanation):
-//
-//	"config": {
-//	    Type:     pluginsdk.TypeList,
-//	    MaxItems: 1,
-//	    // Additional properties will be added per service team confirmation
-//	    Elem: &pluginsdk.Resource{
-//	        Schema: map[string]*pluginsdk.Schema{
-//	            "value": {...},
-//	        },
-//	    },
-//	}
-//
-// # AZSD002 - AtLeastOneOf Validation for TypeList Fields
-//
-// Reports when a TypeList block contains multiple optional nested fields but none
-// of them have AtLeastOneOf validation set. When all nested fields are optional,
-// at least one should use AtLeastOneOf to ensure users specify at least one option.
-//
-// Flagged:
-//
-//	"setting": {
-//	    Type:     pluginsdk.TypeList,
-//	    Optional: true,
-//	    MaxItems: 1,
-//	    Elem: &pluginsdk.Resource{
-//	        Schema: map[string]*pluginsdk.Schema{
-//	            "linux": {
-//	                Type:     pluginsdk.TypeList,
-//	                Optional: true,
-//	                // Missing AtLeastOneOf!
-//	            },
-//	            "windows": {
-//	                Type:     pluginsdk.TypeList,
-//	                Optional: true,
-//	                // Missing AtLeastOneOf!
-//	            },
-//	        },
-//	    },
-//	}
-//
-// Correct:
-//
-//	"setting": {
-//	    Type:     pluginsdk.TypeList,
-//	    Optional: true,
-//	    MaxItems: 1,
-//	    Elem: &pluginsdk.Resource{
-//	        Schema: map[string]*pluginsdk.Schema{
-//	            "linux": {
-//	                Type:     pluginsdk.TypeList,
-//	                Optional: true,
-//	                AtLeastOneOf: []string{"setting.0.linux", "setting.0.windows"},
-//	            },
-//	            "windows": {
-//	                Type:     pluginsdk.TypeList,
-//	                Optional: true,
-//	                AtLeastOneOf: []string{"setting.0.linux", "setting.0.windows"},
-//	            },
-//	        },
-//	    },
-//	}
-//
-// # AZBP003 - Enum Conversion Convention
-//
-// Reports when go-azure-sdk enum types are converted using pointer.To() with
-// explicit type conversion instead of the generic pointer.ToEnum[T]() function.
-// Using ToEnum is safer as it's specifically designed for enum types.
-//
-// Flagged:
-//
-//	return &managedclusters.ManagedClusterBootstrapProfile{
-//	    ArtifactSource: pointer.To(managedclusters.ArtifactSource(config["artifact_source"].(string))),
-//	}
-//
-// Correct:
-//
-//	return &managedclusters.ManagedClusterBootstrapProfile{
-//	    ArtifactSource: pointer.ToEnum[managedclusters.ArtifactSource](config["artifact_source"].(string)),
-//	}
-//
-// # AZBP004 - Pointer Dereferencing Convention
-//
-// Reports when a variable is initialized to its zero value and then conditionally
-// assigned via pointer dereferencing. This pattern can be simplified using pointer.From(),
-// which is more concise and safely handles nil cases by returning the zero value.
-//
-// Flagged:
-//
-//	name := ""
-//	if input.Name != nil {
-//	    name = *input.Name
-//	}
-//
-// Correct:
-//
-//	name := pointer.From(input.Name)
-//
-// # AZBP005 - License Header Check
-//
-// Reports when Go source files do not have the required licensing header at the
-// very beginning of the file.
-//
-// Flagged:
-//
-//	package main  // Missing license header!
-//
-//	func main() {}
-//
-// Correct:
-//
-//	// Copyright IBM Corp. 2014, 2025
-//	// SPDX-License-Identifier: MPL-2.0
-//
-//	package main
-//
-//	func main() {}
-//
-// # AZRN001 - Percentage Suffix Convention
-//
-// Reports when percentage properties use '_in_percent' suffix instead of the
-// standardized '_percentage' suffix.
-//
-// Flagged:
-//
-//	"cpu_threshold_in_percent": {...}
-//
-// Correct:
-//
-//	"cpu_threshold_percentage": {...}
-//
-// # AZRE001 - Error Creation Convention
-//
-// Reports when fixed error strings (without format placeholders) use fmt.Errorf()
-// instead of errors.New().
-//
-// Flagged:
-//
-//	return fmt.Errorf("something went wrong")
-//
-// Correct:
-//
-//	return errors.New("something went wrong")
-//	return fmt.Errorf("value %s is invalid", value)  // with placeholder, OK
-//
-// # AZNR001 - Schema Field Ordering
-//
-// Reports when schema fields are not ordered according to the provider's conventions.
-// For top-level schemas, checks that name, resource_group_name, and location appear
-// in the correct relative order. For nested schemas, checks that required, optional,
-// and computed fields are properly grouped and alphabetically sorted.
-//
-// Reference: https://github.com/hashicorp/terraform-provider-azurerm/blob/main/contributing/topics/guide-new-resource.md
-//
-// # When git filter is enabled, it only validates on newly created files
-//
-// Required order:
-//  1. Special ID fields (name, resource_group_name in order)
-//  2. Location field
-//  3. Required fields (sorted alphabetically for nested schemas)
-//  4. Optional fields (sorted alphabetically)
-//  5. Computed fields (sorted alphabetically)
-//
-// # AZNR002 - Update Function Property Handling
-//
-// Reports when updatable properties (not marked as ForceNew) are not properly handled
-// in the Update function for typed resources. This ensures that all properties that can
-// be updated are explicitly checked for changes.
-//
-// Reference: https://github.com/hashicorp/terraform-provider-azurerm/blob/main/contributing/topics/guide-new-resource.md
-//
-// Flagged:
-//
-//	// In Arguments()
-//	"display_name": {
-//	    Type:     pluginsdk.TypeString,
-//	    Required: true,
-//	    // No ForceNew - this is updatable
-//	}
-//
-//	// In Update() - missing HasChange check
-//	func (r Resource) Update() sdk.ResourceFunc {
-//	    return sdk.ResourceFunc{
-//	        Func: func(ctx context.Context, metadata sdk.ResourceMetaData) error {
-//	            // Missing: if metadata.ResourceData.HasChange("display_name") { ... }
-//	            return nil
-//	        },
-//	    }
-//	}
-//
-// Correct:
-//
-//	func (r Resource) Update() sdk.ResourceFunc {
-//	    return sdk.ResourceFunc{
-//	        Func: func(ctx context.Context, metadata sdk.ResourceMetaData) error {
-//	            if metadata.ResourceData.HasChange("display_name") {
-//	                props.DisplayName = pointer.To(config.DisplayName)
-//	            }
-//	            return nil
-//	        },
-//	    }
-//	}
-//
-// # AZBP012 - Avoid Unnecessary Else
-//
-// Reports when an if/else block assigns a value to the same target in both
-// branches. The else value should be set as a default before the if statement.
-//
-// Flagged:
-//
-//	if len(regions) != 0 {
-//	    props.Type = pointer.To(TypeManaged)
-//	} else {
-//	    props.Type = pointer.To(TypeUnmanaged)
-//	}
-//
-// Correct:
-//
-//	props.Type = pointer.To(TypeUnmanaged)
-//	if len(regions) != 0 {
-//	    props.Type = pointer.To(TypeManaged)
-//	}
-//
-// # AZNR007 - Test Resource Name Prefix
-//
-// Reports when top-level name attributes in HCL test configurations do not
-// start with "acctest". Only the first-level name attribute (2-space indentation)
-// inside a resource block is checked.
-//
-// Flagged:
-//
-//	name = "acckv%[1]d"
-//	name = "sdsds"
-//	name = "myresource%d"
-//
-// Correct:
-//
-//	name = "acctestkv%[1]d"
-//	name = "acctestresource%d"
-//
-// # AZNR003 - Expand/Flatten Function Convention
-//
-// Reports when expand* or flatten* functions are defined as global/package-level
-// functions instead of receiver methods on a resource type.
-//
-// Flagged:
-//
-//	// Global function - should be a receiver method
-//	func expandCustomerManagedKey(input []CustomerManagedKey) (*Encryption, error) {
-//	    // ...
-//	}
-//
-//	func flattenNetworkACLs(input *NetworkRuleSet) []NetworkACLs {
-//	    // ...
-//	}
-//
-// Correct:
-//
-//	func (r AIServices) expandCustomerManagedKey(input []CustomerManagedKey) (*Encryption, error) {
-//	    // ...
-//	}
-//
-//	func (r AIServices) flattenNetworkACLs(input *NetworkRuleSet) []NetworkACLs {
-//	    // ...
-//	}
+//	azurerm-linter explain AZNR002
 package passes
@@ -12,7 +12,6 @@ import (
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 	"golang.org/x/tools/go/ast/inspector"
-	"golang.org/x/tools/go/packages"
 )
 
 const completeSchemaDoc = `Extracts and resolves all schema fields from schema map literals.
@@ -25,7 +24,7 @@ Resolution strategies:
 - Direct literals: &pluginsdk.Schema{Type: TypeString, Required: true}
 - Cross-package calls: commonschema.ResourceGroupName() → resolved from CommonAnalyzer cache
 - Same-package calls: metadataSchema() → traces function definition in current package
-- External package calls: network.SubnetSchema() → searches in global package registry
+- External package calls: network.SubnetSchema() → resolved from the facts SchemaFuncAnalyzer exports
 
 Output format:
 - Key: token.Pos of the schema map composite literal (unique across packages in same build)
@@ -42,7 +41,7 @@ Example:
             },
             "resource_group_name": commonschema.ResourceGroupName(),  // ← Cross-package: resolved from CommonAnalyzer
             "tags": tagsSchema(),                                      // ← Same-package: traces to tagsSchema() in current file
-            "subnet_id": network.SubnetIdSchema(),                     // ← External package: searches in internal/services/network
+            "subnet_id": network.SubnetIdSchema(),                     // ← External package: resolved from its SchemaFuncFact
         }
     }
 
@@ -55,7 +54,7 @@ Example:
     }
 
 Limitations:
-- External package resolution depends on packages being loaded by the runner (via packages.Load)
+- External package resolution only covers schema flags (Required, Optional, Computed, ForceNew)
 - Does not handle dynamic schema construction (mergeSchemas, conditional schema, feature flags)
 - Preserves original field order from source code
 
@@ -74,7 +73,7 @@ var CompleteSchemaAnalyzer = &analysis.Analyzer{
 	Name:       "completeschemainfo",
 	Doc:        completeSchemaDoc,
 	Run:        runComplete,
-	Requires:   []*analysis.Analyzer{inspect.Analyzer, CommonAnalyzer},
+	Requires:   []*analysis.Analyzer{inspect.Analyzer, CommonAnalyzer, SchemaFuncAnalyzer},
 	ResultType: reflect.TypeOf(&CompleteSchemaInfo{}),
 }
 
@@ -149,7 +148,7 @@ func extractCompleteSchemaInfoFromMap(pass *analysis.Pass, smap *ast.CompositeLi
 // resolveSchemaFromCall resolves schema from a function call using:
 // 1. CommonAnalyzer cache (commonschema.*)
 // 2. Current package definitions
-// 3. External packages (via global registry)
+// 3. External packages (via SchemaFuncFact)
 func resolveSchemaFromCall(pass *analysis.Pass, call *ast.CallExpr, commonSchemaInfo *CommonSchemaInfo) *schema.SchemaInfo {
 	if selExpr, ok := call.Fun.(*ast.SelectorExpr); ok {
 		if pkgIdent, ok := selExpr.X.(*ast.Ident); ok {
@@ -187,52 +186,28 @@ func findSchemaInCurrentPackage(pass *analysis.Pass, call *ast.CallExpr) *schema
 
 	funcDecl := helper.FindFuncDecl(pass, funcObj)
 	if funcDecl == nil {
-		return findSchemaInExternalPackage(funcObj, pass.TypesInfo)
+		return findSchemaInExternalPackage(pass, funcObj)
 	}
 
 	return extractSchemaFromFuncReturn(funcDecl, pass.TypesInfo)
 }
 
-// findSchemaInExternalPackage searches external packages via global registry.
-func findSchemaInExternalPackage(funcObj types.Object, typesInfo *types.Info) *schema.SchemaInfo {
-	if funcObj == nil || funcObj.Pkg() == nil {
+// findSchemaInExternalPackage resolves the schema of a function in an imported package
+// from the fact SchemaFuncAnalyzer exported while analyzing that package.
+func findSchemaInExternalPackage(pass *analysis.Pass, funcObj types.Object) *schema.SchemaInfo {
+	if funcObj == nil || funcObj.Pkg() == nil || funcObj.Pkg() == pass.Pkg {
 		return nil
 	}
 
-	targetPkgPath := funcObj.Pkg().Path()
-	funcName := funcObj.Name()
-
-	pkg := helper.FindPackageByPath(targetPkgPath)
-	if pkg == nil {
+	schemaFuncInfo, ok := pass.ResultOf[SchemaFuncAnalyzer].(*SchemaFuncInfo)
+	if !ok {
 		return nil
 	}
 
-	return findSchemaInPackage(pkg, funcName, typesInfo)
-}
-
-// findSchemaInPackage searches for a function by name in a package and extracts its schema
-// from the return statement.
-func findSchemaInPackage(pkg *packages.Package, funcName string, typesInfo *types.Info) *schema.SchemaInfo {
-	if pkg == nil || pkg.Syntax == nil {
+	fact, ok := schemaFuncInfo.Functions[funcObj]
+	if !ok {
 		return nil
 	}
 
-	for _, file := range pkg.Syntax {
-		for _, decl := range file.Decls {
-			funcDecl, ok := decl.(*ast.FuncDecl)
-			if !ok || funcDecl.Name == nil {
-				continue
-			}
-
-			if funcDecl.Name.Name == funcName {
-				useTypesInfo := pkg.TypesInfo
-				if useTypesInfo == nil {
-					useTypesInfo = typesInfo
-				}
-				return extractSchemaFromFuncReturn(funcDecl, useTypesInfo)
-			}
-		}
-	}
-
-	return nil
+	return fact.SchemaInfo(pass.TypesInfo)
 }
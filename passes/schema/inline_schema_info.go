@@ -7,6 +7,7 @@ import (
 
 	"github.com/bflad/tfproviderlint/helper/terraformtype/helper/schema"
 	"github.com/qixialu/azurerm-linter/helper"
+	"github.com/qixialu/azurerm-linter/session"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 	"golang.org/x/tools/go/ast/inspector"
@@ -51,20 +52,22 @@ Skipped patterns:
 type LocalSchemaInfoWithName struct {
 	Info         *schema.SchemaInfo
 	PropertyName string
+	// Key is the property name literal in the schema map; nil for standalone schemas
+	Key *ast.BasicLit
 }
 
 var LocalAnalyzer = &analysis.Analyzer{
 	Name:       localAnalyzerName,
 	Doc:        localAnalyzerDoc,
 	Run:        runLocal,
-	Requires:   []*analysis.Analyzer{inspect.Analyzer},
+	Requires:   []*analysis.Analyzer{inspect.Analyzer, session.Analyzer},
 	ResultType: reflect.TypeOf(LocalSchemaInfoList{}),
 }
 
 func runLocal(pass *analysis.Pass) (interface{}, error) {
 	var schemaInfoList LocalSchemaInfoList
 
-	if helper.ShouldSkipPackageForResourceAnalysis(pass.Pkg.Path()) {
+	if helper.ShouldSkipPackageForResourceAnalysis(pass.Pkg.Path(), session.From(pass).SkipPackages()) {
 		return schemaInfoList, nil
 	}
 
@@ -141,6 +144,7 @@ func runLocal(pass *analysis.Pass) (interface{}, error) {
 					schemaInfoList = append(schemaInfoList, &LocalSchemaInfoWithName{
 						Info:         schemaInfo,
 						PropertyName: propertyName,
+						Key:          key,
 					})
 				}
 			}
@@ -0,0 +1,130 @@
+package schema
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/bflad/tfproviderlint/helper/terraformtype/helper/schema"
+	"github.com/qixialu/azurerm-linter/helper"
+	"golang.org/x/tools/go/analysis"
+)
+
+const schemaFuncDoc = `Exports the schemas returned by the exported functions of each package as facts.
+
+Importing packages resolve calls such as network.SubnetIdSchema() through these facts,
+so schema resolution works per package, including under go vet -vettool. The result holds
+the facts of the package and of all packages it imports, as facts are only visible to the
+analyzer that exports them.
+
+Example:
+
+	// Function in internal/services/network
+	func SubnetIdSchema() *pluginsdk.Schema {
+	    return &pluginsdk.Schema{
+	        Type:     pluginsdk.TypeString,
+	        Required: true,
+	        ForceNew: true,
+	    }
+	}
+
+	// Exported fact on network.SubnetIdSchema
+	SchemaFuncFact{Required: true, ForceNew: true}
+`
+
+// SchemaFuncInfo stores the schemas returned by the exported functions visible to a package
+type SchemaFuncInfo struct {
+	// Map of function object -> fact exported while analyzing its package
+	Functions map[types.Object]*SchemaFuncFact
+}
+
+var SchemaFuncAnalyzer = &analysis.Analyzer{
+	Name:       "schemafuncinfo",
+	Doc:        schemaFuncDoc,
+	Run:        runSchemaFunc,
+	FactTypes:  []analysis.Fact{new(SchemaFuncFact)},
+	ResultType: reflect.TypeOf(&SchemaFuncInfo{}),
+}
+
+// SchemaFuncFact records the schema returned by an exported function
+type SchemaFuncFact struct {
+	Required bool
+	Optional bool
+	Computed bool
+	ForceNew bool
+}
+
+func (*SchemaFuncFact) AFact() {}
+
+func (f *SchemaFuncFact) String() string {
+	return fmt.Sprintf("schema(%s)", strings.Join(f.fields(), ","))
+}
+
+// SchemaInfo rebuilds the schema as a literal of the recorded fields, so importing packages
+// get the same SchemaInfo as for a schema defined in the package itself
+func (f *SchemaFuncFact) SchemaInfo(typesInfo *types.Info) *schema.SchemaInfo {
+	lit := &ast.CompositeLit{}
+	for _, name := range f.fields() {
+		lit.Elts = append(lit.Elts, &ast.KeyValueExpr{Key: ast.NewIdent(name), Value: ast.NewIdent("true")})
+	}
+	return schema.NewSchemaInfo(lit, typesInfo)
+}
+
+// fields returns the names of the schema fields set to true
+func (f *SchemaFuncFact) fields() []string {
+	var names []string
+	for name, set := range map[string]bool{
+		schema.SchemaFieldRequired: f.Required,
+		schema.SchemaFieldOptional: f.Optional,
+		schema.SchemaFieldComputed: f.Computed,
+		schema.SchemaFieldForceNew: f.ForceNew,
+	} {
+		if set {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func runSchemaFunc(pass *analysis.Pass) (interface{}, error) {
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || funcDecl.Recv != nil || funcDecl.Body == nil || !funcDecl.Name.IsExported() {
+				continue
+			}
+
+			fn, ok := pass.TypesInfo.Defs[funcDecl.Name].(*types.Func)
+			if !ok || !helper.ReturnsSchema(fn) {
+				continue
+			}
+
+			schemaInfo := extractSchemaFromFuncReturn(funcDecl, pass.TypesInfo)
+			if schemaInfo == nil {
+				continue
+			}
+
+			pass.ExportObjectFact(fn, &SchemaFuncFact{
+				Required: schemaInfo.Schema.Required,
+				Optional: schemaInfo.Schema.Optional,
+				Computed: schemaInfo.Schema.Computed,
+				ForceNew: schemaInfo.Schema.ForceNew,
+			})
+		}
+	}
+
+	info := &SchemaFuncInfo{
+		Functions: make(map[types.Object]*SchemaFuncFact),
+	}
+	for _, objFact := range pass.AllObjectFacts() {
+		if fact, ok := objFact.Fact.(*SchemaFuncFact); ok {
+			info.Functions[objFact.Object] = fact
+		}
+	}
+
+	return info, nil
+}
@@ -0,0 +1,134 @@
+package passes
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/qixialu/azurerm-linter/rules"
+	"github.com/qixialu/azurerm-linter/session"
+	"golang.org/x/tools/go/analysis"
+)
+
+// SelectChecks returns the checks matched by enable (all when empty) minus those matched by disable.
+// Selectors are check IDs (AZNR001) or category prefixes (AZNR).
+func SelectChecks(enable, disable []string) ([]*analysis.Analyzer, error) {
+	return selectChecks(AllChecks, enable, disable)
+}
+
+func selectChecks(all []*analysis.Analyzer, enable, disable []string) ([]*analysis.Analyzer, error) {
+	for _, selector := range append(append([]string{}, enable...), disable...) {
+		if !selectorMatchesAny(all, selector) {
+			return nil, fmt.Errorf("unknown check or category %q", selector)
+		}
+	}
+
+	var checks []*analysis.Analyzer
+	for _, analyzer := range all {
+		if len(enable) > 0 && !matchesAnySelector(analyzer.Name, enable) {
+			continue
+		}
+		if matchesAnySelector(analyzer.Name, disable) {
+			continue
+		}
+		checks = append(checks, analyzer)
+	}
+
+	if len(checks) == 0 {
+		return nil, errors.New("no checks enabled")
+	}
+
+	return checks, nil
+}
+
+func selectorMatchesAny(all []*analysis.Analyzer, selector string) bool {
+	for _, analyzer := range all {
+		if matchesSelector(analyzer.Name, selector) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAnySelector(name string, selectors []string) bool {
+	for _, selector := range selectors {
+		if matchesSelector(name, selector) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesSelector reports whether a check ID (AZNR001) or category prefix (AZNR) selects the check
+func matchesSelector(name, selector string) bool {
+	return rules.MatchesSelector(name, selector)
+}
+
+// ResolveSettings validates per-check options, keyed by check ID and then by analyzer flag name,
+// and converts their values into the string form flag.Value accepts. The checks read them from the
+// session of a run rather than from their flags, so runs in one process keep their own settings.
+func ResolveSettings(settings map[string]map[string]interface{}) (map[string]map[string]string, error) {
+	return resolveCheckSettings(AllChecks, settings)
+}
+
+func resolveCheckSettings(all []*analysis.Analyzer, settings map[string]map[string]interface{}) (map[string]map[string]string, error) {
+	byName := make(map[string]*analysis.Analyzer, len(all))
+	for _, analyzer := range all {
+		byName[analyzer.Name] = analyzer
+	}
+
+	checkIDs := make([]string, 0, len(settings))
+	for checkID := range settings {
+		checkIDs = append(checkIDs, checkID)
+	}
+	sort.Strings(checkIDs)
+
+	resolved := make(map[string]map[string]string, len(settings))
+	for _, checkID := range checkIDs {
+		analyzer, ok := byName[checkID]
+		if !ok {
+			return nil, fmt.Errorf("settings: unknown check %q", checkID)
+		}
+
+		resolved[checkID] = make(map[string]string, len(settings[checkID]))
+		for name, value := range settings[checkID] {
+			if analyzer.Flags.Lookup(name) == nil {
+				return nil, fmt.Errorf("settings: %s has no setting %q", checkID, name)
+			}
+			resolved[checkID][name] = settingValueString(value)
+		}
+	}
+
+	return resolved, nil
+}
+
+// setting returns the option name of the check running in pass: the setting of the run's session,
+// or the value of the analyzer flag, as set on the command line of go vet
+func setting(pass *analysis.Pass, name string) string {
+	if value, ok := session.From(pass).Setting(pass.Analyzer.Name, name); ok {
+		return value
+	}
+	return pass.Analyzer.Flags.Lookup(name).Value.String()
+}
+
+// settingValueString converts a YAML or JSON scalar or list into the string form expected by flag.Value
+func settingValueString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			parts = append(parts, settingValueString(item))
+		}
+		return strings.Join(parts, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
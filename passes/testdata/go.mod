@@ -6,6 +6,7 @@ require (
 	github.com/hashicorp/go-azure-helpers/lang/pointer v0.0.0
 	github.com/hashicorp/go-azure-sdk/resource-manager/compute/2024-03-01/virtualmachines v0.0.0
 	github.com/hashicorp/terraform-plugin-sdk/v2 v2.34.0
+	github.com/pkg/errors v0.0.0
 )
 
 require (
@@ -45,4 +46,5 @@ replace (
 	github.com/hashicorp/go-azure-helpers/lang/pointer => ./src/github.com/hashicorp/go-azure-helpers/lang/pointer
 	github.com/hashicorp/go-azure-sdk/resource-manager/compute/2024-03-01/virtualmachines => ./src/github.com/hashicorp/go-azure-sdk/resource-manager/compute/2024-03-01/virtualmachines
 	github.com/hashicorp/terraform-plugin-sdk/v2 => ./src/github.com/hashicorp/terraform-plugin-sdk/v2
+	github.com/pkg/errors => ./src/github.com/pkg/errors
 )
@@ -0,0 +1,25 @@
+package aznr001
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"testdata/src/mockpkg/sharedschema"
+)
+
+// Test: Schemas returned by functions of another package are categorized
+func resourceExternalSchema() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{ // want `name, sku, description, primary_key`
+			"primary_key": sharedschema.PrimaryKey(),
+
+			"name": sharedschema.Name(),
+
+			"sku": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"description": sharedschema.Description(),
+		},
+	}
+}
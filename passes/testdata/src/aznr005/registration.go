@@ -74,6 +74,16 @@ func (r Registration) InvalidSectionedDataSources() map[string]*pluginsdk.Resour
 	}
 }
 
+func (r Registration) InvalidGroupedResources() map[string]*pluginsdk.Resource {
+	return map[string]*pluginsdk.Resource{ // want `AZNR005`
+		"azurerm_managed_disk":     nil, // moves with its entry
+		"azurerm_availability_set": nil,
+
+		"azurerm_virtual_machine": nil,
+		"azurerm_dedicated_host":  nil,
+	}
+}
+
 func (r Registration) Resources() []sdk.Resource {
 	return []sdk.Resource{
 		ApiManagementNotificationRecipientEmailResource{},
@@ -25,6 +25,13 @@ func invalidCases() {
 	_ = fmt.Errorf("error occurred")       // want `AZRE001`
 }
 
+func shadowedErrorsCase() {
+	// Invalid, but errors.New would refer to the local variable: no fix
+	var errors []error
+	errors = append(errors, fmt.Errorf("shadowed errors")) // want `AZRE001`
+	_ = errors
+}
+
 func ignoredInvalidCase() {
 	_ = fmt.Errorf("ignored input") //lintignore:AZRE001 // ignored in fixture
 }
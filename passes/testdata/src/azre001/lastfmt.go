@@ -0,0 +1,15 @@
+package azre001
+
+import (
+	"errors"
+	"fmt"
+)
+
+func lastFmtCase(valid bool) error {
+	if valid {
+		return errors.New("valid")
+	}
+
+	// Invalid, and the last use of fmt: the fix drops the fmt import
+	return fmt.Errorf("last use of fmt") // want `AZRE001`
+}
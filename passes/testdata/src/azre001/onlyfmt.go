@@ -0,0 +1,13 @@
+package azre001
+
+import (
+	"fmt"
+	"strings"
+)
+
+func onlyFmtCase() error {
+	_ = strings.TrimSpace(" ")
+
+	// Invalid, and the last use of fmt: the fix imports errors in its place
+	return fmt.Errorf("only use of fmt") // want `AZRE001`
+}
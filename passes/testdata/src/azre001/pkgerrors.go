@@ -0,0 +1,12 @@
+package azre001
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+func pkgErrorsCases() error {
+	// Invalid, but errors.New would refer to github.com/pkg/errors: no fix
+	return errors.Wrap(fmt.Errorf("pkg errors in scope"), "context") // want `AZRE001`
+}
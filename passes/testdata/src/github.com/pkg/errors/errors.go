@@ -0,0 +1,9 @@
+package errors
+
+func New(message string) error {
+	return nil
+}
+
+func Wrap(err error, message string) error {
+	return err
+}
@@ -0,0 +1,86 @@
+// Package plugin exposes the AzureRM provider checks as a golangci-lint module plugin.
+//
+// Register it in .custom-gcl.yml and enable it in .golangci.yml:
+//
+//	linters:
+//	  enable:
+//	    - azurermlinter
+//	  settings:
+//	    custom:
+//	      azurermlinter:
+//	        type: module
+//	        settings:
+//	          enable: [AZBP, AZNR001]
+//	          disable: [AZBP002]
+//	          settings:
+//	            AZBP005:
+//	              license-header: "// Copyright (c) Contoso"
+//
+// The linter's own change filtering is not used by the plugin; every diagnostic is reported
+// and golangci-lint's new-from-rev and new-from-patch options decide which ones are new.
+package plugin
+
+import (
+	"github.com/golangci/plugin-module-register/register"
+	"github.com/qixialu/azurerm-linter/passes"
+	"github.com/qixialu/azurerm-linter/session"
+	"golang.org/x/tools/go/analysis"
+)
+
+// Name is the name the plugin is registered under in golangci-lint
+const Name = "azurermlinter"
+
+func init() {
+	register.Plugin(Name, New)
+}
+
+// Settings are the plugin settings from .golangci.yml, matching the keys of .azurerm-linter.yaml
+type Settings struct {
+	// Enable lists check IDs or category prefixes (e.g. AZBP) to run; empty runs all checks
+	Enable []string `json:"enable"`
+	// Disable lists check IDs or category prefixes to skip; takes precedence over Enable
+	Disable []string `json:"disable"`
+	// SkipPackages replaces the package path fragments skipped by resource analysis
+	SkipPackages []string `json:"skip-packages"`
+	// Settings holds per-check options, keyed by check ID and then by analyzer flag name
+	Settings map[string]map[string]interface{} `json:"settings"`
+}
+
+// Plugin is the golangci-lint linter plugin running the selected checks
+type Plugin struct {
+	checks []*analysis.Analyzer
+}
+
+var _ register.LinterPlugin = (*Plugin)(nil)
+
+// New validates the plugin settings and resolves the per-check options
+func New(conf any) (register.LinterPlugin, error) {
+	settings, err := register.DecodeSettings[Settings](conf)
+	if err != nil {
+		return nil, err
+	}
+
+	checks, err := passes.SelectChecks(settings.Enable, settings.Disable)
+	if err != nil {
+		return nil, err
+	}
+
+	checkSettings, err := passes.ResolveSettings(settings.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	config := session.Config{Settings: checkSettings, SkipPackages: settings.SkipPackages}
+	return &Plugin{checks: session.Bind(checks, session.NewWithConfig(nil, config))}, nil
+}
+
+// BuildAnalyzers returns the selected checks. Their session carries the plugin settings and no
+// changes, so no diagnostic is dropped by the linter's change filtering.
+func (p *Plugin) BuildAnalyzers() ([]*analysis.Analyzer, error) {
+	return p.checks, nil
+}
+
+// GetLoadMode reports that the checks need type information
+func (p *Plugin) GetLoadMode() string {
+	return register.LoadModeTypesInfo
+}
@@ -0,0 +1,102 @@
+package plugin
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golangci/plugin-module-register/register"
+	"github.com/qixialu/azurerm-linter/passes"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestNewSelectsChecksAndAppliesSettings(t *testing.T) {
+	flag := passes.AZBP005Analyzer.Flags.Lookup("license-header")
+	original := flag.Value.String()
+
+	newPlugin, err := register.GetPlugin(Name)
+	if err != nil {
+		t.Fatalf("GetPlugin() error = %v", err)
+	}
+
+	// golangci-lint passes the settings as decoded YAML
+	p, err := newPlugin(map[string]any{
+		"enable":  []any{"AZBP", "AZRE001"},
+		"disable": []any{"AZBP002"},
+		"settings": map[string]any{
+			"AZBP005": map[string]any{"license-header": "// Copyright (c) Contoso"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	analyzers, err := p.BuildAnalyzers()
+	if err != nil {
+		t.Fatalf("BuildAnalyzers() error = %v", err)
+	}
+
+	var names []string
+	var azbp005 *analysis.Analyzer
+	for _, analyzer := range analyzers {
+		if !strings.HasPrefix(analyzer.Name, "AZBP") && analyzer.Name != "AZRE001" || analyzer.Name == "AZBP002" {
+			t.Errorf("unexpected check %s", analyzer.Name)
+		}
+		if analyzer.Name == "AZBP005" {
+			azbp005 = analyzer
+		}
+		names = append(names, analyzer.Name)
+	}
+	if len(names) == 0 || !contains(names, "AZBP001") || !contains(names, "AZRE001") {
+		t.Fatalf("checks = %v, want AZBP checks and AZRE001", names)
+	}
+
+	// The configured header applies to the plugin's checks only
+	analysistest.Run(t, analysistest.TestData(), azbp005, "azbp005")
+	if got := flag.Value.String(); got != original {
+		t.Fatalf("analyzer flag license-header = %q, want it unchanged as %q", got, original)
+	}
+	if p.GetLoadMode() != register.LoadModeTypesInfo {
+		t.Fatalf("GetLoadMode() = %q, want %q", p.GetLoadMode(), register.LoadModeTypesInfo)
+	}
+}
+
+func TestNewRejectsInvalidSettings(t *testing.T) {
+	tests := map[string]map[string]any{
+		"unknown key":     {"enabled": []any{"AZBP001"}},
+		"unknown check":   {"enable": []any{"AZXX001"}},
+		"no checks":       {"enable": []any{"AZRE001"}, "disable": []any{"AZRE"}},
+		"unknown setting": {"settings": map[string]any{"AZBP005": map[string]any{"header": "x"}}},
+	}
+
+	for name, settings := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := New(settings); err == nil {
+				t.Fatalf("New() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestAnalyzersReportWithoutChangeFiltering(t *testing.T) {
+	p, err := New(map[string]any{"enable": []any{"AZRE001"}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	analyzers, err := p.BuildAnalyzers()
+	if err != nil {
+		t.Fatalf("BuildAnalyzers() error = %v", err)
+	}
+
+	analysistest.Run(t, "../passes/testdata", analyzers[0], "testdata/src/azre001")
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
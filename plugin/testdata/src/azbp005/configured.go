@@ -0,0 +1,5 @@
+// Copyright (c) Contoso
+
+package azbp005
+
+func Configured() {}
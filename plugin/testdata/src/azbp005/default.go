@@ -0,0 +1,6 @@
+// Copyright IBM Corp. 2014, 2025 // want `AZBP005`
+// SPDX-License-Identifier: MPL-2.0
+
+package azbp005
+
+func Default() {}
@@ -28,39 +28,49 @@ type DiagnosticMeta struct {
 }
 
 type ReportOptions struct {
-	Rule          string
-	ReportPos     token.Pos
-	Message       string
-	EvidenceFile  string
-	EvidenceLines []int
-	MatchMode     string
+	Rule           string
+	ReportPos      token.Pos
+	Message        string
+	EvidenceFile   string
+	EvidenceLines  []int
+	MatchMode      string
+	SuggestedFixes []analysis.SuggestedFix
 }
 
-var (
-	registryMu sync.Mutex
-	registry   = make(map[string]DiagnosticMeta)
-)
+// Registry holds the metadata of the diagnostics reported during one run
+type Registry struct {
+	mu      sync.Mutex
+	entries map[string]DiagnosticMeta
+}
 
-func Reset() {
-	registryMu.Lock()
-	defer registryMu.Unlock()
+// NewRegistry creates an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{entries: make(map[string]DiagnosticMeta)}
+}
 
-	registry = make(map[string]DiagnosticMeta)
+// Recorder receives the metadata of reported diagnostics. It is implemented by the per-run
+// value analyzers get as the result of a required analyzer, see package session.
+type Recorder interface {
+	RecordDiagnostic(meta DiagnosticMeta)
 }
 
-func Record(meta DiagnosticMeta) {
-	registryMu.Lock()
-	defer registryMu.Unlock()
+func (r *Registry) Record(meta DiagnosticMeta) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
 	meta = normalizeMeta(meta)
-	registry[makeKey(meta.PkgPath, meta.ReportFile, meta.ReportLine, meta.ReportColumn, meta.Message)] = cloneMeta(meta)
+	r.entries[makeKey(meta.PkgPath, meta.ReportFile, meta.ReportLine, meta.ReportColumn, meta.Message)] = cloneMeta(meta)
 }
 
-func Lookup(pkgPath, file string, line, column int, message string) (DiagnosticMeta, bool) {
-	registryMu.Lock()
-	defer registryMu.Unlock()
+func (r *Registry) Lookup(pkgPath, file string, line, column int, message string) (DiagnosticMeta, bool) {
+	if r == nil {
+		return DiagnosticMeta{}, false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	meta, ok := registry[makeKey(pkgPath, file, line, column, message)]
+	meta, ok := r.entries[makeKey(pkgPath, file, line, column, message)]
 	if !ok {
 		return DiagnosticMeta{}, false
 	}
@@ -68,6 +78,22 @@ func Lookup(pkgPath, file string, line, column int, message string) (DiagnosticM
 	return cloneMeta(meta), true
 }
 
+// Entries returns the metadata of all recorded diagnostics
+func (r *Registry) Entries() []DiagnosticMeta {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := make([]DiagnosticMeta, 0, len(r.entries))
+	for _, meta := range r.entries {
+		entries = append(entries, cloneMeta(meta))
+	}
+	return entries
+}
+
 func Report(pass *analysis.Pass, opts ReportOptions) {
 	pos := pass.Fset.Position(opts.ReportPos)
 	evidenceFile := opts.EvidenceFile
@@ -87,8 +113,14 @@ func Report(pass *analysis.Pass, opts ReportOptions) {
 		MatchMode:     opts.MatchMode,
 	}
 
-	Record(meta)
-	pass.Report(analysis.Diagnostic{Pos: opts.ReportPos, Message: opts.Message})
+	if recorder := recorderFor(pass); recorder != nil {
+		recorder.RecordDiagnostic(meta)
+	}
+	pass.Report(analysis.Diagnostic{
+		Pos:            opts.ReportPos,
+		Message:        opts.Message,
+		SuggestedFixes: opts.SuggestedFixes,
+	})
 }
 
 func Reportf(pass *analysis.Pass, opts ReportOptions, format string, args ...interface{}) {
@@ -96,6 +128,17 @@ func Reportf(pass *analysis.Pass, opts ReportOptions, format string, args ...int
 	Report(pass, opts)
 }
 
+// recorderFor returns the Recorder among the results of the analyzers pass depends on, if any.
+// Without one, as under analysistest or go vet, no change filtering applies and metadata is not needed.
+func recorderFor(pass *analysis.Pass) Recorder {
+	for _, result := range pass.ResultOf {
+		if recorder, ok := result.(Recorder); ok {
+			return recorder
+		}
+	}
+	return nil
+}
+
 func cloneMeta(meta DiagnosticMeta) DiagnosticMeta {
 	meta.EvidenceLines = append([]int(nil), meta.EvidenceLines...)
 	return meta
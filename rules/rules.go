@@ -0,0 +1,226 @@
+// Package rules describes the checks of the linter: their category, title, severity, reference
+// into the provider's contributing guide and lifecycle. The check list, SARIF rules and JSON
+// output are generated from it, so it must have an entry for every analyzer in passes.AllChecks.
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/qixialu/azurerm-linter/reporting"
+)
+
+// contributingBaseURL is the contributing guide of terraform-provider-azurerm
+const contributingBaseURL = "https://github.com/hashicorp/terraform-provider-azurerm/blob/main/contributing/topics/"
+
+// Category groups checks by the part of the contributing guide they enforce
+type Category string
+
+const (
+	BestPractice   Category = "best-practice"
+	NewResource    Category = "new-resource"
+	NamingRule     Category = "naming-rule"
+	ReferenceError Category = "reference-error"
+	SchemaDesign   Category = "schema-design"
+)
+
+type categoryInfo struct {
+	prefix string
+	title  string
+	guide  string
+}
+
+// categories are in the order checks are listed
+var categories = []Category{BestPractice, NewResource, NamingRule, ReferenceError, SchemaDesign}
+
+var categoryInfos = map[Category]categoryInfo{
+	BestPractice:   {prefix: "AZBP", title: "Azure Best Practice Checks", guide: contributingBaseURL + "best-practices.md"},
+	NewResource:    {prefix: "AZNR", title: "Azure New Resource Checks", guide: contributingBaseURL + "guide-new-resource.md"},
+	NamingRule:     {prefix: "AZRN", title: "Azure Naming Rule Checks", guide: contributingBaseURL + "reference-naming.md"},
+	ReferenceError: {prefix: "AZRE", title: "Azure Reference Error Checks", guide: contributingBaseURL + "reference-errors.md"},
+	SchemaDesign:   {prefix: "AZSD", title: "Azure Schema Design Checks", guide: contributingBaseURL + "schema-design-considerations.md"},
+}
+
+// MatchesSelector reports whether selector selects the check checkID. A selector is a check ID
+// (AZNR001) or the prefix of a category (AZNR), in any case; partial IDs such as AZNR00 select nothing.
+func MatchesSelector(checkID, selector string) bool {
+	selector = strings.ToUpper(strings.TrimSpace(selector))
+	if selector == checkID {
+		return true
+	}
+	for _, c := range categories {
+		if c.Prefix() == selector {
+			return strings.HasPrefix(checkID, selector)
+		}
+	}
+	return false
+}
+
+// Categories returns all categories in listing order
+func Categories() []Category {
+	return append([]Category(nil), categories...)
+}
+
+// Prefix returns the check ID prefix of the category, such as AZBP
+func (c Category) Prefix() string {
+	return categoryInfos[c].prefix
+}
+
+// Title returns the heading the category is listed under
+func (c Category) Title() string {
+	return categoryInfos[c].title
+}
+
+// Guide returns the contributing guide page the checks of the category follow
+func (c Category) Guide() string {
+	return categoryInfos[c].guide
+}
+
+// Severity is how serious a finding of a check is. Checks are warnings unless registered
+// otherwise, and the project configuration can override the severity of any check.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"   // blocks merging
+	SeverityWarning Severity = "warning" // should be fixed
+	SeverityInfo    Severity = "info"    // advice, such as style
+)
+
+// severityRanks orders the severities from least to most serious
+var severityRanks = map[Severity]int{SeverityInfo: 1, SeverityWarning: 2, SeverityError: 3}
+
+// Severities returns the severities, most serious first
+func Severities() []Severity {
+	return []Severity{SeverityError, SeverityWarning, SeverityInfo}
+}
+
+// ParseSeverity parses error, warning or info, in any case
+func ParseSeverity(s string) (Severity, error) {
+	severity := Severity(strings.ToLower(strings.TrimSpace(s)))
+	if _, ok := severityRanks[severity]; !ok {
+		return "", fmt.Errorf("unknown severity %q: must be one of error, warning or info", s)
+	}
+	return severity, nil
+}
+
+// AtLeast reports whether s is as serious as threshold or more
+func (s Severity) AtLeast(threshold Severity) bool {
+	return severityRanks[s] >= severityRanks[threshold]
+}
+
+// Rule is the metadata of a check
+type Rule struct {
+	ID       string
+	Category Category
+	Title    string
+	Severity Severity
+
+	// Reference links to the contributing guide section the check enforces
+	Reference string
+
+	// Notes describe limits of the check, such as the kinds of resources it runs on
+	Notes string
+
+	// Since is the version that added the check. Deprecated is the version that deprecated it,
+	// and RemovedIn the version that removes it; both are empty for active checks.
+	Since      string
+	Deprecated string
+	RemovedIn  string
+
+	// MatchMode is the reporting match mode of the check's diagnostics, which decides which
+	// changes keep them in filtered runs
+	MatchMode string
+}
+
+// IsDeprecated reports whether the check is deprecated. Deprecated checks are not in passes.AllChecks.
+func (r Rule) IsDeprecated() bool {
+	return r.Deprecated != ""
+}
+
+// PlainTitle returns the title without the backticks that mark code in it
+func (r Rule) PlainTitle() string {
+	return strings.ReplaceAll(r.Title, "`", "")
+}
+
+// registry lists the checks by category, then by ID
+var registry = []Rule{
+	{ID: "AZBP001", Category: BestPractice, Title: "check for all String arguments have `ValidateFunc`", Since: "v0.1.0",
+		Reference: contributingBaseURL + "guide-new-fields-to-resource.md#schema"},
+	{ID: "AZBP002", Category: BestPractice, Title: "check for `Optional+Computed` fields follow conventions", Since: "v0.1.0",
+		Reference: contributingBaseURL + "best-practices.md#setting-properties-to-optional--computed"},
+	{ID: "AZBP003", Category: BestPractice, Title: "check for `pointer.ToEnum` to convert Enum type instead of explicitly type conversion", Since: "v0.1.0"},
+	{ID: "AZBP004", Category: BestPractice, Title: "check for zero-value initialization followed by nil check and pointer dereference that should use `pointer.From`", Since: "v0.1.0"},
+	{ID: "AZBP005", Category: BestPractice, Title: "check that Go source files have the correct licensing header", Since: "v0.1.0",
+		Severity: SeverityError},
+	{ID: "AZBP006", Category: BestPractice, Title: "check for redundant `nil` assignments to pointer fields in struct literals", Since: "v0.1.2"},
+	{ID: "AZBP007", Category: BestPractice, Title: "check for string slices initialized using `make([]string, 0)` instead of `[]string{}`", Since: "v0.1.2"},
+	{ID: "AZBP008", Category: BestPractice, Title: "check for `ValidateFunc` uses `PossibleValuesFor*` instead of manual enum listing", Since: "v0.1.2"},
+	{ID: "AZBP009", Category: BestPractice, Title: "check for variables that use the same name as an imported package", Since: "v0.1.3"},
+	{ID: "AZBP010", Category: BestPractice, Title: "check for variables that are declared and immediately returned", Since: "v0.1.3"},
+	{ID: "AZBP011", Category: BestPractice, Title: "check for `strings.EqualFold` usage in enum comparisons", Since: "v0.1.3"},
+	{ID: "AZBP012", Category: BestPractice, Title: "check for unnecessary else blocks that can be avoided by setting a default", Since: "v0.1.5",
+		Severity: SeverityInfo},
+	{ID: "AZBP013", Category: BestPractice, Title: "check for chained nil checks that should be split into separate if statements", Since: "v0.1.5",
+		Severity: SeverityInfo},
+	{ID: "AZBP014", Category: BestPractice, Title: "check for empty `OperationOptions` literals when a `Default*` constructor exists", Since: "v0.1.5"},
+	{ID: "AZBP015", Category: BestPractice, Title: "check that `check.That().Key().HasValue()` is unnecessary when `ImportStep` is used", Since: "v0.1.5", Deprecated: "v0.1.7",
+		Reference: contributingBaseURL + "reference-acceptance-testing.md"},
+
+	{ID: "AZNR001", Category: NewResource, Title: "check for Schema field ordering", Since: "v0.1.0", Severity: SeverityError,
+		Notes:     "When git filter is on, this analyzer only runs on newly created resources/data sources",
+		MatchMode: reporting.MatchModeNewFile},
+	{ID: "AZNR002", Category: NewResource, Title: "check for top-level updatable arguments are included in Update func", Since: "v0.1.0",
+		Notes: "This analyzer currently only runs on typed resources"},
+	{ID: "AZNR003", Category: NewResource, Title: "check for `expand*`/`flatten*` functions are defined as receiver methods", Since: "v0.1.0", Deprecated: "v0.1.4",
+		Notes: "This analyzer currently only runs on typed resources/data sources"},
+	{ID: "AZNR004", Category: NewResource, Title: "check for `flatten*` functions returning slices don't return `nil`", Since: "v0.1.2"},
+	{ID: "AZNR005", Category: NewResource, Title: "check for registrations are sorted alphabetically", Since: "v0.1.2",
+		MatchMode: reporting.MatchModeSameHunk},
+	{ID: "AZNR006", Category: NewResource, Title: "check that nil checks are performed inside `flatten*` methods", Since: "v0.1.3"},
+	{ID: "AZNR007", Category: NewResource, Title: "check that resource names in test configurations start with `\"acctest\"`", Since: "v0.1.5", Deprecated: "v0.1.9",
+		Reference: contributingBaseURL + "reference-acceptance-testing.md"},
+	{ID: "AZNR008", Category: NewResource, Title: "check for hardcoded resource IDs in test configurations", Since: "v0.1.5",
+		Reference: contributingBaseURL + "reference-acceptance-testing.md"},
+
+	{ID: "AZRN001", Category: NamingRule, Title: "check for percentage properties use `_percentage` suffix instead of `_in_percent`", Since: "v0.1.0"},
+	{ID: "AZRN002", Category: NamingRule, Title: "check that boolean property names do not start with `is_`", Since: "v0.2.0"},
+
+	{ID: "AZRE001", Category: ReferenceError, Title: "check for fixed error strings using `fmt.Errorf` instead of `errors.New`", Since: "v0.1.0"},
+
+	{ID: "AZSD001", Category: SchemaDesign, Title: "check for `MaxItems:1` blocks with single property should be flattened", Since: "v0.1.0"},
+	{ID: "AZSD002", Category: SchemaDesign, Title: "check for `AtLeastOneOf` or `ExactlyOneOf` validation on TypeList fields with all optional nested fields", Since: "v0.1.0"},
+	{ID: "AZSD003", Category: SchemaDesign, Title: "check for redundant use of both `ExactlyOneOf` and `ConflictsWith`", Since: "v0.1.2"},
+	{ID: "AZSD004", Category: SchemaDesign, Title: "check for `computed` attributes should only have computed-only nested schema", Since: "v0.1.2"},
+}
+
+var byID = func() map[string]int {
+	index := make(map[string]int, len(registry))
+	for i := range registry {
+		rule := &registry[i]
+		if rule.Severity == "" {
+			rule.Severity = SeverityWarning
+		}
+		if rule.Reference == "" {
+			rule.Reference = rule.Category.Guide()
+		}
+		if rule.MatchMode == "" {
+			rule.MatchMode = reporting.MatchModeExactAdded
+		}
+		index[rule.ID] = i
+	}
+	return index
+}()
+
+// All returns the metadata of every check, including deprecated ones, by category and ID
+func All() []Rule {
+	return append([]Rule(nil), registry...)
+}
+
+// Lookup returns the metadata of the check with the given ID
+func Lookup(id string) (Rule, bool) {
+	i, ok := byID[id]
+	if !ok {
+		return Rule{}, false
+	}
+	return registry[i], true
+}
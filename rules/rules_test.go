@@ -0,0 +1,87 @@
+package rules_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/qixialu/azurerm-linter/passes"
+	"github.com/qixialu/azurerm-linter/rules"
+)
+
+func TestEveryCheckHasMetadata(t *testing.T) {
+	active := make(map[string]bool)
+	for _, analyzer := range passes.AllChecks {
+		active[analyzer.Name] = true
+		rule, ok := rules.Lookup(analyzer.Name)
+		if !ok {
+			t.Errorf("%s has no entry in the rules registry", analyzer.Name)
+			continue
+		}
+		if rule.IsDeprecated() {
+			t.Errorf("%s is in passes.AllChecks but deprecated in %s", rule.ID, rule.Deprecated)
+		}
+	}
+
+	deprecated := make(map[string]bool)
+	for _, analyzer := range passes.DeprecatedChecks {
+		deprecated[analyzer.Name] = true
+	}
+
+	for _, rule := range rules.All() {
+		if !rule.IsDeprecated() && !active[rule.ID] {
+			t.Errorf("%s is not deprecated but missing from passes.AllChecks", rule.ID)
+		}
+		if rule.IsDeprecated() && !deprecated[rule.ID] {
+			t.Errorf("%s is deprecated but missing from passes.DeprecatedChecks", rule.ID)
+		}
+	}
+}
+
+func TestRuleMetadataIsComplete(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, rule := range rules.All() {
+		if seen[rule.ID] {
+			t.Errorf("%s is registered twice", rule.ID)
+		}
+		seen[rule.ID] = true
+
+		if !strings.HasPrefix(rule.ID, rule.Category.Prefix()) || rule.Category.Title() == "" {
+			t.Errorf("%s has category %q, want one with its prefix", rule.ID, rule.Category)
+		}
+		if rule.Title == "" || rule.Since == "" || rule.MatchMode == "" {
+			t.Errorf("%s is missing its title, since version or match mode: %+v", rule.ID, rule)
+		}
+		switch rule.Severity {
+		case rules.SeverityError, rules.SeverityWarning, rules.SeverityInfo:
+		default:
+			t.Errorf("%s has severity %q", rule.ID, rule.Severity)
+		}
+		if !strings.HasPrefix(rule.Reference, "https://") {
+			t.Errorf("%s has reference %q, want a link into the contributing guide", rule.ID, rule.Reference)
+		}
+		if rule.RemovedIn != "" && !rule.IsDeprecated() {
+			t.Errorf("%s is removed in %s without being deprecated", rule.ID, rule.RemovedIn)
+		}
+	}
+}
+
+func TestMatchesSelectorAcceptsCheckIDsAndCategoryPrefixes(t *testing.T) {
+	tests := map[string]bool{
+		"AZNR001":   true,
+		" aznr001 ": true,
+		"AZNR":      true,
+		"aznr":      true,
+		"AZBP":      false,
+		"AZNR00":    false,
+		"AZN":       false,
+		"A":         false,
+		"":          false,
+		"AZNR0011":  false,
+	}
+
+	for selector, want := range tests {
+		if got := rules.MatchesSelector("AZNR001", selector); got != want {
+			t.Errorf("MatchesSelector(AZNR001, %q) = %v, want %v", selector, got, want)
+		}
+	}
+}
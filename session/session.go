@@ -0,0 +1,170 @@
+// Package session holds the state of one linter run: the changes to filter by, the configuration
+// of the checks and the metadata of reported diagnostics. Analyzers receive it as the result of
+// the session Analyzer, so several runs can share a process without package-level state.
+package session
+
+import (
+	"reflect"
+
+	"github.com/qixialu/azurerm-linter/loader"
+	"github.com/qixialu/azurerm-linter/reporting"
+	"golang.org/x/tools/go/analysis"
+)
+
+// Session is the per-run state shared by the analyzers. A nil Session is valid and means
+// no change filtering, as when analyzers run under analysistest or a driver other than this
+// linter.
+type Session struct {
+	changes     *loader.ChangeSet
+	config      Config
+	diagnostics *reporting.Registry
+}
+
+// Config configures the checks of a run. The zero Config keeps the values of the analyzer flags
+// and the default package path fragments skipped by resource analysis.
+type Config struct {
+	// Settings are per-check options, keyed by check ID and then by analyzer flag name, in the
+	// string form flag.Value accepts. Options without a setting keep the value of their flag.
+	Settings map[string]map[string]string
+
+	// SkipPackages replaces the package path fragments skipped by resource analysis;
+	// nil keeps helper.DefaultSkipPackages
+	SkipPackages []string
+}
+
+// New creates a Session filtering by changes; nil changes disable filtering
+func New(changes *loader.ChangeSet) *Session {
+	return NewWithConfig(changes, Config{})
+}
+
+// NewWithConfig creates a Session filtering by changes whose checks are configured by config
+func NewWithConfig(changes *loader.ChangeSet, config Config) *Session {
+	return &Session{
+		changes:     changes,
+		config:      config,
+		diagnostics: reporting.NewRegistry(),
+	}
+}
+
+// Analyzer provides the Session to the analyzers requiring it. Unbound, it provides a nil Session.
+var Analyzer = &analysis.Analyzer{
+	Name:             "azurermsession",
+	Doc:              "provides the state of the current azurerm-linter run",
+	Run:              func(*analysis.Pass) (interface{}, error) { return (*Session)(nil), nil },
+	RunDespiteErrors: true,
+	ResultType:       reflect.TypeOf((*Session)(nil)),
+}
+
+// From returns the Session of the run pass belongs to. pass.Analyzer must require Analyzer.
+func From(pass *analysis.Pass) *Session {
+	s, _ := pass.ResultOf[Analyzer].(*Session)
+	return s
+}
+
+// Bind returns copies of analyzers that receive s from Analyzer. Analyzers that do not depend
+// on Analyzer, directly or through their requirements, are returned as is.
+//
+// The copies run the original Run functions with ResultOf keyed by the original analyzers,
+// so analyzers keep looking up their requirements as usual.
+func Bind(analyzers []*analysis.Analyzer, s *Session) []*analysis.Analyzer {
+	provider := *Analyzer
+	provider.Run = func(*analysis.Pass) (interface{}, error) { return s, nil }
+
+	bound := map[*analysis.Analyzer]*analysis.Analyzer{Analyzer: &provider}
+	original := map[*analysis.Analyzer]*analysis.Analyzer{&provider: Analyzer}
+
+	var bind func(a *analysis.Analyzer) *analysis.Analyzer
+	bind = func(a *analysis.Analyzer) *analysis.Analyzer {
+		if b, ok := bound[a]; ok {
+			return b
+		}
+
+		requires := make([]*analysis.Analyzer, len(a.Requires))
+		changed := false
+		for i, req := range a.Requires {
+			requires[i] = bind(req)
+			changed = changed || requires[i] != req
+		}
+		if !changed {
+			bound[a] = a
+			return a
+		}
+
+		b := *a
+		b.Requires = requires
+		b.Run = func(pass *analysis.Pass) (interface{}, error) {
+			p := *pass
+			p.Analyzer = a
+			p.ResultOf = make(map[*analysis.Analyzer]interface{}, len(pass.ResultOf))
+			for req, result := range pass.ResultOf {
+				if orig, ok := original[req]; ok {
+					req = orig
+				}
+				p.ResultOf[req] = result
+			}
+			return a.Run(&p)
+		}
+
+		bound[a] = &b
+		original[&b] = a
+		return &b
+	}
+
+	result := make([]*analysis.Analyzer, len(analyzers))
+	for i, a := range analyzers {
+		result[i] = bind(a)
+	}
+	return result
+}
+
+// Changes returns the changes findings are filtered by, or nil when filtering is disabled
+func (s *Session) Changes() *loader.ChangeSet {
+	if s == nil {
+		return nil
+	}
+	return s.changes
+}
+
+// IsFileChanged checks if a file has any changes. Every file counts as changed without filtering.
+func (s *Session) IsFileChanged(filename string) bool {
+	return s.Changes().IsFileChanged(filename)
+}
+
+// IsNewFile checks if a file is newly added. Every file counts as new without filtering.
+func (s *Session) IsNewFile(filename string) bool {
+	return s.Changes().IsNewFile(filename)
+}
+
+// Setting returns the value of the option name of check checkID set for this run, if any
+func (s *Session) Setting(checkID, name string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	value, ok := s.config.Settings[checkID][name]
+	return value, ok
+}
+
+// SkipPackages returns the package path fragments skipped by resource analysis in this run,
+// or nil for the default ones
+func (s *Session) SkipPackages() []string {
+	if s == nil {
+		return nil
+	}
+	return s.config.SkipPackages
+}
+
+// Diagnostics returns the metadata recorded for the diagnostics reported in this run
+func (s *Session) Diagnostics() *reporting.Registry {
+	if s == nil {
+		return nil
+	}
+	return s.diagnostics
+}
+
+// RecordDiagnostic implements reporting.Recorder
+func (s *Session) RecordDiagnostic(meta reporting.DiagnosticMeta) {
+	if s == nil {
+		return
+	}
+	s.diagnostics.Record(meta)
+}
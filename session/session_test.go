@@ -0,0 +1,69 @@
+package session
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/qixialu/azurerm-linter/reporting"
+	"golang.org/x/tools/go/analysis"
+)
+
+func TestBindProvidesSessionUnderOriginalKeys(t *testing.T) {
+	dep := &analysis.Analyzer{
+		Name:       "dep",
+		Doc:        "dependency without a session",
+		Run:        func(*analysis.Pass) (interface{}, error) { return "dep", nil },
+		ResultType: reflect.TypeOf(""),
+	}
+
+	var gotSession *Session
+	var gotDep interface{}
+	check := &analysis.Analyzer{
+		Name:     "check",
+		Doc:      "check requiring the session",
+		Requires: []*analysis.Analyzer{dep, Analyzer},
+		Run: func(pass *analysis.Pass) (interface{}, error) {
+			gotSession, gotDep = From(pass), pass.ResultOf[dep]
+			return nil, nil
+		},
+	}
+
+	s := New(nil)
+	bound := Bind([]*analysis.Analyzer{check, dep}, s)
+
+	if bound[0] == check || bound[0].Requires[0] != dep || bound[0].Requires[1] == Analyzer {
+		t.Fatalf("Bind() should copy check and replace only its session requirement")
+	}
+	if bound[1] != dep {
+		t.Fatalf("Bind() copied an analyzer that does not depend on the session")
+	}
+
+	provider := bound[0].Requires[1]
+	result, err := provider.Run(&analysis.Pass{})
+	if err != nil {
+		t.Fatalf("provider Run() error = %v", err)
+	}
+	if _, err := bound[0].Run(&analysis.Pass{ResultOf: map[*analysis.Analyzer]interface{}{dep: "dep", provider: result}}); err != nil {
+		t.Fatalf("bound Run() error = %v", err)
+	}
+	if gotSession != s || gotDep != "dep" {
+		t.Fatalf("bound analyzer got session %p and dep %v, want %p and the dep result", gotSession, gotDep, s)
+	}
+
+	// The original analyzer is unaffected and sees the unbound, nil session
+	unbound, _ := Analyzer.Run(&analysis.Pass{})
+	if _, err := check.Run(&analysis.Pass{ResultOf: map[*analysis.Analyzer]interface{}{dep: "dep", Analyzer: unbound}}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if gotSession != nil {
+		t.Fatalf("unbound analyzer got session %p, want nil", gotSession)
+	}
+}
+
+func TestNilSessionDisablesFiltering(t *testing.T) {
+	var s *Session
+	if !s.IsFileChanged("internal/services/cdn/cdn_profile_resource.go") || !s.IsNewFile("internal/services/cdn/cdn_profile_resource.go") {
+		t.Fatalf("nil session should treat every file as changed and new")
+	}
+	s.RecordDiagnostic(reporting.DiagnosticMeta{Message: "ignored"})
+}